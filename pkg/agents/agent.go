@@ -0,0 +1,58 @@
+package agents
+
+import (
+	"context"
+	"time"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+// Summarizer is the interface for summarizer.
+type Summarizer interface {
+	// Summary summarizes the given content.
+	Summary(ctx context.Context, post apitypes.Post) (*SummaryOutput, error)
+}
+
+// Scorer is the interface for relevance scorer.
+type Scorer interface {
+	// Score rates the post against the configured interest profiles, it
+	// returns nil if there isn't any profile.
+	Score(ctx context.Context, post apitypes.Post) (*apitypes.Relevance, error)
+}
+
+// Answerer is the interface for question answering over the stored summaries.
+type Answerer interface {
+	// Answer answers the question grounded in the posts, which are usually
+	// retrieved by search. The answer cites the post urls.
+	Answer(ctx context.Context, question string, posts []*storage.SummaryResult) (*Answer, error)
+}
+
+// Answer is the output of Answerer.
+type Answer struct {
+	// Found is false if the posts have nothing relevant to the question
+	Found bool `json:"found"`
+	// Text is the answer which references the citations
+	Text string `json:"answer"`
+	// Citations is the urls of posts which support the answer
+	Citations []string `json:"citations"`
+}
+
+// SummaryOutput is the structured output of Summarizer.
+type SummaryOutput struct {
+	// Summary is the summary text in the first configured language.
+	Summary string
+
+	// Summaries is the summary text keyed by language, e.g. zh-CN, en.
+	Summaries map[string]string
+
+	// Title and PublishedAt are extracted from the post, they are used when
+	// the collector doesn't provide them.
+	Title       string
+	PublishedAt time.Time
+
+	// Model is the provider/model which produces the output.
+	Model string
+
+	apitypes.SummaryDetail
+}
@@ -7,7 +7,14 @@ import (
 	"github.com/cloudwego/eino/schema"
 )
 
+// extractMessageText return the text of llm response, the markdown code
+// fence is trimmed because the providers without json mode may wrap the json
+// object in it.
 func extractMessageText(msg *schema.Message) string {
+	return trimCodeFence(messageText(msg))
+}
+
+func messageText(msg *schema.Message) string {
 	if msg == nil {
 		return ""
 	}
@@ -26,3 +33,16 @@ func extractMessageText(msg *schema.Message) string {
 	}
 	return strings.Join(parts, "")
 }
+
+func trimCodeFence(text string) string {
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, "```") || !strings.HasSuffix(trimmed, "```") || len(trimmed) < 6 {
+		return text
+	}
+	trimmed = strings.TrimSuffix(trimmed[3:], "```")
+	// The info string of fence, e.g. json
+	if i := strings.IndexByte(trimmed, '\n'); i >= 0 && !strings.ContainsAny(trimmed[:i], "{[") {
+		trimmed = trimmed[i+1:]
+	}
+	return strings.TrimSpace(trimmed)
+}
@@ -2,17 +2,26 @@ package agents
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/onsi/gomega"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+	"github.com/anyvoxel/vela/pkg/storage"
+	"github.com/anyvoxel/vela/test"
 )
 
 func TestNewSummarizer(t *testing.T) {
 	g := gomega.NewWithT(t)
 	s := &summarizerImpl{
 		summarizeType: "image",
+		chatModels:    fakeChain(&fakeChatModel{}),
 	}
 	err := s.AfterPropertiesSet(context.Background())
 	g.Expect(err).ToNot(gomega.HaveOccurred())
@@ -70,3 +79,262 @@ func TestTruncateForLog_UTF8Safe(t *testing.T) {
 	got = truncateForLog(s, 9)
 	g.Expect(got).To(gomega.Equal("你好世...(truncated)"))
 }
+
+func TestTruncateUTF8(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	// 3 bytes per Chinese rune in UTF-8
+	s := "你好世界abc"
+
+	g.Expect(truncateUTF8(s, 4)).To(gomega.Equal("你"))
+	g.Expect(truncateUTF8(s, 6)).To(gomega.Equal("你好"))
+	g.Expect(truncateUTF8(s, 13)).To(gomega.Equal("你好世界a"))
+	g.Expect(truncateUTF8(s, 2)).To(gomega.BeEmpty())
+	g.Expect(truncateUTF8(s, len(s))).To(gomega.Equal(s))
+	g.Expect(truncateUTF8(s, 0)).To(gomega.Equal(s))
+}
+
+func TestRenderSystemPrompt(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	prompt := renderSystemPrompt(systemPrompts, []string{"zh-CN", "en", "pt-BR"}, nil)
+	g.Expect(prompt).ToNot(gomega.ContainSubstring("{{LANGUAGES}}"))
+	g.Expect(prompt).To(gomega.ContainSubstring("`zh-CN` (simplified chinese), `en` (english), `pt-BR`"))
+}
+
+func TestParseSummaryOutput(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+
+	s := &summarizerImpl{languages: []string{"en", "zh-CN", "ja"}}
+	output, err := s.parseSummaryOutput(ctx,
+		`{"error":"","summaries":{"zh-CN":"中文摘要","en":" english summary "}}`)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(output.Summary).To(gomega.Equal("english summary"))
+	g.Expect(output.Summaries).To(gomega.Equal(map[string]string{
+		"zh-CN": "中文摘要",
+		"en":    "english summary",
+	}))
+
+	s = &summarizerImpl{languages: []string{"zh-CN"}}
+	output, err = s.parseSummaryOutput(ctx, `{"error":"","summary":"摘要"}`)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(output.Summary).To(gomega.Equal("摘要"))
+
+	_, err = s.parseSummaryOutput(ctx, `{"error":"page not found"}`)
+	g.Expect(err).To(gomega.MatchError("page not found"))
+
+	output, err = s.parseSummaryOutput(ctx, `{"error":"","summary":"摘要","title":" Raft ","published_at":"2025-05-08"}`)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(output.Title).To(gomega.Equal("Raft"))
+	g.Expect(output.PublishedAt).To(gomega.Equal(time.Date(2025, 5, 8, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestParseSummaryOutput_SummaryDetail(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	s := &summarizerImpl{
+		languages: []string{"en"},
+		taxonomy:  taxonomy{{Name: "consensus"}, {Name: "databases"}},
+	}
+	output, err := s.parseSummaryOutput(context.Background(), `{
+		"error": "",
+		"summaries": {"en": "summary"},
+		"thesis": " Raft is understandable ",
+		"key_points": ["leader election", "", "log replication", "leader election"],
+		"takeaways": ["use raft"],
+		"audience": "distributed system engineers",
+		"tags": ["Consensus", "unknown"],
+		"keywords": ["consensus", "raft"],
+		"reading_time_minutes": 12,
+		"content_type": "Deep_Dive",
+		"paper": {"authors": [" "], "venue": ""}
+	}`)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(output.SummaryDetail).To(gomega.Equal(apitypes.SummaryDetail{
+		Thesis:             "Raft is understandable",
+		KeyPoints:          []string{"leader election", "log replication"},
+		Takeaways:          []string{"use raft"},
+		Audience:           "distributed system engineers",
+		Tags:               []string{"consensus"},
+		Keywords:           []string{"consensus", "raft"},
+		ReadingTimeMinutes: 12,
+		ContentType:        apitypes.ContentTypeDeepDive,
+	}))
+
+	output, err = s.parseSummaryOutput(context.Background(),
+		`{"summaries": {"en": "summary"}, "content_type": "podcast"}`)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(output.ContentType).To(gomega.Equal(apitypes.ContentTypeOther))
+}
+
+func TestLoadTaxonomy(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	tags, err := loadTaxonomy(filepath.Join(test.CurrentProjectPath(), "taxonomy.json"))
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(tags).ToNot(gomega.BeEmpty())
+	g.Expect(tags.prompt()).To(gomega.ContainSubstring("- `consensus`: "))
+
+	tags, err = loadTaxonomy("")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(tags).To(gomega.BeEmpty())
+	g.Expect(tags.filter([]string{"consensus"})).To(gomega.BeEmpty())
+}
+
+func TestParseScoreResult(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	relevance, err := parseScoreResult(`{"error":"","scores":[` +
+		`{"profile":"storage","score":42,"reason":"mentions raft"},` +
+		`{"profile":" llm ","score":120,"reason":" about agents "}]}`)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(relevance).To(gomega.Equal(&apitypes.Relevance{Score: 100, Profile: "llm", Reason: "about agents"}))
+
+	_, err = parseScoreResult(`{"error":"empty page","scores":[]}`)
+	g.Expect(err).To(gomega.MatchError(errScorerResponse))
+
+	_, err = parseScoreResult(`{"error":"","scores":[]}`)
+	g.Expect(err).To(gomega.MatchError(errScorerResponse))
+}
+
+func TestScorer_WithoutProfiles(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	s := &scorerImpl{}
+	g.Expect(s.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+	relevance, err := s.Score(context.Background(), apitypes.Post{Path: "https://example.com"})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(relevance).To(gomega.BeNil())
+}
+
+func TestScorer_PostPages(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><body><nav>menu</nav><h1>Raft</h1><p>Leader election.</p></body></html>`))
+	}))
+	defer server.Close()
+
+	scorerModel := &fakeChatModel{response: `{"error":"","scores":[{"profile":"storage","score":80,"reason":"raft"}]}`}
+	scorer := &scorerImpl{
+		chatModels: fakeChain(scorerModel),
+		profiles:   []interestProfile{{Name: "storage", Description: "databases"}},
+	}
+	summarizerModel := &fakeChatModel{response: `{"summaries":{"en":"The post explains raft."}}`}
+	summarizer := &summarizerImpl{
+		chatModels:    fakeChain(summarizerModel),
+		client:        server.Client(),
+		summarizeType: "markdown",
+		languages:     []string{"en"},
+		taxonomyFile:  filepath.Join(test.CurrentProjectPath(), "taxonomy.json"),
+	}
+	g.Expect(summarizer.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+
+	post := apitypes.Post{Domain: "example", Path: server.URL + "/blog/raft.html"}
+	// The page is fetched by both of them unless the context is WithPostPages
+	for ctx, expected := range map[context.Context]int{
+		context.Background():                2,
+		WithPostPages(context.Background()): 1,
+	} {
+		requests = 0
+		relevance, err := scorer.Score(ctx, post)
+		g.Expect(err).ToNot(gomega.HaveOccurred())
+		g.Expect(relevance.Score).To(gomega.Equal(80))
+		output, err := summarizer.Summary(ctx, post)
+		g.Expect(err).ToNot(gomega.HaveOccurred())
+		g.Expect(output.Summary).To(gomega.Equal("The post explains raft."))
+		g.Expect(requests).To(gomega.Equal(expected))
+		g.Expect(scorerModel.inputs[len(scorerModel.inputs)-1][1].Content).
+			To(gomega.HaveSuffix("Content:\n# Raft\n\nLeader election."))
+		g.Expect(summarizerModel.inputs[len(summarizerModel.inputs)-1][1].Content).
+			To(gomega.ContainSubstring("Leader election."))
+	}
+}
+
+func TestLoadInterestProfiles(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "profiles.json")
+	g.Expect(os.WriteFile(filePath, []byte(`[{"name":" storage ","description":"databases"}]`), 0o600)).
+		To(gomega.Succeed())
+	profiles, err := loadInterestProfiles(filePath)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(profiles).To(gomega.Equal([]interestProfile{{Name: "storage", Description: "databases"}}))
+
+	g.Expect(os.WriteFile(filePath, []byte(`[{"name":"","description":"databases"}]`), 0o600)).
+		To(gomega.Succeed())
+	_, err = loadInterestProfiles(filePath)
+	g.Expect(err).To(gomega.MatchError(errInterestProfileNameEmpty))
+}
+
+func TestFetchArticleMarkdown(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><body><nav>menu</nav><h1>Raft</h1><p>Leader election.</p></body></html>`))
+	}))
+	defer server.Close()
+
+	text, err := fetchArticleMarkdown(context.Background(), server.Client(), server.URL+"/post", 1024)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(text).To(gomega.Equal("# Raft\n\nLeader election."))
+
+	_, err = fetchArticleMarkdown(context.Background(), server.Client(), server.URL+"/missing", 1024)
+	g.Expect(err).To(gomega.MatchError(errArticleResponse))
+}
+
+func TestParseAnswerResult(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	posts := []*storage.SummaryResult{
+		{Title: "Raft", Path: "https://brooker.co.za/blog/1"},
+		{Title: "Paxos", Path: "https://charap.co/2"},
+	}
+
+	answer, err := parseAnswerResult(`{"error":"","found":true,"answer":" Use snapshots [1]. ",`+
+		`"citations":["https://brooker.co.za/blog/1","https://example.com/unknown","https://brooker.co.za/blog/1"]}`, posts)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(answer).To(gomega.Equal(&Answer{
+		Found:     true,
+		Text:      "Use snapshots [1].",
+		Citations: []string{"https://brooker.co.za/blog/1"},
+	}))
+
+	answer, err = parseAnswerResult(`{"error":"","found":false,"answer":"","citations":[]}`, posts)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(answer.Found).To(gomega.BeFalse())
+
+	_, err = parseAnswerResult(`{"error":"","found":true,"answer":"made up","citations":["https://example.com"]}`, posts)
+	g.Expect(err).To(gomega.MatchError(errAnswererResponse))
+}
+
+func TestAnswerer_WithoutPosts(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	a := &answererImpl{}
+	answer, err := a.Answer(context.Background(), "what about raft?", nil)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(answer.Found).To(gomega.BeFalse())
+}
+
+func TestAnswerer_LazyChatModels(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+	t.Setenv("LLM_PROVIDER_ANSWERER", "unknown")
+
+	// The misconfigured provider only fails the answer
+	a := &answererImpl{}
+	g.Expect(a.AfterPropertiesSet(ctx)).To(gomega.Succeed())
+	_, err := a.Answer(ctx, "what about raft?", []*storage.SummaryResult{{Path: "https://brooker.co.za/blog/1"}})
+	g.Expect(err).To(gomega.MatchError(errUnknownProvider))
+}
@@ -0,0 +1,195 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/anyvoxel/airmid/anvil"
+	airapp "github.com/anyvoxel/airmid/app"
+	"github.com/anyvoxel/airmid/ioc"
+	slogctx "github.com/veqryn/slog-context"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/anyvoxel/vela/pkg/fetch"
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+func init() {
+	anvil.Must(airapp.RegisterBeanDefinition(
+		"vela.agents.answerer",
+		ioc.MustNewBeanDefinition(
+			reflect.TypeFor[*answererImpl](),
+		),
+	))
+}
+
+const answererSystemPrompt = `You are a careful research assistant for an engineering team.
+You are given a question and several sources. Each source is a blog post the team has read, with its url,
+summary and an excerpt of the original article in markdown.
+
+Return ONLY valid JSON object with this schema:
+{"error":"", "found":true, "answer":"", "citations":[""]}
+
+Rules:
+- Answer ONLY with facts stated in the sources, never use your own knowledge.
+- Cite the sources inline as [n] where n is the source number, and put the url of every cited source in citations.
+- If the sources have nothing relevant to the question, return {"error":"", "found":false, "answer":"", "citations":[]}.
+- If the sources only partially answer the question, answer that part and say what is not covered.
+- Answer in the language of the question, in at most 300 words.
+- Never include any explanation or extra text.
+`
+
+const (
+	// maxAnswerArticleBytes is the max bytes of each article excerpt sent to llm.
+	maxAnswerArticleBytes = 4 * 1024
+)
+
+var errAnswererResponse = errors.New("answerer response error")
+
+// answererImpl is an agent that answers question over the stored summaries.
+type answererImpl struct {
+	cache        *responseCache `airmid:"autowire:vela.agents.responseCache"`
+	policy       *fetch.Policy  `airmid:"autowire:vela.fetch.policy"`
+	systemPrompt string
+
+	// chatModels is built at the first answer, so the other commands don't
+	// require the ANSWERER provider. It's injected in tests
+	mu         sync.Mutex
+	chatModels chatModelChain
+}
+
+var (
+	_ ioc.InitializingBean = (*answererImpl)(nil)
+	_ Answerer             = (*answererImpl)(nil)
+)
+
+// AfterPropertiesSet implement InitializingBean
+func (a *answererImpl) AfterPropertiesSet(_ context.Context) error {
+	a.systemPrompt = answererSystemPrompt
+	return nil
+}
+
+// models return the chat models, the failed creation is retried by the next
+// answer.
+func (a *answererImpl) models(ctx context.Context) (chatModelChain, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.chatModels == nil {
+		chatModels, err := newChatModelChain(ctx, "ANSWERER", a.cache)
+		if err != nil {
+			return nil, err
+		}
+		a.chatModels = chatModels
+	}
+	return a.chatModels, nil
+}
+
+type answerResult struct {
+	Error     string   `json:"error"`
+	Found     bool     `json:"found"`
+	Answer    string   `json:"answer"`
+	Citations []string `json:"citations"`
+}
+
+// Answer implement Answerer.Answer
+func (a *answererImpl) Answer(
+	ctx context.Context, question string, posts []*storage.SummaryResult,
+) (*Answer, error) {
+	if len(posts) == 0 {
+		return &Answer{}, nil
+	}
+	chatModels, err := a.models(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	excerpts := make([]string, 0, len(posts))
+	for _, post := range posts {
+		excerpt, err := fetchArticleMarkdown(ctx, a.policy.Client(post.Domain), post.Path, maxAnswerArticleBytes)
+		if err != nil {
+			// The summary is still helpful to answer the question
+			slogctx.FromCtx(ctx).WarnContext(ctx,
+				"fetch article for answerer failed",
+				slog.String("Path", post.Path),
+				slog.Any("Error", err))
+		}
+		excerpts = append(excerpts, excerpt)
+	}
+
+	var answer *Answer
+	_, err = chatModels.generate(ctx, a.systemPrompt, &schema.Message{
+		Role:    schema.User,
+		Content: buildAnswererMessage(question, posts, excerpts),
+	}, func(text string) (err error) {
+		answer, err = parseAnswerResult(text, posts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+func buildAnswererMessage(question string, posts []*storage.SummaryResult, excerpts []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Question: %s\n\nSources:\n", question)
+	for i, post := range posts {
+		fmt.Fprintf(&b, "\n[%d] %s\nURL: %s\n", i+1, post.Title, post.Path)
+		if post.Thesis != "" {
+			fmt.Fprintf(&b, "Thesis: %s\n", post.Thesis)
+		}
+		if post.Summary != "" {
+			fmt.Fprintf(&b, "Summary: %s\n", post.Summary)
+		}
+		if excerpts[i] != "" {
+			fmt.Fprintf(&b, "Excerpt:\n%s\n", excerpts[i])
+		}
+	}
+	return b.String()
+}
+
+// parseAnswerResult drop the citations which are not one of the posts, so
+// the answer is always grounded in the stored data.
+func parseAnswerResult(text string, posts []*storage.SummaryResult) (*Answer, error) {
+	var result answerResult
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("%w: %s", errAnswererResponse, result.Error)
+	}
+
+	answer := strings.TrimSpace(result.Answer)
+	if !result.Found || answer == "" {
+		return &Answer{}, nil
+	}
+
+	citations := make([]string, 0, len(result.Citations))
+	for _, citation := range result.Citations {
+		citation = strings.TrimSpace(citation)
+		if slices.Contains(citations, citation) {
+			continue
+		}
+		if !slices.ContainsFunc(posts, func(post *storage.SummaryResult) bool { return post.Path == citation }) {
+			continue
+		}
+		citations = append(citations, citation)
+	}
+	if len(citations) == 0 {
+		return nil, fmt.Errorf("%w: answer without citation of sources", errAnswererResponse)
+	}
+
+	return &Answer{
+		Found:     true,
+		Text:      answer,
+		Citations: citations,
+	}, nil
+}
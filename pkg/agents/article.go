@@ -0,0 +1,181 @@
+package agents
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+)
+
+const (
+	// maxArticleBodyBytes is the max bytes read from the article page.
+	maxArticleBodyBytes = 5 * 1024 * 1024
+)
+
+var errArticleResponse = errors.New("unexpected article response")
+
+// fetchArticleMarkdown fetch the post page without rendering it in chrome, and
+// convert the html to markdown which is truncated to maxBytes. It's used when
+// the agent only needs the text of the post.
+func fetchArticleMarkdown(ctx context.Context, client *http.Client, path string, maxBytes int) (string, error) {
+	html, err := fetchArticleHTML(ctx, client, path)
+	if err != nil {
+		return "", err
+	}
+	return articleMarkdown(html, path, maxBytes)
+}
+
+// postPage is the post fetched by a single GET, it's used to detect the pdf
+// and media before the post is summarized.
+type postPage struct {
+	contentType string
+	// pdf is the data if the post links to a pdf
+	pdf []byte
+	// html is the page if the response is html, the body of other content
+	// types is not read
+	html string
+}
+
+type postPagesKey struct{}
+
+// postPages are the pages fetched in a context, keyed by the post path.
+type postPages struct {
+	mu    sync.Mutex
+	pages map[string]*fetchedPage
+}
+
+type fetchedPage struct {
+	once sync.Once
+	page *postPage
+	err  error
+}
+
+// WithPostPages return a context whose agents fetch each post page once,
+// e.g. the post is scored and summarized by the same page.
+func WithPostPages(ctx context.Context) context.Context {
+	return context.WithValue(ctx, postPagesKey{}, &postPages{pages: make(map[string]*fetchedPage)})
+}
+
+// fetchPostPage fetch the post without rendering it, the page is reused if
+// the context is WithPostPages. The pdf is detected by the Content-Type or its
+// magic bytes, e.g. the sites may response it as application/octet-stream, and
+// the path ends with .pdf must be a pdf. The pdf larger than maxPDFBytes is
+// rejected.
+func fetchPostPage(ctx context.Context, client *http.Client, path string, maxPDFBytes int64) (*postPage, error) {
+	pages, ok := ctx.Value(postPagesKey{}).(*postPages)
+	if !ok {
+		return doFetchPostPage(ctx, client, path, maxPDFBytes)
+	}
+
+	pages.mu.Lock()
+	fetched, ok := pages.pages[path]
+	if !ok {
+		fetched = &fetchedPage{}
+		pages.pages[path] = fetched
+	}
+	pages.mu.Unlock()
+	fetched.once.Do(func() {
+		fetched.page, fetched.err = doFetchPostPage(ctx, client, path, maxPDFBytes)
+	})
+	return fetched.page, fetched.err
+}
+
+func doFetchPostPage(ctx context.Context, client *http.Client, path string, maxPDFBytes int64) (*postPage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	pdfPath := isPDFPath(path)
+	if pdfPath {
+		req.Header.Set("Accept", "application/pdf")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%w: status %d", errArticleResponse, resp.StatusCode)
+	}
+	page := &postPage{contentType: resp.Header.Get("Content-Type")}
+	body := bufio.NewReader(resp.Body)
+	// The read error is returned by the reading below
+	magic, _ := body.Peek(len(pdfMagic))
+	switch {
+	case pdfPath || isPDFContentType(page.contentType) || bytes.Equal(magic, pdfMagic):
+		page.pdf, err = readPaperPDF(resp, body, maxPDFBytes)
+		if err != nil {
+			return nil, err
+		}
+	case page.contentType == "" || strings.Contains(page.contentType, "html"):
+		b, err := io.ReadAll(io.LimitReader(body, maxArticleBodyBytes))
+		if err != nil {
+			return nil, err
+		}
+		page.html = string(b)
+	}
+	return page, nil
+}
+
+// fetchArticleHTML fetch the html of post page without rendering it.
+func fetchArticleHTML(ctx context.Context, client *http.Client, path string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close() //nolint
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("%w: status %d", errArticleResponse, resp.StatusCode)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !strings.Contains(contentType, "html") {
+		return "", fmt.Errorf("%w: content type %q", errArticleResponse, contentType)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxArticleBodyBytes))
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// articleMarkdown convert the html of post page to markdown without the
+// navigation, which is truncated to maxBytes.
+func articleMarkdown(html string, path string, maxBytes int) (string, error) {
+	text, err := md.NewConverter(md.DomainFromURL(path), true, nil).
+		Remove("nav", "header", "footer", "aside", "form", "noscript", "svg").
+		ConvertString(html)
+	if err != nil {
+		return "", err
+	}
+	return truncateUTF8(strings.TrimSpace(text), maxBytes), nil
+}
+
+// truncateUTF8 return the prefix of s within n bytes which doesn't cut a
+// rune, it's used for the prompt content, the logs use truncateForLog. The s
+// is not truncated if n is not positive.
+func truncateUTF8(s string, n int) string {
+	if n <= 0 || len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,212 @@
+package agents
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/anyvoxel/airmid/anvil"
+	airapp "github.com/anyvoxel/airmid/app"
+	"github.com/anyvoxel/airmid/ioc"
+	"github.com/cloudwego/eino/schema"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+func init() {
+	anvil.Must(airapp.RegisterBeanDefinition(
+		"vela.agents.responseCache",
+		ioc.MustNewBeanDefinition(
+			reflect.TypeFor[*responseCache](),
+		),
+	))
+}
+
+// responseCache is the on-disk cache of the accepted llm responses, it's
+// keyed by the hash of model, system prompt, user message and attachments.
+// The cache is disabled if the dir is empty.
+type responseCache struct {
+	dir string        `airmid:"value:${vela.llm.cache.dir:=}"`
+	ttl time.Duration `airmid:"value:${vela.llm.cache.ttl:=168h}"`
+	// bypass will neither read nor write the cache
+	bypass bool `airmid:"value:${vela.llm.cache.bypass:=false}"`
+	// refresh will not read the cache, but the new responses are written
+	refresh bool `airmid:"value:${vela.llm.cache.refresh:=false}"`
+}
+
+// cacheEntry is the content of a cache file.
+type cacheEntry struct {
+	Model     string    `json:"model"`
+	CreatedAt time.Time `json:"created_at"`
+	Response  string    `json:"response"`
+}
+
+type cacheRefreshKey struct{}
+
+// WithCacheRefresh return a context whose llm calls don't read the cached
+// responses, e.g. the post is summarized again on request.
+func WithCacheRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheRefreshKey{}, true)
+}
+
+func (c *responseCache) enabled() bool {
+	return c != nil && c.dir != "" && !c.bypass
+}
+
+// attachmentDigestKey is the Extra key of message or part, which is the
+// sha256 of the attachment content whose url doesn't change with it, e.g. the
+// file rendered from the post is put to oss by the post path.
+const attachmentDigestKey = "vela_attachment_sha256"
+
+// cacheKey is the sha256 of the json of model and input, the attachments
+// are replaced by their sha256.
+func cacheKey(modelID string, input []*schema.Message) string {
+	type part struct {
+		Type       schema.ChatMessagePartType `json:"type"`
+		Text       string                     `json:"text,omitempty"`
+		Attachment string                     `json:"attachment,omitempty"`
+	}
+	type message struct {
+		Role       schema.RoleType `json:"role"`
+		Content    string          `json:"content"`
+		Attachment string          `json:"attachment,omitempty"`
+		Parts      []part          `json:"parts,omitempty"`
+	}
+
+	messages := make([]message, 0, len(input))
+	for _, msg := range input {
+		m := message{Role: msg.Role, Content: msg.Content}
+		m.Attachment, _ = msg.Extra[attachmentDigestKey].(string)
+		for _, p := range msg.UserInputMultiContent {
+			m.Parts = append(m.Parts, part{
+				Type:       p.Type,
+				Text:       p.Text,
+				Attachment: attachmentHash(p),
+			})
+		}
+		messages = append(messages, m)
+	}
+
+	b, _ := json.Marshal(struct { //nolint:errchkjson
+		Model    string    `json:"model"`
+		Messages []message `json:"messages"`
+	}{modelID, messages})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// attachmentHash return the sha256 of the content, url or data of
+// attachment, the content digest is preferred because the url may not change
+// with the content.
+func attachmentHash(p schema.MessageInputPart) string {
+	if digest, ok := p.Extra[attachmentDigestKey].(string); ok && digest != "" {
+		return digest
+	}
+
+	var common *schema.MessagePartCommon
+	switch {
+	case p.Image != nil:
+		common = &p.Image.MessagePartCommon
+	case p.Audio != nil:
+		common = &p.Audio.MessagePartCommon
+	case p.Video != nil:
+		common = &p.Video.MessagePartCommon
+	case p.File != nil:
+		common = &p.File.MessagePartCommon
+	default:
+		return ""
+	}
+
+	h := sha256.New()
+	if common.URL != nil {
+		h.Write([]byte(*common.URL))
+	}
+	if common.Base64Data != nil {
+		h.Write([]byte(*common.Base64Data))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *responseCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// get return the unexpired response of key.
+func (c *responseCache) get(ctx context.Context, key string) (string, bool) {
+	if !c.enabled() || c.refresh {
+		return "", false
+	}
+	if refresh, _ := ctx.Value(cacheRefreshKey{}).(bool); refresh {
+		return "", false
+	}
+
+	b, err := os.ReadFile(c.path(key))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slogctx.FromCtx(ctx).WarnContext(ctx, "read llm cache failed", slog.Any("Error", err))
+		}
+		return "", false
+	}
+	var entry cacheEntry
+	err = json.Unmarshal(b, &entry)
+	if err != nil {
+		slogctx.FromCtx(ctx).WarnContext(ctx, "decode llm cache failed", slog.Any("Error", err))
+		return "", false
+	}
+	if c.ttl > 0 && time.Since(entry.CreatedAt) > c.ttl {
+		return "", false
+	}
+	return entry.Response, true
+}
+
+// put write the response of key, the failure is logged because the cache is
+// optional.
+func (c *responseCache) put(ctx context.Context, key string, modelID string, response string) {
+	if !c.enabled() {
+		return
+	}
+
+	err := c.write(key, &cacheEntry{
+		Model:     modelID,
+		CreatedAt: time.Now().UTC(),
+		Response:  response,
+	})
+	if err != nil {
+		slogctx.FromCtx(ctx).WarnContext(ctx, "write llm cache failed", slog.Any("Error", err))
+	}
+}
+
+func (c *responseCache) write(key string, entry *cacheEntry) error {
+	filePath := c.path(key)
+	err := os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(filePath), strings.TrimSuffix(filepath.Base(filePath), ".json")+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) //nolint
+
+	_, err = f.Write(b)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filePath)
+}
@@ -0,0 +1,140 @@
+package agents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/onsi/gomega"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+)
+
+func TestCacheKey(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	image := func(url string) *schema.Message {
+		return &schema.Message{
+			Role: schema.User,
+			UserInputMultiContent: []schema.MessageInputPart{
+				{Type: schema.ChatMessagePartTypeText, Text: "summarize"},
+				{
+					Type:  schema.ChatMessagePartTypeImageURL,
+					Image: &schema.MessageInputImage{MessagePartCommon: schema.MessagePartCommon{URL: &url}},
+				},
+			},
+		}
+	}
+	input := []*schema.Message{schema.SystemMessage("system"), image("https://oss/a.png")}
+
+	key := cacheKey("openai/gpt-4o", input)
+	g.Expect(key).To(gomega.HaveLen(64))
+	g.Expect(cacheKey("openai/gpt-4o", []*schema.Message{schema.SystemMessage("system"), image("https://oss/a.png")})).
+		To(gomega.Equal(key))
+	g.Expect(cacheKey("openai/gpt-4o-mini", input)).ToNot(gomega.Equal(key))
+	g.Expect(cacheKey("openai/gpt-4o", []*schema.Message{schema.SystemMessage("other"), image("https://oss/a.png")})).
+		ToNot(gomega.Equal(key))
+	g.Expect(cacheKey("openai/gpt-4o", []*schema.Message{schema.SystemMessage("system"), image("https://oss/b.png")})).
+		ToNot(gomega.Equal(key))
+}
+
+func TestResponseCache(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+	key := cacheKey("fake/model", []*schema.Message{schema.UserMessage("hello")})
+
+	var nilCache *responseCache
+	nilCache.put(ctx, key, "fake/model", "ignored")
+	_, ok := nilCache.get(ctx, key)
+	g.Expect(ok).To(gomega.BeFalse())
+
+	c := &responseCache{dir: t.TempDir(), ttl: time.Hour}
+	_, ok = c.get(ctx, key)
+	g.Expect(ok).To(gomega.BeFalse())
+
+	c.put(ctx, key, "fake/model", `{"a":1}`)
+	text, ok := c.get(ctx, key)
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(text).To(gomega.Equal(`{"a":1}`))
+
+	_, ok = c.get(WithCacheRefresh(ctx), key)
+	g.Expect(ok).To(gomega.BeFalse())
+	_, ok = (&responseCache{dir: c.dir, ttl: time.Hour, refresh: true}).get(ctx, key)
+	g.Expect(ok).To(gomega.BeFalse())
+	_, ok = (&responseCache{dir: c.dir, ttl: time.Hour, bypass: true}).get(ctx, key)
+	g.Expect(ok).To(gomega.BeFalse())
+
+	err := c.write(key, &cacheEntry{Model: "fake/model", CreatedAt: time.Now().Add(-2 * time.Hour), Response: "old"})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	_, ok = c.get(ctx, key)
+	g.Expect(ok).To(gomega.BeFalse())
+}
+
+func TestChatModelChainGenerate_Cache(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+
+	fake := &fakeChatModel{response: `{"error":""}`}
+	chain := fakeChain(fake)
+	chain[0].cache = &responseCache{dir: t.TempDir(), ttl: time.Hour}
+	accept := func(string) error { return nil }
+
+	for range 2 {
+		id, err := chain.generate(ctx, "system", schema.UserMessage("user"), accept)
+		g.Expect(err).ToNot(gomega.HaveOccurred())
+		g.Expect(id).To(gomega.Equal("fake/model-0"))
+	}
+	g.Expect(fake.inputs).To(gomega.HaveLen(1))
+
+	_, err := chain.generate(WithCacheRefresh(ctx), "system", schema.UserMessage("user"), accept)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(fake.inputs).To(gomega.HaveLen(2))
+
+	// The bypassed chat model doesn't write the cache
+	chain[0].cache = &responseCache{dir: t.TempDir(), ttl: time.Hour, bypass: true}
+	for range 2 {
+		_, err = chain.generate(ctx, "system", schema.UserMessage("other"), accept)
+		g.Expect(err).ToNot(gomega.HaveOccurred())
+	}
+	g.Expect(fake.inputs).To(gomega.HaveLen(4))
+}
+
+func TestSummarizer_CacheRenderedContent(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+	post := apitypes.Post{Path: "https://example.com/blog/raft.html"}
+
+	for _, summarizeType := range []string{"image", "pdf"} {
+		fake := &fakeChatModel{response: `{"summaries":{"en":"The post explains raft."}}`}
+		chain := fakeChain(fake)
+		chain[0].cache = &responseCache{dir: t.TempDir(), ttl: time.Hour}
+
+		// The url of rendered file is derived from the post path, only the
+		// content changes
+		content := "v1"
+		s := &summarizerImpl{
+			chatModels:    chain,
+			summarizeType: summarizeType,
+			languages:     []string{"en"},
+			renderFn: func(context.Context, apitypes.Post, captureFunc) (*renderedFile, error) {
+				return newRenderedFile("https://oss/raft", []byte(content), func() {}), nil
+			},
+		}
+		summarize := s.summarizeByImage
+		if summarizeType == "pdf" {
+			summarize = s.summarizeByPdf
+		}
+
+		for range 2 {
+			_, err := summarize(ctx, post, nil)
+			g.Expect(err).ToNot(gomega.HaveOccurred())
+		}
+		g.Expect(fake.inputs).To(gomega.HaveLen(1), summarizeType)
+
+		content = "v2"
+		_, err := summarize(ctx, post, nil)
+		g.Expect(err).ToNot(gomega.HaveOccurred())
+		g.Expect(fake.inputs).To(gomega.HaveLen(2), summarizeType)
+	}
+}
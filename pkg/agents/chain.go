@@ -0,0 +1,130 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+var (
+	errNoChatModel   = errors.New("no chat model")
+	errEmptyResponse = errors.New("empty response from llm")
+)
+
+// chatModel is a chat model created from the LLM_*_<name> env.
+type chatModel struct {
+	// name is the suffix of env, e.g. SUMMARIZER
+	name string
+	// id is the provider/model which is recorded as the producer of result
+	id string
+	// cache is nil if the responses are not cached
+	cache *responseCache
+
+	model.BaseChatModel
+}
+
+// chatModelChain is the ordered fallback chain of chat models.
+type chatModelChain []*chatModel
+
+// newChatModelChain create the chain of the suffix, it starts with the model
+// of LLM_*_<suffix> env and falls back to the models named by the
+// LLM_FALLBACKS_<suffix> env in order, e.g. LLM_FALLBACKS_SUMMARIZER=CHEAP
+// falls back to the model of LLM_*_CHEAP env.
+func newChatModelChain(ctx context.Context, suffix string, cache *responseCache) (chatModelChain, error) {
+	names := append([]string{suffix}, splitNames(os.Getenv("LLM_FALLBACKS_"+suffix))...)
+	chain := make(chatModelChain, 0, len(names))
+	for _, name := range names {
+		m, err := newChatModel(ctx, name, cache)
+		if err != nil {
+			return nil, fmt.Errorf("create chat model %s failed: %w", name, err)
+		}
+		chain = append(chain, m)
+	}
+	return chain, nil
+}
+
+// splitNames split the comma separated model names, the names are upper
+// cased as the env suffix.
+func splitNames(value string) []string {
+	names := make([]string, 0)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if name == "" || slices.Contains(names, name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// generate send the system prompt and user message to the chat models in
+// order until the response is accepted by parse, the failed models (e.g. rate
+// limited or refused) fall back to the next one. It returns the id of model
+// which produces the accepted response.
+func (c chatModelChain) generate(
+	ctx context.Context, systemPrompt string, userMessage *schema.Message, parse func(text string) error,
+) (string, error) {
+	if len(c) == 0 {
+		return "", errNoChatModel
+	}
+
+	input := []*schema.Message{
+		{
+			Role:    schema.System,
+			Content: systemPrompt,
+		},
+		userMessage,
+	}
+	errs := make([]error, 0, len(c))
+	for _, m := range c {
+		err := generateBy(ctx, m, input, parse)
+		if err == nil {
+			return m.id, nil
+		}
+		slogctx.FromCtx(ctx).WarnContext(ctx, "generate by chat model failed",
+			slog.String("Model", m.id),
+			slog.Any("Error", err),
+		)
+		errs = append(errs, fmt.Errorf("%s: %w", m.id, err))
+	}
+	return "", errors.Join(errs...)
+}
+
+// generateBy generate the response by the chat model, the cached response
+// is used if it's still accepted by parse.
+func generateBy(ctx context.Context, m *chatModel, input []*schema.Message, parse func(text string) error) error {
+	key := cacheKey(m.id, input)
+	if text, ok := m.cache.get(ctx, key); ok {
+		err := parse(text)
+		if err == nil {
+			slogctx.FromCtx(ctx).InfoContext(ctx, "use cached llm response",
+				slog.String("Model", m.id), slog.String("Key", key))
+			return nil
+		}
+		slogctx.FromCtx(ctx).WarnContext(ctx, "cached llm response is not accepted",
+			slog.String("Model", m.id), slog.Any("Error", err))
+	}
+
+	resp, err := m.Generate(ctx, input)
+	if err != nil {
+		return err
+	}
+	text := extractMessageText(resp)
+	if text == "" {
+		return errEmptyResponse
+	}
+	err = parse(text)
+	if err != nil {
+		return err
+	}
+	m.cache.put(ctx, key, m.id, text)
+	return nil
+}
@@ -0,0 +1,133 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/onsi/gomega"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+)
+
+func TestNewChatModelChain(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	t.Setenv("LLM_MODEL_TEST", "gpt-4o")
+	t.Setenv("LLM_PROVIDER_CHEAP", "ollama")
+	t.Setenv("LLM_MODEL_CHEAP", "qwen2.5:7b")
+	t.Setenv("LLM_FALLBACKS_TEST", " cheap, ,CHEAP")
+	chain, err := newChatModelChain(context.Background(), "TEST", nil)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(chain).To(gomega.HaveLen(2))
+	g.Expect(chain[0].id).To(gomega.Equal("openai/gpt-4o"))
+	g.Expect(chain[1].name).To(gomega.Equal("CHEAP"))
+	g.Expect(chain[1].id).To(gomega.Equal("ollama/qwen2.5:7b"))
+
+	t.Setenv("LLM_PROVIDER_CHEAP", "unknown")
+	_, err = newChatModelChain(context.Background(), "TEST", nil)
+	g.Expect(err).To(gomega.MatchError(errUnknownProvider))
+}
+
+func TestChatModelChainGenerate(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+	errRateLimited := errors.New("429 too many requests")
+
+	limited := &fakeChatModel{err: errRateLimited}
+	refused := &fakeChatModel{response: `{"error":"refused"}`}
+	ok := &fakeChatModel{response: `{"error":""}`}
+	parse := func(text string) error {
+		var result struct {
+			Error string `json:"error"`
+		}
+		err := json.Unmarshal([]byte(text), &result)
+		if err != nil {
+			return err
+		}
+		if result.Error != "" {
+			return errors.New(result.Error) //nolint
+		}
+		return nil
+	}
+
+	id, err := fakeChain(limited, refused, ok).generate(ctx, "system", schema.UserMessage("user"), parse)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(id).To(gomega.Equal("fake/model-2"))
+	for _, m := range []*fakeChatModel{limited, refused, ok} {
+		g.Expect(m.inputs).To(gomega.HaveLen(1))
+		g.Expect(m.inputs[0][0].Content).To(gomega.Equal("system"))
+		g.Expect(m.inputs[0][1].Content).To(gomega.Equal("user"))
+	}
+
+	_, err = fakeChain(limited, refused, &fakeChatModel{}).generate(ctx, "system", schema.UserMessage("user"), parse)
+	g.Expect(err).To(gomega.MatchError(errRateLimited))
+	g.Expect(err).To(gomega.MatchError(errEmptyResponse))
+	g.Expect(err.Error()).To(gomega.ContainSubstring("fake/model-1: refused"))
+
+	_, err = chatModelChain{}.generate(ctx, "system", schema.UserMessage("user"), parse)
+	g.Expect(err).To(gomega.MatchError(errNoChatModel))
+}
+
+func TestSummarizerGenerate_Fallback(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+
+	s := &summarizerImpl{
+		languages:  []string{"en"},
+		chatModels: fakeChain(&fakeChatModel{err: errors.New("rate limited")}, &fakeChatModel{response: `{"summary":"ok"}`}),
+	}
+	output, err := s.generate(ctx, apitypes.Post{Path: "https://example.com/post"}, schema.UserMessage("summarize"))
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(output.Summary).To(gomega.Equal("ok"))
+	g.Expect(output.Model).To(gomega.Equal("fake/model-1"))
+}
+
+func TestSummarizerChatModelsOf(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+
+	t.Setenv("LLM_PROVIDER_LONG_CONTEXT", "gemini")
+	t.Setenv("LLM_API_KEY_LONG_CONTEXT", "key")
+	t.Setenv("LLM_MODEL_LONG_CONTEXT", "gemini-1.5-pro")
+	defaults := fakeChain(&fakeChatModel{}, &fakeChatModel{})
+	s := &summarizerImpl{chatModels: defaults}
+
+	chain, err := s.chatModelsOf(ctx, apitypes.Post{})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(chain).To(gomega.Equal(defaults))
+
+	chain, err = s.chatModelsOf(ctx, apitypes.Post{Models: []string{"long_context", "FAKE1"}})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(chain).To(gomega.HaveLen(3))
+	g.Expect(chain[0].id).To(gomega.Equal("gemini/gemini-1.5-pro"))
+	g.Expect(chain[1]).To(gomega.BeIdenticalTo(defaults[1]))
+	g.Expect(chain[2]).To(gomega.BeIdenticalTo(defaults[0]))
+
+	// The overridden models are cached
+	again, err := s.chatModelsOf(ctx, apitypes.Post{Models: []string{"LONG_CONTEXT"}})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(again[0]).To(gomega.BeIdenticalTo(chain[0]))
+
+	// The fallbacks of the overridden model are tried before the summarizer
+	// models, and each model is tried once
+	t.Setenv("LLM_FALLBACKS_PAPER", "LONG_CONTEXT,FAKE0")
+	t.Setenv("LLM_PROVIDER_PAPER", "gemini")
+	t.Setenv("LLM_API_KEY_PAPER", "key")
+	t.Setenv("LLM_MODEL_PAPER", "gemini-2.5-pro")
+	chain, err = s.chatModelsOf(ctx, apitypes.Post{Models: []string{"PAPER", "LONG_CONTEXT"}})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	names := make([]string, 0, len(chain))
+	for _, m := range chain {
+		names = append(names, m.name)
+	}
+	g.Expect(names).To(gomega.Equal([]string{"PAPER", "LONG_CONTEXT", "FAKE0", "FAKE1"}))
+	g.Expect(chain[0].id).To(gomega.Equal("gemini/gemini-2.5-pro"))
+	g.Expect(chain[2]).To(gomega.BeIdenticalTo(defaults[0]))
+
+	t.Setenv("LLM_PROVIDER_BROKEN", "unknown")
+	_, err = s.chatModelsOf(ctx, apitypes.Post{Models: []string{"BROKEN"}})
+	g.Expect(err).To(gomega.MatchError(errUnknownProvider))
+}
@@ -0,0 +1,198 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+	"github.com/anyvoxel/vela/pkg/collectors"
+	"github.com/anyvoxel/vela/pkg/collectors/framework"
+	"github.com/anyvoxel/vela/test"
+)
+
+// updateGolden rewrite the golden files by the parsed posts, e.g.
+// go test ./pkg/agents/ -run TestListGolden -update
+var updateGolden = flag.Bool("update", false, "update the golden files of list parsing")
+
+// listGoldenTolerance is the minimum share of golden posts which the llm
+// parser must produce, the extra posts are counted as missing ones.
+const listGoldenTolerance = 0.9
+
+// listGoldenDir has the snapshot <name>.html, golden <name>.golden.json and
+// the llm cassette <name>.llm.json of each source, the sources.json are the
+// fixture sources which are not in collectors.json, and the pending.txt are
+// the sources whose snapshot is not saved yet.
+const listGoldenDir = "testdata/lists"
+
+// goldenPost is the post in golden file.
+type goldenPost struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	PublishedAt string `json:"published_at,omitempty"`
+}
+
+func TestListGolden(t *testing.T) {
+	sources, err := framework.ReadSources(filepath.Join(test.CurrentProjectPath(), "collectors.json"))
+	if err != nil {
+		t.Fatalf("read sources failed: %v", err)
+	}
+	fixtures, err := framework.ReadSources(filepath.Join(listGoldenDir, "sources.json"))
+	if err != nil {
+		t.Fatalf("read fixture sources failed: %v", err)
+	}
+
+	pending, err := readPendingSources(filepath.Join(listGoldenDir, "pending.txt"))
+	if err != nil {
+		t.Fatalf("read pending sources failed: %v", err)
+	}
+
+	names := make(map[string]bool)
+	for _, src := range append(sources, fixtures...) {
+		names[src.Name] = true
+		t.Run(src.Name, func(t *testing.T) {
+			testListGolden(t, src, pending)
+		})
+	}
+	for name := range pending {
+		if names[name] {
+			continue
+		}
+		t.Errorf("pending source %s is not in collectors.json, remove it from pending.txt", name)
+	}
+}
+
+func testListGolden(t *testing.T, src framework.CollectorSource, pending map[string]bool) {
+	g := gomega.NewWithT(t)
+	snapshot := filepath.Join(listGoldenDir, src.Name+".html")
+	html, err := os.ReadFile(snapshot)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && pending[src.Name]:
+		t.Skipf("snapshot is pending, save the list page %s to %s and run with -update", src.URL, snapshot)
+	case errors.Is(err, os.ErrNotExist):
+		t.Fatalf("no snapshot, save the list page %s to %s and run with -update", src.URL, snapshot)
+	case err == nil && pending[src.Name]:
+		t.Errorf("snapshot %s is saved, remove %s from pending.txt", snapshot, src.Name)
+	}
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	var llmParser collectors.ListParser
+	if src.Selectors == nil {
+		rec := test.NewRecorder(t, filepath.Join("lists", src.Name+".llm"))
+		p := &listParserImpl{chatModels: replayChatModels(t, rec, "LIST_PARSER")}
+		g.Expect(p.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+		llmParser = p
+	}
+	parser, err := framework.SourceListParser(src, llmParser)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	posts, err := parser.ParseList(context.Background(), string(html), src.URL, src.Name)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	got := toGoldenPosts(posts)
+
+	goldenFile := filepath.Join(listGoldenDir, src.Name+".golden.json")
+	if *updateGolden {
+		b, err := json.MarshalIndent(got, "", "  ")
+		g.Expect(err).ToNot(gomega.HaveOccurred())
+		g.Expect(os.WriteFile(goldenFile, append(b, '\n'), 0600)).To(gomega.Succeed())
+		return
+	}
+	b, err := os.ReadFile(goldenFile)
+	g.Expect(err).ToNot(gomega.HaveOccurred(), "run with -update to create the golden file")
+	var want []goldenPost
+	g.Expect(json.Unmarshal(b, &want)).To(gomega.Succeed())
+
+	if src.Selectors != nil {
+		g.Expect(got).To(gomega.Equal(want))
+		return
+	}
+	score, diff := compareGoldenPosts(want, got)
+	g.Expect(score).To(gomega.BeNumerically(">=", listGoldenTolerance), diff)
+}
+
+// readPendingSources return the source names in pending file, one name per
+// line, the blank and # comment lines are ignored.
+func readPendingSources(path string) (map[string]bool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pending := make(map[string]bool)
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pending[line] = true
+	}
+	return pending, nil
+}
+
+func toGoldenPosts(posts []apitypes.Post) []goldenPost {
+	golden := make([]goldenPost, 0, len(posts))
+	for _, post := range posts {
+		p := goldenPost{URL: post.Path, Title: post.Title}
+		if !post.PublishedAt.IsZero() {
+			p.PublishedAt = post.PublishedAt.Format(time.DateOnly)
+		}
+		golden = append(golden, p)
+	}
+	return golden
+}
+
+// compareGoldenPosts return the share of golden posts which are produced
+// with the same url and title, and the description of differences.
+func compareGoldenPosts(want, got []goldenPost) (float64, string) {
+	if len(want) == 0 && len(got) == 0 {
+		return 1, ""
+	}
+
+	gotByURL := make(map[string]goldenPost, len(got))
+	for _, p := range got {
+		gotByURL[p.URL] = p
+	}
+	var diff strings.Builder
+	matched := 0
+	for _, w := range want {
+		p, ok := gotByURL[w.URL]
+		switch {
+		case !ok:
+			fmt.Fprintf(&diff, "missing %s\n", w.URL)
+		case p.Title != w.Title:
+			fmt.Fprintf(&diff, "title of %s: want %q, got %q\n", w.URL, w.Title, p.Title)
+		default:
+			matched++
+		}
+		delete(gotByURL, w.URL)
+	}
+	for url := range gotByURL {
+		fmt.Fprintf(&diff, "extra %s\n", url)
+	}
+	return float64(matched) / float64(max(len(want), len(got))), diff.String()
+}
+
+func TestCompareGoldenPosts(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	want := []goldenPost{{URL: "a", Title: "A"}, {URL: "b", Title: "B"}, {URL: "c", Title: "C"}, {URL: "d", Title: "D"}}
+	score, diff := compareGoldenPosts(want, want)
+	g.Expect(score).To(gomega.Equal(1.0))
+	g.Expect(diff).To(gomega.BeEmpty())
+
+	score, diff = compareGoldenPosts(want, []goldenPost{
+		{URL: "a", Title: "A"}, {URL: "b", Title: "b"}, {URL: "c", Title: "C"}, {URL: "e", Title: "E"},
+	})
+	g.Expect(score).To(gomega.Equal(0.5))
+	g.Expect(diff).To(gomega.Equal("title of b: want \"B\", got \"b\"\nmissing d\nextra e\n"))
+
+	score, _ = compareGoldenPosts(nil, nil)
+	g.Expect(score).To(gomega.Equal(1.0))
+}
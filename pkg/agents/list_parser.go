@@ -7,19 +7,16 @@ import (
 	"fmt"
 	"log/slog"
 	"net/url"
-	"os"
 	"reflect"
 	"strings"
 	"time"
 	"unicode/utf8"
 
 	"github.com/anyvoxel/airmid/anvil"
-	"github.com/anyvoxel/airmid/anvil/xerrors"
 	airapp "github.com/anyvoxel/airmid/app"
 	"github.com/anyvoxel/airmid/ioc"
 	"github.com/anyvoxel/vela/pkg/apitypes"
 
-	openai "github.com/cloudwego/eino-ext/components/model/openai"
 	"github.com/cloudwego/eino/schema"
 )
 
@@ -48,7 +45,8 @@ Rules:
 
 // listParserImpl is an agent that extracts list items from HTML.
 type listParserImpl struct {
-	chatModel    *openai.ChatModel
+	chatModels   chatModelChain
+	cache        *responseCache `airmid:"autowire:vela.agents.responseCache"`
 	systemPrompt string
 }
 
@@ -62,19 +60,15 @@ var errBaseURLMustBeAbsolute = errors.New("base url must be absolute")
 
 // AfterPropertiesSet implement InitializingBean
 func (a *listParserImpl) AfterPropertiesSet(ctx context.Context) error {
-	responseFormat := &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
-	chatModel, err := openai.NewChatModel(ctx, &openai.ChatModelConfig{
-		APIKey:         os.Getenv("OPENAI_API_KEY_LIST_PARSER"),
-		Model:          os.Getenv("OPENAI_MODEL_LIST_PARSER"),
-		BaseURL:        os.Getenv("OPENAI_BASE_URL_LIST_PARSER"),
-		ResponseFormat: responseFormat,
-		ByAzure:        os.Getenv("OPENAI_BY_AZURE_LIST_PARSER") == "true",
-	})
-	if err != nil {
-		return err
+	// The chat models are injected in tests
+	if a.chatModels == nil {
+		chatModels, err := newChatModelChain(ctx, "LIST_PARSER", a.cache)
+		if err != nil {
+			return err
+		}
+		a.chatModels = chatModels
 	}
 
-	a.chatModel = chatModel
 	a.systemPrompt = listParserSystemPrompt
 	return nil
 }
@@ -103,12 +97,11 @@ func (a *listParserImpl) ParseList(ctx context.Context, html, baseURL, domain st
 			resolveBaseURL, domain, html),
 	}
 
-	text, err := a.generate(ctx, message)
-	if err != nil {
-		return nil, err
-	}
-
-	result, err := parseListResult(text)
+	var result listParseResult
+	_, err = a.chatModels.generate(ctx, a.systemPrompt, message, func(text string) (err error) {
+		result, err = parseListResult(text)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -236,21 +229,3 @@ func parsePublishedAt(publishedAt string) time.Time {
 	}
 	return time.Time{}
 }
-
-func (a *listParserImpl) generate(ctx context.Context, userMessage *schema.Message) (string, error) {
-	resp, err := a.chatModel.Generate(ctx, []*schema.Message{
-		{
-			Role:    schema.System,
-			Content: a.systemPrompt,
-		},
-		userMessage,
-	})
-	if err != nil {
-		return "", err
-	}
-	text := extractMessageText(resp)
-	if text == "" {
-		return "", xerrors.Errorf("empty response from llm")
-	}
-	return text, nil
-}
@@ -0,0 +1,304 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/cloudwego/eino/schema"
+	slogctx "github.com/veqryn/slog-context"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+)
+
+// ErrUnsupportedMedia is returned if the post is a video or audio without
+// any transcript, it's recorded without summary.
+var ErrUnsupportedMedia = errors.New("unsupported media without transcript")
+
+// errNotMediaPage means the post is summarized as the article.
+var errNotMediaPage = errors.New("not a media page")
+
+const (
+	mediaVideo = "video"
+	mediaAudio = "audio"
+
+	// maxMediaPageTextBytes is the max text of a media page, the article
+	// with an embedded media and more text is summarized as the article
+	maxMediaPageTextBytes = 2000
+	// minTranscriptBytes is the min text of a transcript, e.g. the show notes
+	// with only the links are not a transcript
+	minTranscriptBytes = 500
+	// maxTranscriptBytes is the max bytes of the transcript sent to llm
+	maxTranscriptBytes = 128 * 1024
+)
+
+// mediaHosts are the hosts of the embedded players, the subdomains are
+// matched too.
+var mediaHosts = map[string]string{
+	"youtube.com":          mediaVideo,
+	"youtube-nocookie.com": mediaVideo,
+	"youtu.be":             mediaVideo,
+	"vimeo.com":            mediaVideo,
+	"wistia.com":           mediaVideo,
+	"wistia.net":           mediaVideo,
+	"open.spotify.com":     mediaAudio,
+	"soundcloud.com":       mediaAudio,
+	"podcasts.apple.com":   mediaAudio,
+	"simplecast.com":       mediaAudio,
+	"buzzsprout.com":       mediaAudio,
+	"transistor.fm":        mediaAudio,
+	"libsyn.com":           mediaAudio,
+	"megaphone.fm":         mediaAudio,
+	"podbean.com":          mediaAudio,
+}
+
+// showNotesSelector selects the inline transcript or show notes.
+const showNotesSelector = `[id*="transcript" i], [class*="transcript" i], ` +
+	`[id*="show-notes" i], [class*="show-notes" i], [id*="shownotes" i], [class*="shownotes" i]`
+
+// captionTagRegexp matches the tags in cue text, e.g. <v Speaker>, <c> and
+// the timestamps of karaoke style.
+var captionTagRegexp = regexp.MustCompile(`<[^>]*>`)
+
+// mediaTranscript is the transcript of media post.
+type mediaTranscript struct {
+	// source is where the transcript is found, e.g. captions
+	source string
+	text   string
+}
+
+// mediaKind return the kind of the embedded player, it's empty if the page
+// doesn't embed any media.
+func mediaKind(doc *goquery.Document, base *url.URL) string {
+	if doc.Find("video").Length() > 0 {
+		return mediaVideo
+	}
+	if doc.Find("audio").Length() > 0 {
+		return mediaAudio
+	}
+
+	kind := ""
+	doc.Find("iframe[src]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		ref, err := url.Parse(strings.TrimSpace(s.AttrOr("src", "")))
+		if err != nil {
+			return true
+		}
+		host := strings.ToLower(base.ResolveReference(ref).Hostname())
+		for h, k := range mediaHosts {
+			if host == h || strings.HasSuffix(host, "."+h) {
+				kind = k
+				return false
+			}
+		}
+		return true
+	})
+	return kind
+}
+
+// summarizeMedia summarize the video or audio post by its transcript, it
+// return errNotMediaPage if the post should be summarized as the article.
+func (a *summarizerImpl) summarizeMedia(
+	ctx context.Context, client *http.Client, post apitypes.Post, page string,
+) (*SummaryOutput, error) {
+	base, err := url.Parse(post.Path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		return nil, err
+	}
+	kind := mediaKind(doc, base)
+	if kind == "" {
+		return nil, errNotMediaPage
+	}
+	text, err := articleMarkdown(page, post.Path, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(text) >= maxMediaPageTextBytes {
+		return nil, errNotMediaPage
+	}
+
+	transcript := findTranscript(ctx, client, doc, base)
+	if transcript == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMedia, kind)
+	}
+	slogctx.FromCtx(ctx).InfoContext(ctx, "summarize media by transcript",
+		slog.String("Media", kind),
+		slog.String("Source", transcript.source),
+		slog.Int("Bytes", len(transcript.text)),
+	)
+
+	return a.generate(ctx, post, &schema.Message{
+		Role: schema.User,
+		Content: fmt.Sprintf("Please summarize the following %s post by its %s, ignore the filler words, "+
+			"ads and sponsor messages of the speech\nTitle: %s\nURL: %s\nTranscript:\n%s",
+			kind, transcript.source, post.Title, post.Path, transcript.text),
+	})
+}
+
+// findTranscript return the transcript by the captions track, the
+// transcript link or the show notes in order, it's nil if none is found.
+func findTranscript(
+	ctx context.Context, client *http.Client, doc *goquery.Document, base *url.URL,
+) *mediaTranscript {
+	if u := captionsURL(doc, base); u != "" {
+		text, err := fetchCaptions(ctx, client, u)
+		if err != nil {
+			slogctx.FromCtx(ctx).WarnContext(ctx, "fetch captions failed",
+				slog.String("URL", u),
+				slog.Any("Error", err),
+			)
+		} else if len(text) >= minTranscriptBytes {
+			return &mediaTranscript{source: "captions", text: text}
+		}
+	}
+
+	if u := transcriptURL(doc, base); u != "" {
+		text, err := fetchArticleMarkdown(ctx, client, u, maxTranscriptBytes)
+		if err != nil {
+			slogctx.FromCtx(ctx).WarnContext(ctx, "fetch transcript failed",
+				slog.String("URL", u),
+				slog.Any("Error", err),
+			)
+		} else if len(text) >= minTranscriptBytes {
+			return &mediaTranscript{source: "transcript", text: text}
+		}
+	}
+
+	// The longest one is the transcript or show notes, the others are
+	// usually the headings or links of them
+	text := ""
+	doc.Find(showNotesSelector).Each(func(_ int, s *goquery.Selection) {
+		t := strings.Join(strings.Fields(s.Text()), " ")
+		if len(t) > len(text) {
+			text = t
+		}
+	})
+	if len(text) >= minTranscriptBytes {
+		return &mediaTranscript{source: "show notes", text: truncateUTF8(text, maxTranscriptBytes)}
+	}
+	return nil
+}
+
+// captionsURL return the url of the captions or subtitles track, the english
+// one is preferred.
+func captionsURL(doc *goquery.Document, base *url.URL) string {
+	var urls, english []string
+	doc.Find("video track[src], audio track[src]").Each(func(_ int, s *goquery.Selection) {
+		// The kind defaults to subtitles
+		kind := strings.ToLower(s.AttrOr("kind", "subtitles"))
+		if kind != "captions" && kind != "subtitles" {
+			return
+		}
+		u, ok := resolveHTTPURL(base, s.AttrOr("src", ""))
+		if !ok {
+			return
+		}
+		urls = append(urls, u)
+		if strings.HasPrefix(strings.ToLower(s.AttrOr("srclang", "")), "en") {
+			english = append(english, u)
+		}
+	})
+	if len(english) > 0 {
+		return english[0]
+	}
+	if len(urls) > 0 {
+		return urls[0]
+	}
+	return ""
+}
+
+// transcriptURL return the first link to the transcript of the other page.
+func transcriptURL(doc *goquery.Document, base *url.URL) string {
+	ret := ""
+	doc.Find("a[href]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		href := s.AttrOr("href", "")
+		if !strings.Contains(strings.ToLower(s.Text()), "transcript") &&
+			!strings.Contains(strings.ToLower(href), "transcript") {
+			return true
+		}
+		u, ok := resolveHTTPURL(base, href)
+		if !ok {
+			return true
+		}
+		// The anchor of the inline transcript is found as the show notes
+		self := *base
+		self.Fragment = ""
+		if u == self.String() {
+			return true
+		}
+		ret = u
+		return false
+	})
+	return ret
+}
+
+// resolveHTTPURL return the http url of ref without fragment.
+func resolveHTTPURL(base *url.URL, ref string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return "", false
+	}
+	u = base.ResolveReference(u)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", false
+	}
+	u.Fragment = ""
+	return u.String(), true
+}
+
+// fetchCaptions return the text of the WebVTT or SRT captions.
+func fetchCaptions(ctx context.Context, client *http.Client, path string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close() //nolint
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("%w: status %d", errArticleResponse, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxArticleBodyBytes))
+	if err != nil {
+		return "", err
+	}
+	return truncateUTF8(captionsText(string(body)), maxTranscriptBytes), nil
+}
+
+// captionsText return the text of the cues of WebVTT or SRT captions, the
+// repeated lines of the rolling captions are dropped.
+func captionsText(captions string) string {
+	captions = strings.ReplaceAll(captions, "\r\n", "\n")
+	var lines []string
+	for _, block := range strings.Split(captions, "\n\n") {
+		// The header, NOTE and STYLE blocks don't have the timings
+		cue := strings.Split(strings.TrimSpace(block), "\n")
+		timing := slices.IndexFunc(cue, func(line string) bool { return strings.Contains(line, "-->") })
+		if timing < 0 {
+			continue
+		}
+		for _, line := range cue[timing+1:] {
+			line = strings.TrimSpace(html.UnescapeString(captionTagRegexp.ReplaceAllString(line, "")))
+			if line == "" || (len(lines) > 0 && lines[len(lines)-1] == line) {
+				continue
+			}
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " ")
+}
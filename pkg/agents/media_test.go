@@ -0,0 +1,133 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+)
+
+func TestCaptionsText(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	g.Expect(captionsText("WEBVTT Kind: captions\r\n\r\nNOTE recorded at QCon\r\n\r\n" +
+		"intro\r\n00:00:01.000 --> 00:00:03.000 align:start\r\n<v Speaker>Welcome to the talk</v>\r\n\r\n" +
+		"00:00:03.000 --> 00:00:05.000\r\nWelcome to the talk\r\n<c>about</c> Raft &amp; Paxos\r\n")).
+		To(gomega.Equal("Welcome to the talk about Raft & Paxos"))
+	g.Expect(captionsText("1\n00:00:01,000 --> 00:00:03,000\nHello\n\n2\n00:00:03,000 --> 00:00:05,000\nworld\n")).
+		To(gomega.Equal("Hello world"))
+	g.Expect(captionsText("<html>not captions</html>")).To(gomega.BeEmpty())
+}
+
+func TestSummarizer_Media(t *testing.T) {
+	g := gomega.NewWithT(t)
+	sentence := "The speaker explains how the leader replicates the log to the followers. "
+	transcript := strings.Repeat(sentence, 10)
+	var cues strings.Builder
+	cues.WriteString("WEBVTT\n\n")
+	for i := range 10 {
+		fmt.Fprintf(&cues, "00:00:%02d.000 --> 00:00:%02d.000\n%d. %s\n\n", i, i+1, i, sentence)
+	}
+	pages := map[string]string{
+		"/talk": `<html><body><h1>Raft talk</h1><video src="/talk.mp4">
+<track kind="chapters" src="/chapters.vtt"><track kind="captions" srclang="de" src="/de.vtt">
+<track kind="captions" srclang="en-US" src="/en.vtt"></video></body></html>`,
+		"/en.vtt": cues.String(),
+		"/episode": `<html><body><h1>Episode 42</h1><iframe src="https://open.spotify.com/embed/episode/1"></iframe>
+<a href="#transcript">Jump to transcript</a><a href="/episode/transcript">Read the transcript</a></body></html>`,
+		"/episode/transcript": "<html><body><article>" + transcript + "</article></body></html>",
+		"/notes": `<html><body><iframe src="//w.soundcloud.com/player/?url=x"></iframe>
+<section class="Show-Notes"><h2>Show notes</h2><p>` + transcript + `</p></section></body></html>`,
+		"/bare": `<html><body><h1>Keynote</h1><iframe src="https://www.youtube.com/embed/abc"></iframe></body></html>`,
+		"/article": `<html><body><iframe src="https://www.youtube.com/embed/abc"></iframe><p>` +
+			strings.Repeat(sentence, 30) + `</p></body></html>`,
+	}
+	var mu sync.Mutex
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.Method+" "+r.URL.Path]++
+		mu.Unlock()
+		if r.URL.Path == "/raft.png" {
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("png"))
+			return
+		}
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, ".vtt") {
+			w.Header().Set("Content-Type", "text/vtt")
+		} else {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		}
+		_, _ = w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	m := &fakeChatModel{response: `{"summary":"ok"}`}
+	var rendered []string
+	s := &summarizerImpl{
+		chatModels:    fakeChain(m),
+		client:        server.Client(),
+		summarizeType: "image",
+		languages:     []string{"en"},
+		summaryFn: func(_ context.Context, post apitypes.Post, page *postPage) (*SummaryOutput, error) {
+			g.Expect(page).ToNot(gomega.BeNil())
+			rendered = append(rendered, post.Path)
+			return &SummaryOutput{Summary: "rendered"}, nil
+		},
+	}
+	ctx := context.Background()
+	for path, prefix := range map[string]string{
+		"/talk":    "Please summarize the following video post by its captions,",
+		"/episode": "Please summarize the following audio post by its transcript,",
+		"/notes":   "Please summarize the following audio post by its show notes,",
+	} {
+		m.inputs = nil
+		output, err := s.Summary(ctx, apitypes.Post{Title: "Raft", Path: server.URL + path})
+		g.Expect(err).ToNot(gomega.HaveOccurred(), path)
+		g.Expect(output.Summary).To(gomega.Equal("ok"), path)
+		g.Expect(m.inputs).To(gomega.HaveLen(1), path)
+		message := m.inputs[0][1].Content
+		g.Expect(message).To(gomega.HavePrefix(prefix), path)
+		g.Expect(message).To(gomega.ContainSubstring(strings.TrimSpace(sentence)), path)
+	}
+
+	_, err := s.Summary(ctx, apitypes.Post{Path: server.URL + "/bare"})
+	g.Expect(err).To(gomega.MatchError(ErrUnsupportedMedia))
+
+	// The article with an embedded video is rendered as usual, and the page
+	// is fetched once to detect the pdf and media
+	requests = map[string]int{}
+	output, err := s.Summary(ctx, apitypes.Post{Path: server.URL + "/article"})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(output.Summary).To(gomega.Equal("rendered"))
+	_, err = s.Summary(ctx, apitypes.Post{Path: server.URL + "/raft.png"})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(rendered).To(gomega.Equal([]string{server.URL + "/article", server.URL + "/raft.png"}))
+	g.Expect(requests).To(gomega.Equal(map[string]int{"GET /article": 1, "GET /raft.png": 1}))
+
+	// The markdown summarize type detects the media by the fetched page
+	s.summarizeType = "markdown"
+	s.summaryFn = s.summarizeByMarkdown
+	_, err = s.Summary(ctx, apitypes.Post{Path: server.URL + "/bare"})
+	g.Expect(err).To(gomega.MatchError(ErrUnsupportedMedia))
+	m.inputs = nil
+	requests = map[string]int{}
+	_, err = s.Summary(ctx, apitypes.Post{Path: server.URL + "/article"})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(m.inputs[0][1].Content).To(gomega.HavePrefix("Please summarize the following blog post in markdown"))
+	g.Expect(requests).To(gomega.Equal(map[string]int{"GET /article": 1}))
+	_, err = s.Summary(ctx, apitypes.Post{Path: server.URL + "/raft.png"})
+	g.Expect(err).To(gomega.MatchError(errArticleResponse))
+}
@@ -13,7 +13,9 @@ import (
 	context "context"
 	reflect "reflect"
 
+	agents "github.com/anyvoxel/vela/pkg/agents"
 	apitypes "github.com/anyvoxel/vela/pkg/apitypes"
+	storage "github.com/anyvoxel/vela/pkg/storage"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -42,10 +44,10 @@ func (m *MockSummarizer) EXPECT() *MockSummarizerMockRecorder {
 }
 
 // Summary mocks base method.
-func (m *MockSummarizer) Summary(ctx context.Context, post apitypes.Post) (string, error) {
+func (m *MockSummarizer) Summary(ctx context.Context, post apitypes.Post) (*agents.SummaryOutput, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Summary", ctx, post)
-	ret0, _ := ret[0].(string)
+	ret0, _ := ret[0].(*agents.SummaryOutput)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -55,3 +57,81 @@ func (mr *MockSummarizerMockRecorder) Summary(ctx, post any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Summary", reflect.TypeOf((*MockSummarizer)(nil).Summary), ctx, post)
 }
+
+// MockScorer is a mock of Scorer interface.
+type MockScorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockScorerMockRecorder
+	isgomock struct{}
+}
+
+// MockScorerMockRecorder is the mock recorder for MockScorer.
+type MockScorerMockRecorder struct {
+	mock *MockScorer
+}
+
+// NewMockScorer creates a new mock instance.
+func NewMockScorer(ctrl *gomock.Controller) *MockScorer {
+	mock := &MockScorer{ctrl: ctrl}
+	mock.recorder = &MockScorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScorer) EXPECT() *MockScorerMockRecorder {
+	return m.recorder
+}
+
+// Score mocks base method.
+func (m *MockScorer) Score(ctx context.Context, post apitypes.Post) (*apitypes.Relevance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Score", ctx, post)
+	ret0, _ := ret[0].(*apitypes.Relevance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Score indicates an expected call of Score.
+func (mr *MockScorerMockRecorder) Score(ctx, post any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Score", reflect.TypeOf((*MockScorer)(nil).Score), ctx, post)
+}
+
+// MockAnswerer is a mock of Answerer interface.
+type MockAnswerer struct {
+	ctrl     *gomock.Controller
+	recorder *MockAnswererMockRecorder
+	isgomock struct{}
+}
+
+// MockAnswererMockRecorder is the mock recorder for MockAnswerer.
+type MockAnswererMockRecorder struct {
+	mock *MockAnswerer
+}
+
+// NewMockAnswerer creates a new mock instance.
+func NewMockAnswerer(ctrl *gomock.Controller) *MockAnswerer {
+	mock := &MockAnswerer{ctrl: ctrl}
+	mock.recorder = &MockAnswererMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAnswerer) EXPECT() *MockAnswererMockRecorder {
+	return m.recorder
+}
+
+// Answer mocks base method.
+func (m *MockAnswerer) Answer(ctx context.Context, question string, posts []*storage.SummaryResult) (*agents.Answer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Answer", ctx, question, posts)
+	ret0, _ := ret[0].(*agents.Answer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Answer indicates an expected call of Answer.
+func (mr *MockAnswererMockRecorder) Answer(ctx, question, posts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Answer", reflect.TypeOf((*MockAnswerer)(nil).Answer), ctx, question, posts)
+}
@@ -0,0 +1,162 @@
+package agents
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/ledongthuc/pdf"
+	slogctx "github.com/veqryn/slog-context"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+)
+
+//go:embed paper_prompt.md
+var paperPrompt string
+
+// maxPaperTextBytes is the max bytes of the paper text sent to llm.
+const maxPaperTextBytes = 128 * 1024
+
+var (
+	errNotPDF        = errors.New("not a pdf")
+	errPaperTooLarge = errors.New("paper pdf is too large")
+	errPaperNoText   = errors.New("paper pdf has no text")
+)
+
+// paperText is the text extracted from the pdf of paper.
+type paperText struct {
+	text string
+	// pages is the number of pages of pdf, the text may only contain the
+	// first ones
+	pages     int
+	readPages int
+	// title and author are the document info of pdf, they are usually empty
+	// or wrong, so they are only the hints of llm
+	title  string
+	author string
+}
+
+// pdfMagic is the header of pdf file.
+var pdfMagic = []byte("%PDF-")
+
+// isPDFPath return whether the path of post ends with .pdf.
+func isPDFPath(path string) bool {
+	u, err := url.Parse(path)
+	return err == nil && strings.HasSuffix(strings.ToLower(u.Path), ".pdf")
+}
+
+func isPDFContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/pdf" || mediaType == "application/x-pdf")
+}
+
+// readPaperPDF read the pdf from the body of response, the pdf larger than
+// maxBytes is rejected because it can't be read partially.
+func readPaperPDF(resp *http.Response, body io.Reader, maxBytes int64) ([]byte, error) {
+	if resp.ContentLength > maxBytes {
+		return nil, fmt.Errorf("%w: %d bytes", errPaperTooLarge, resp.ContentLength)
+	}
+	data, err := io.ReadAll(io.LimitReader(body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("%w: more than %d bytes", errPaperTooLarge, maxBytes)
+	}
+	// The sites may response the login or landing page for the pdf link
+	if !bytes.HasPrefix(data, pdfMagic) {
+		return nil, fmt.Errorf("%w: content type %q", errNotPDF, resp.Header.Get("Content-Type"))
+	}
+	return data, nil
+}
+
+// extractPaperText return the text of the first maxPages pages of pdf.
+func extractPaperText(data []byte, maxPages int) (paper *paperText, err error) {
+	// The pdf reader panics on some malformed files
+	defer func() {
+		if r := recover(); r != nil {
+			paper, err = nil, fmt.Errorf("%w: %v", errNotPDF, r)
+		}
+	}()
+
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errNotPDF, err)
+	}
+
+	paper = &paperText{pages: r.NumPage()}
+	info := r.Trailer().Key("Info")
+	paper.title = strings.TrimSpace(info.Key("Title").Text())
+	paper.author = strings.TrimSpace(info.Key("Author").Text())
+
+	var b strings.Builder
+	for i := 1; i <= paper.pages && (maxPages <= 0 || i <= maxPages); i++ {
+		page := r.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		text, err := page.GetPlainText(nil)
+		if err != nil {
+			return nil, fmt.Errorf("%w: page %d: %w", errNotPDF, i, err)
+		}
+		paper.readPages = i
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(text)
+		if b.Len() >= maxPaperTextBytes {
+			break
+		}
+	}
+
+	paper.text = truncateUTF8(b.String(), maxPaperTextBytes)
+	if paper.text == "" {
+		// The scanned pdf has only images
+		return nil, errPaperNoText
+	}
+	return paper, nil
+}
+
+// summarizePaper summarize the pdf of a research paper by its text, the
+// paper prompt asks for the problem, method, results and limitations.
+func (a *summarizerImpl) summarizePaper(ctx context.Context, post apitypes.Post, data []byte) (*SummaryOutput, error) {
+	paper, err := extractPaperText(data, a.paperMaxPages)
+	if err != nil {
+		return nil, err
+	}
+	slogctx.FromCtx(ctx).InfoContext(ctx, "summarize paper pdf",
+		slog.String("Path", post.Path),
+		slog.Int("Pages", paper.pages),
+		slog.Int("ReadPages", paper.readPages),
+		slog.Int("Bytes", len(data)),
+	)
+
+	var b strings.Builder
+	b.WriteString(paperPrompt)
+	fmt.Fprintf(&b, "\nTitle: %s\nURL: %s\n", post.Title, post.Path)
+	if paper.title != "" || paper.author != "" {
+		fmt.Fprintf(&b, "PDF Info: title %q, author %q\n", paper.title, paper.author)
+	}
+	if paper.readPages < paper.pages {
+		fmt.Fprintf(&b, "Pages: the first %d of %d pages\n", paper.readPages, paper.pages)
+	}
+	fmt.Fprintf(&b, "Content:\n%s", paper.text)
+
+	return a.generate(ctx, post, &schema.Message{
+		Role:    schema.User,
+		Content: b.String(),
+	})
+}
@@ -0,0 +1,173 @@
+package agents
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+)
+
+// buildPDF return a pdf with a page of each text, and the document info.
+func buildPDF(title, author string, pages ...string) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // The pages are filled after the page objects are numbered
+		fmt.Sprintf("<< /Title (%s) /Author (%s) >>", title, author),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	kids := make([]string, 0, len(pages))
+	for _, text := range pages {
+		content := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+		objects = append(objects, fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R "+
+				"/Resources << /Font << /F1 4 0 R >> >> >>", len(objects)))
+		kids = append(kids, fmt.Sprintf("%d 0 R", len(objects)))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, 0, len(objects))
+	for i, obj := range objects {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+func TestExtractPaperText(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	paper, err := extractPaperText(buildPDF("Raft", "Diego", "Abstract", "Evaluation", "Appendix"), 2)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(*paper).To(gomega.Equal(paperText{
+		text:      "Abstract\n\nEvaluation",
+		pages:     3,
+		readPages: 2,
+		title:     "Raft",
+		author:    "Diego",
+	}))
+
+	paper, err = extractPaperText(buildPDF("", "", "Abstract", "Evaluation"), 0)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(paper.text).To(gomega.Equal("Abstract\n\nEvaluation"))
+	g.Expect(paper.readPages).To(gomega.Equal(2))
+
+	_, err = extractPaperText(buildPDF("", "", ""), 0)
+	g.Expect(err).To(gomega.MatchError(errPaperNoText))
+	_, err = extractPaperText([]byte("%PDF-1.4\ngarbage\n%%EOF\n"), 0)
+	g.Expect(err).To(gomega.MatchError(errNotPDF))
+}
+
+func TestFetchPostPage(t *testing.T) {
+	g := gomega.NewWithT(t)
+	data := buildPDF("Raft", "Diego", "Abstract")
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		switch r.URL.Path {
+		case "/papers/raft.PDF", "/download":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write(data)
+		case "/pub/123":
+			w.Header().Set("Content-Type", "application/pdf; qs=0.001")
+			_, _ = w.Write(data)
+		case "/login.pdf":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte("<html>Sign in</html>"))
+		case "/blog/raft.html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte("<html>Raft</html>"))
+		case "/raft.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("png"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	maxBytes := int64(len(data))
+	for _, path := range []string{"/papers/raft.PDF", "/pub/123", "/download"} {
+		page, err := fetchPostPage(ctx, server.Client(), server.URL+path, maxBytes)
+		g.Expect(err).ToNot(gomega.HaveOccurred(), path)
+		g.Expect(page.pdf).To(gomega.Equal(data), path)
+		g.Expect(page.html).To(gomega.BeEmpty(), path)
+	}
+	page, err := fetchPostPage(ctx, server.Client(), server.URL+"/blog/raft.html", maxBytes)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(page.pdf).To(gomega.BeNil())
+	g.Expect(page.html).To(gomega.Equal("<html>Raft</html>"))
+	page, err = fetchPostPage(ctx, server.Client(), server.URL+"/raft.png", maxBytes)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(*page).To(gomega.Equal(postPage{contentType: "image/png"}))
+	g.Expect(methods).To(gomega.HaveEach(http.MethodGet))
+
+	_, err = fetchPostPage(ctx, server.Client(), server.URL+"/pub/123", maxBytes-1)
+	g.Expect(err).To(gomega.MatchError(errPaperTooLarge))
+	_, err = fetchPostPage(ctx, server.Client(), server.URL+"/login.pdf", maxBytes)
+	g.Expect(err).To(gomega.MatchError(errNotPDF))
+	_, err = fetchPostPage(ctx, server.Client(), server.URL+"/missing.pdf", maxBytes)
+	g.Expect(err).To(gomega.MatchError(errArticleResponse))
+}
+
+func TestSummarizer_Paper(t *testing.T) {
+	g := gomega.NewWithT(t)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/pdf")
+		_, _ = w.Write(buildPDF("Raft", "Diego", "In Search of an Understandable Consensus Algorithm", "Evaluation"))
+	}))
+	defer server.Close()
+
+	m := &fakeChatModel{response: `{"summary":"Raft is understandable.","content_type":"paper","paper":{
+"authors":[" Diego Ongaro ","John Ousterhout","Diego Ongaro"],"venue":" USENIX ATC 2014 ",
+"problem":"Paxos is hard to understand.","method":"Decompose consensus.","results":"Students learn it faster.",
+"limitations":""}}`}
+	s := &summarizerImpl{
+		chatModels:    fakeChain(m),
+		client:        server.Client(),
+		languages:     []string{"en"},
+		paperMaxPages: 1,
+		paperMaxBytes: 1 << 20,
+		summaryFn: func(context.Context, apitypes.Post, *postPage) (*SummaryOutput, error) {
+			panic("the pdf is summarized by the summarize type")
+		},
+	}
+	output, err := s.Summary(context.Background(), apitypes.Post{Title: "Raft", Path: server.URL + "/pub/raft"})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	// The pdf is detected by the response which is summarized
+	g.Expect(requests).To(gomega.Equal(1))
+	g.Expect(output.Summary).To(gomega.Equal("Raft is understandable."))
+	g.Expect(output.ContentType).To(gomega.Equal(apitypes.ContentTypePaper))
+	g.Expect(output.Paper).To(gomega.Equal(&apitypes.PaperDetail{
+		Authors: []string{"Diego Ongaro", "John Ousterhout"},
+		Venue:   "USENIX ATC 2014",
+		Problem: "Paxos is hard to understand.",
+		Method:  "Decompose consensus.",
+		Results: "Students learn it faster.",
+	}))
+
+	g.Expect(m.inputs).To(gomega.HaveLen(1))
+	message := m.inputs[0][1].Content
+	g.Expect(message).To(gomega.HavePrefix(paperPrompt))
+	g.Expect(message).To(gomega.ContainSubstring(`PDF Info: title "Raft", author "Diego"`))
+	g.Expect(message).To(gomega.ContainSubstring("Pages: the first 1 of 2 pages\n"))
+	g.Expect(message).To(gomega.HaveSuffix("Content:\nIn Search of an Understandable Consensus Algorithm"))
+}
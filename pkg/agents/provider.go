@@ -0,0 +1,214 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/cloudwego/eino-ext/components/model/ark"
+	"github.com/cloudwego/eino-ext/components/model/claude"
+	"github.com/cloudwego/eino-ext/components/model/gemini"
+	"github.com/cloudwego/eino-ext/components/model/ollama"
+	openai "github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino-ext/components/model/qwen"
+	"github.com/cloudwego/eino/components/model"
+	arkmodel "github.com/volcengine/volcengine-go-sdk/service/arkruntime/model"
+	"google.golang.org/genai"
+)
+
+// The llm providers which can be selected by LLM_PROVIDER_<suffix>.
+const (
+	providerOpenAI    = "openai"
+	providerAzure     = "azure"
+	providerOllama    = "ollama"
+	providerLlamaCpp  = "llamacpp"
+	providerAnthropic = "anthropic"
+	providerGemini    = "gemini"
+	providerArk       = "ark"
+	providerQwen      = "qwen"
+)
+
+// defaultBaseURLs is the default base url of the providers, the others are
+// defaulted by their client.
+var defaultBaseURLs = map[string]string{
+	providerOllama:   "http://localhost:11434",
+	providerLlamaCpp: "http://localhost:8080/v1",
+	providerQwen:     "https://dashscope.aliyuncs.com/compatible-mode/v1",
+}
+
+// anthropicMaxTokens is the max tokens of the anthropic response, which is
+// required by its api.
+const anthropicMaxTokens = 8192
+
+var errUnknownProvider = errors.New("unknown llm provider")
+
+// chatModelConfig is the config of the chat model of an agent.
+type chatModelConfig struct {
+	Provider   string
+	APIKey     string
+	Model      string
+	BaseURL    string
+	APIVersion string
+
+	// HTTPClient is nil for the default client, it's used to replay the
+	// recorded responses in tests
+	HTTPClient *http.Client
+}
+
+// loadChatModelConfig read the config from LLM_*_<suffix> env, each of
+// them falls back to the OPENAI_*_<suffix> env.
+func loadChatModelConfig(suffix string) *chatModelConfig {
+	cfg := &chatModelConfig{
+		Provider:   strings.ToLower(strings.TrimSpace(os.Getenv("LLM_PROVIDER_" + suffix))),
+		APIKey:     llmEnv("API_KEY", suffix),
+		Model:      llmEnv("MODEL", suffix),
+		BaseURL:    llmEnv("BASE_URL", suffix),
+		APIVersion: llmEnv("API_VERSION", suffix),
+	}
+	if cfg.Provider == "" {
+		cfg.Provider = providerOpenAI
+		if os.Getenv("OPENAI_BY_AZURE_"+suffix) == "true" {
+			cfg.Provider = providerAzure
+		}
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultBaseURLs[cfg.Provider]
+	}
+	return cfg
+}
+
+func llmEnv(name string, suffix string) string {
+	if v := os.Getenv("LLM_" + name + "_" + suffix); v != "" {
+		return v
+	}
+	return os.Getenv("OPENAI_" + name + "_" + suffix)
+}
+
+// newChatModel create the chat model of the provider configured by the
+// LLM_*_<suffix> env, the responses are cached if cache is not nil.
+func newChatModel(ctx context.Context, suffix string, cache *responseCache) (*chatModel, error) {
+	return buildChatModel(ctx, suffix, loadChatModelConfig(suffix), cache)
+}
+
+func buildChatModel(ctx context.Context, name string, cfg *chatModelConfig, cache *responseCache) (*chatModel, error) {
+	var (
+		baseModel model.BaseChatModel
+		err       error
+	)
+	switch cfg.Provider {
+	case providerGemini:
+		baseModel, err = newGeminiChatModel(ctx, cfg)
+	case providerAnthropic:
+		baseModel, err = newClaudeChatModel(ctx, cfg)
+	case providerArk:
+		baseModel, err = newArkChatModel(ctx, cfg)
+	case providerQwen:
+		baseModel, err = newQwenChatModel(ctx, cfg)
+	case providerOllama:
+		baseModel, err = newOllamaChatModel(ctx, cfg)
+	case providerOpenAI, providerAzure, providerLlamaCpp:
+		baseModel, err = newOpenAIChatModel(ctx, cfg)
+	default:
+		err = fmt.Errorf("%w: %q", errUnknownProvider, cfg.Provider)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &chatModel{
+		name:          name,
+		id:            cfg.Provider + "/" + cfg.Model,
+		cache:         cache,
+		BaseChatModel: baseModel,
+	}, nil
+}
+
+// newOpenAIChatModel create the chat model for the openai compatible api,
+// the model will always response with json object.
+func newOpenAIChatModel(ctx context.Context, cfg *chatModelConfig) (model.BaseChatModel, error) {
+	responseFormat := &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	return openai.NewChatModel(ctx, &openai.ChatModelConfig{
+		APIKey:         cfg.APIKey,
+		Model:          cfg.Model,
+		BaseURL:        cfg.BaseURL,
+		APIVersion:     cfg.APIVersion,
+		ResponseFormat: responseFormat,
+		ByAzure:        cfg.Provider == providerAzure,
+		HTTPClient:     cfg.HTTPClient,
+	})
+}
+
+// newGeminiChatModel create the chat model for the gemini api, the json
+// object is required by the system prompt only.
+func newGeminiChatModel(ctx context.Context, cfg *chatModelConfig) (model.BaseChatModel, error) {
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:      cfg.APIKey,
+		Backend:     genai.BackendGeminiAPI,
+		HTTPClient:  cfg.HTTPClient,
+		HTTPOptions: genai.HTTPOptions{BaseURL: cfg.BaseURL},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return gemini.NewChatModel(ctx, &gemini.Config{
+		Client: client,
+		Model:  cfg.Model,
+	})
+}
+
+// newClaudeChatModel create the chat model for the anthropic api, the json
+// object is required by the system prompt only because the api doesn't
+// support the response format.
+func newClaudeChatModel(ctx context.Context, cfg *chatModelConfig) (model.BaseChatModel, error) {
+	var baseURL *string
+	if cfg.BaseURL != "" {
+		baseURL = &cfg.BaseURL
+	}
+	return claude.NewChatModel(ctx, &claude.Config{
+		APIKey:     cfg.APIKey,
+		Model:      cfg.Model,
+		BaseURL:    baseURL,
+		MaxTokens:  anthropicMaxTokens,
+		HTTPClient: cfg.HTTPClient,
+	})
+}
+
+// newArkChatModel create the chat model for the volcengine ark api, the
+// model will always response with json object.
+func newArkChatModel(ctx context.Context, cfg *chatModelConfig) (model.BaseChatModel, error) {
+	return ark.NewChatModel(ctx, &ark.ChatModelConfig{
+		APIKey:         cfg.APIKey,
+		Model:          cfg.Model,
+		BaseURL:        cfg.BaseURL,
+		ResponseFormat: &ark.ResponseFormat{Type: arkmodel.ResponseFormatJsonObject},
+		HTTPClient:     cfg.HTTPClient,
+	})
+}
+
+// newQwenChatModel create the chat model for the dashscope api, the model
+// will always response with json object.
+func newQwenChatModel(ctx context.Context, cfg *chatModelConfig) (model.BaseChatModel, error) {
+	responseFormat := &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	return qwen.NewChatModel(ctx, &qwen.ChatModelConfig{
+		APIKey:         cfg.APIKey,
+		Model:          cfg.Model,
+		BaseURL:        cfg.BaseURL,
+		ResponseFormat: responseFormat,
+		HTTPClient:     cfg.HTTPClient,
+	})
+}
+
+// newOllamaChatModel create the chat model for the ollama native api, the
+// model will always response with json. The /v1 suffix of the openai
+// compatible endpoint is trimmed, so the previous base url still works.
+func newOllamaChatModel(ctx context.Context, cfg *chatModelConfig) (model.BaseChatModel, error) {
+	return ollama.NewChatModel(ctx, &ollama.ChatModelConfig{
+		BaseURL:    strings.TrimSuffix(strings.TrimRight(cfg.BaseURL, "/"), "/v1"),
+		Model:      cfg.Model,
+		Format:     json.RawMessage(`"json"`),
+		HTTPClient: cfg.HTTPClient,
+	})
+}
@@ -0,0 +1,190 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/onsi/gomega"
+)
+
+// fakeChatModel is a chat model which responses with the canned text.
+type fakeChatModel struct {
+	response string
+	err      error
+	inputs   [][]*schema.Message
+}
+
+var _ model.BaseChatModel = (*fakeChatModel)(nil)
+
+func (m *fakeChatModel) Generate(
+	_ context.Context, input []*schema.Message, _ ...model.Option,
+) (*schema.Message, error) {
+	m.inputs = append(m.inputs, input)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return schema.AssistantMessage(m.response, nil), nil
+}
+
+func (m *fakeChatModel) Stream(
+	ctx context.Context, input []*schema.Message, opts ...model.Option,
+) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+// fakeChain return the chain of fake chat models, which are named FAKE<i>.
+func fakeChain(models ...*fakeChatModel) chatModelChain {
+	chain := make(chatModelChain, 0, len(models))
+	for i, m := range models {
+		chain = append(chain, &chatModel{
+			name:          fmt.Sprintf("FAKE%d", i),
+			id:            fmt.Sprintf("fake/model-%d", i),
+			BaseChatModel: m,
+		})
+	}
+	return chain
+}
+
+func TestLoadChatModelConfig(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	t.Setenv("OPENAI_API_KEY_TEST", "openai-key")
+	t.Setenv("OPENAI_MODEL_TEST", "gpt-4o")
+	cfg := loadChatModelConfig("TEST")
+	g.Expect(cfg).To(gomega.Equal(&chatModelConfig{
+		Provider: providerOpenAI,
+		APIKey:   "openai-key",
+		Model:    "gpt-4o",
+	}))
+
+	t.Setenv("OPENAI_BY_AZURE_TEST", "true")
+	t.Setenv("OPENAI_API_VERSION_TEST", "2024-06-01")
+	cfg = loadChatModelConfig("TEST")
+	g.Expect(cfg.Provider).To(gomega.Equal(providerAzure))
+	g.Expect(cfg.APIVersion).To(gomega.Equal("2024-06-01"))
+
+	t.Setenv("LLM_PROVIDER_TEST", " Ollama ")
+	t.Setenv("LLM_MODEL_TEST", "qwen2.5:7b")
+	cfg = loadChatModelConfig("TEST")
+	g.Expect(cfg).To(gomega.Equal(&chatModelConfig{
+		Provider:   providerOllama,
+		APIKey:     "openai-key",
+		Model:      "qwen2.5:7b",
+		BaseURL:    "http://localhost:11434",
+		APIVersion: "2024-06-01",
+	}))
+
+	t.Setenv("LLM_BASE_URL_TEST", "http://gpu:11434/v1")
+	cfg = loadChatModelConfig("TEST")
+	g.Expect(cfg.BaseURL).To(gomega.Equal("http://gpu:11434/v1"))
+}
+
+func TestNewChatModel(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+
+	for _, provider := range []string{
+		providerOpenAI, providerAzure, providerOllama, providerLlamaCpp,
+		providerAnthropic, providerGemini, providerArk, providerQwen,
+	} {
+		t.Setenv("LLM_PROVIDER_TEST", provider)
+		t.Setenv("LLM_API_KEY_TEST", "key")
+		t.Setenv("LLM_MODEL_TEST", "model")
+		chatModel, err := newChatModel(ctx, "TEST", nil)
+		g.Expect(err).ToNot(gomega.HaveOccurred(), provider)
+		g.Expect(chatModel.name).To(gomega.Equal("TEST"))
+		g.Expect(chatModel.id).To(gomega.Equal(provider + "/model"))
+	}
+
+	t.Setenv("LLM_PROVIDER_TEST", "unknown")
+	_, err := newChatModel(ctx, "TEST", nil)
+	g.Expect(err).To(gomega.MatchError(errUnknownProvider))
+}
+
+func TestNewChatModel_NativeAPI(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+
+	requests := map[string]map[string]any{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		requests[r.URL.Path] = body
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/messages":
+			_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"model",` +
+				`"content":[{"type":"text","text":"{}"}],"stop_reason":"end_turn",` +
+				`"usage":{"input_tokens":1,"output_tokens":1}}`))
+		case "/api/chat":
+			_, _ = w.Write([]byte(`{"model":"model","created_at":"2026-10-01T00:00:00Z",` +
+				`"message":{"role":"assistant","content":"{}"},"done":true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	// The base url of openai compatible endpoint is accepted by ollama
+	for provider, baseURL := range map[string]string{providerAnthropic: server.URL, providerOllama: server.URL + "/v1"} {
+		chatModel, err := buildChatModel(ctx, "TEST", &chatModelConfig{
+			Provider: provider,
+			Model:    "model",
+			BaseURL:  baseURL,
+		}, nil)
+		g.Expect(err).ToNot(gomega.HaveOccurred(), provider)
+		msg, err := chatModel.Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+		g.Expect(err).ToNot(gomega.HaveOccurred(), provider)
+		g.Expect(msg.Content).To(gomega.Equal("{}"), provider)
+	}
+	g.Expect(requests).To(gomega.HaveKey("/v1/messages"))
+	g.Expect(requests["/v1/messages"]).ToNot(gomega.HaveKey("response_format"))
+	g.Expect(requests["/v1/messages"]).To(gomega.HaveKeyWithValue("max_tokens", float64(anthropicMaxTokens)))
+	g.Expect(requests).To(gomega.HaveKey("/api/chat"))
+	g.Expect(requests["/api/chat"]).To(gomega.HaveKeyWithValue("format", "json"))
+}
+
+func TestExtractMessageText(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	g.Expect(extractMessageText(nil)).To(gomega.Equal(""))
+	g.Expect(extractMessageText(schema.AssistantMessage(`{"a":1}`, nil))).To(gomega.Equal(`{"a":1}`))
+	g.Expect(extractMessageText(schema.AssistantMessage("```json\n{\"a\":1}\n```", nil))).To(gomega.Equal(`{"a":1}`))
+	g.Expect(extractMessageText(schema.AssistantMessage("```{\"a\":1}```", nil))).To(gomega.Equal(`{"a":1}`))
+}
+
+func TestListParserWithFakeChatModel(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+
+	chatModel := &fakeChatModel{
+		response: "```json\n" + `{"error":"","items":[{"url":"/posts/1","title":"Raft","published_at":"2025-05-08"}]}` +
+			"\n```",
+	}
+	a := &listParserImpl{chatModels: fakeChain(chatModel)}
+	err := a.AfterPropertiesSet(ctx)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(a.chatModels[0].BaseChatModel).To(gomega.BeIdenticalTo(chatModel))
+
+	posts, err := a.ParseList(ctx, "<html></html>", "https://example.com/blog/", "example")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(posts).To(gomega.HaveLen(1))
+	g.Expect(posts[0].Path).To(gomega.Equal("https://example.com/posts/1"))
+	g.Expect(posts[0].Title).To(gomega.Equal("Raft"))
+	g.Expect(chatModel.inputs).To(gomega.HaveLen(1))
+	g.Expect(chatModel.inputs[0][0].Content).To(gomega.Equal(listParserSystemPrompt))
+
+	chatModel.response = `{"error":"not a list page"}`
+	_, err = a.ParseList(ctx, "<html></html>", "https://example.com/blog/", "example")
+	g.Expect(err).To(gomega.MatchError(errListParserResponse))
+}
@@ -0,0 +1,128 @@
+package agents
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+	"github.com/anyvoxel/vela/test"
+)
+
+// The cassettes in testdata are recorded against openai by
+// VELA_RECORD=true OPENAI_API_KEY_<AGENT>=... go test ./pkg/agents/ -run Replay
+const replayModel = "gpt-4o-mini"
+
+// replayChatModels return the chat models which send requests by the
+// recorder, the api key is only required when recording.
+func replayChatModels(t *testing.T, rec *test.Recorder, suffix string) chatModelChain {
+	t.Helper()
+
+	apiKey := "replay"
+	if rec.Record {
+		apiKey = os.Getenv("OPENAI_API_KEY_" + suffix)
+	}
+	m, err := buildChatModel(context.Background(), suffix, &chatModelConfig{
+		Provider:   providerOpenAI,
+		APIKey:     apiKey,
+		Model:      replayModel,
+		HTTPClient: rec.Client(),
+	}, nil)
+	if err != nil {
+		t.Fatalf("build chat model failed: %v", err)
+	}
+	return chatModelChain{m}
+}
+
+func TestListParser_Replay(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+	rec := test.NewRecorder(t, "list_parser")
+
+	baseURL := "https://example.com/blog/"
+	resp, err := rec.Client().Get(baseURL)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	defer resp.Body.Close() //nolint
+	html, err := io.ReadAll(resp.Body)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	a := &listParserImpl{chatModels: replayChatModels(t, rec, "LIST_PARSER")}
+	g.Expect(a.AfterPropertiesSet(ctx)).To(gomega.Succeed())
+	posts, err := a.ParseList(ctx, string(html), baseURL, "example")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(posts).To(gomega.HaveLen(3))
+	g.Expect(posts[0].Path).To(gomega.Equal("https://example.com/blog/2025/05/raft-snapshots.html"))
+	g.Expect(posts[0].Title).To(gomega.Equal("Snapshotting the Raft log"))
+	g.Expect(posts[0].Domain).To(gomega.Equal("example"))
+	g.Expect(posts[2].Path).To(gomega.Equal("https://example.com/blog/2025/03/tail-latency.html"))
+}
+
+func TestSummarizer_Replay(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+	rec := test.NewRecorder(t, "summarizer")
+
+	s := &summarizerImpl{
+		chatModels:    replayChatModels(t, rec, "SUMMARIZER"),
+		client:        rec.Client(),
+		summarizeType: "markdown",
+		languages:     []string{"en", "zh-CN"},
+		taxonomyFile:  filepath.Join(test.CurrentProjectPath(), "taxonomy.json"),
+	}
+	g.Expect(s.AfterPropertiesSet(ctx)).To(gomega.Succeed())
+
+	output, err := s.Summary(ctx, apitypes.Post{
+		Domain: "example",
+		Title:  "Snapshotting the Raft log",
+		Path:   "https://example.com/blog/2025/05/raft-snapshots.html",
+	})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(output.Model).To(gomega.Equal(providerOpenAI + "/" + replayModel))
+	g.Expect(output.Summary).To(gomega.HavePrefix("The post explains"))
+	g.Expect(output.Summaries).To(gomega.HaveKey("zh-CN"))
+	g.Expect(output.Tags).To(gomega.Equal([]string{"consensus", "storage"}))
+	g.Expect(output.ContentType).To(gomega.Equal(apitypes.ContentTypeDeepDive))
+
+	_, err = s.Summary(ctx, apitypes.Post{Path: "https://example.com/blog/missing.html"})
+	g.Expect(err).To(gomega.MatchError(errArticleResponse))
+}
+
+// TestSummarizer_ReplayImage replays the image summarize type, the chrome
+// rendering and oss upload are replaced by a fixed screenshot url.
+func TestSummarizer_ReplayImage(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+	rec := test.NewRecorder(t, "summarizer_image")
+
+	screenshot := "https://anyvoxel-vela.oss-cn-beijing.aliyuncs.com/raft-snapshots.png"
+	rendered := 0
+	s := &summarizerImpl{
+		chatModels:    replayChatModels(t, rec, "SUMMARIZER"),
+		client:        rec.Client(),
+		summarizeType: "image",
+		languages:     []string{"en", "zh-CN"},
+		taxonomyFile:  filepath.Join(test.CurrentProjectPath(), "taxonomy.json"),
+		renderFn: func(_ context.Context, _ apitypes.Post, _ captureFunc) (*renderedFile, error) {
+			rendered++
+			return newRenderedFile(screenshot, []byte("png"), func() {}), nil
+		},
+	}
+	g.Expect(s.AfterPropertiesSet(ctx)).To(gomega.Succeed())
+
+	output, err := s.Summary(ctx, apitypes.Post{
+		Domain: "example",
+		Title:  "Snapshotting the Raft log",
+		Path:   "https://example.com/blog/2025/05/raft-snapshots.html",
+	})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(rendered).To(gomega.Equal(1))
+	g.Expect(output.Model).To(gomega.Equal(providerOpenAI + "/" + replayModel))
+	g.Expect(output.Summary).To(gomega.HavePrefix("The post explains"))
+	g.Expect(output.Summaries).To(gomega.HaveKey("zh-CN"))
+	g.Expect(output.Tags).To(gomega.Equal([]string{"consensus", "storage"}))
+	g.Expect(output.ContentType).To(gomega.Equal(apitypes.ContentTypeDeepDive))
+}
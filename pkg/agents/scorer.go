@@ -0,0 +1,223 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/anyvoxel/airmid/anvil"
+	airapp "github.com/anyvoxel/airmid/app"
+	"github.com/anyvoxel/airmid/ioc"
+	slogctx "github.com/veqryn/slog-context"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+	"github.com/anyvoxel/vela/pkg/fetch"
+)
+
+func init() {
+	anvil.Must(airapp.RegisterBeanDefinition(
+		"vela.agents.scorer",
+		ioc.MustNewBeanDefinition(
+			reflect.TypeFor[*scorerImpl](),
+		),
+	))
+}
+
+const scorerSystemPrompt = `You are a precise technical content curator.
+You are given several interest profiles of an engineering team, and a blog post (title, url and the beginning
+of its content in markdown). Rate how relevant the post is to each profile.
+
+Return ONLY valid JSON object with this schema:
+{"error":"", "scores":[{"profile":"", "score":0, "reason":""}]}
+
+Rules:
+- Return one item for each profile, the profile must be the profile name.
+- score is an integer in [0, 100], 0 means irrelevant and 100 means must read.
+- reason is a short justification of the score in one sentence.
+- Judge by the substance of the post, not by the popularity of the website.
+- If the post cannot be judged, return {"error":"the reason", "scores":[]}.
+- Never include any explanation or extra text.
+`
+
+const (
+	// maxScorerArticleBytes is the max bytes of article content sent to llm.
+	maxScorerArticleBytes = 8 * 1024
+)
+
+var (
+	errInterestProfileNameEmpty = errors.New("interest profile name is empty")
+	errScorerResponse           = errors.New("scorer response error")
+)
+
+// interestProfile describes the interest of a team in natural language.
+type interestProfile struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// scorerImpl is an agent that rates the relevance of post.
+type scorerImpl struct {
+	chatModels chatModelChain
+	cache      *responseCache `airmid:"autowire:vela.agents.responseCache"`
+	policy     *fetch.Policy  `airmid:"autowire:vela.fetch.policy"`
+
+	// profilesFile is a path to a JSON file that contains an array of interestProfile.
+	// Example file content:
+	//  [{"name":"storage","description":"We build a distributed database, ..."}]
+	profilesFile string `airmid:"value:${vela.relevance.profiles_file:=}"`
+	profiles     []interestProfile
+	systemPrompt string
+
+	// paperMaxBytes is the same as the summarizer, so the page fetched for
+	// scoring is reused by the summarizer
+	paperMaxBytes int64 `airmid:"value:${vela.summarize.paper.max_bytes:=31457280}"`
+}
+
+var (
+	_ ioc.InitializingBean = (*scorerImpl)(nil)
+	_ Scorer               = (*scorerImpl)(nil)
+)
+
+// AfterPropertiesSet implement InitializingBean
+func (a *scorerImpl) AfterPropertiesSet(ctx context.Context) error {
+	profiles, err := loadInterestProfiles(a.profilesFile)
+	if err != nil {
+		return err
+	}
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	// The chat models are injected in tests
+	if a.chatModels == nil {
+		chatModels, err := newChatModelChain(ctx, "SCORER", a.cache)
+		if err != nil {
+			return err
+		}
+		a.chatModels = chatModels
+	}
+
+	a.profiles = profiles
+	a.systemPrompt = scorerSystemPrompt
+	return nil
+}
+
+func loadInterestProfiles(filePath string) ([]interestProfile, error) {
+	filePath = strings.TrimSpace(filePath)
+	if filePath == "" {
+		return nil, nil
+	}
+
+	b, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("read vela.relevance.profiles_file %q failed: %w", filePath, err)
+	}
+
+	var profiles []interestProfile
+	if err := json.Unmarshal(b, &profiles); err != nil {
+		return nil, fmt.Errorf("invalid profiles file %q: %w", filePath, err)
+	}
+	for i := range profiles {
+		profiles[i].Name = strings.TrimSpace(profiles[i].Name)
+		if profiles[i].Name == "" {
+			return nil, fmt.Errorf("invalid profiles file %q item[%d]: %w", filePath, i, errInterestProfileNameEmpty)
+		}
+	}
+	return profiles, nil
+}
+
+type scoreItem struct {
+	Profile string `json:"profile"`
+	Score   int    `json:"score"`
+	Reason  string `json:"reason"`
+}
+
+type scoreResult struct {
+	Error  string      `json:"error"`
+	Scores []scoreItem `json:"scores"`
+}
+
+// Score implement Scorer.Score
+func (a *scorerImpl) Score(ctx context.Context, post apitypes.Post) (*apitypes.Relevance, error) {
+	if len(a.profiles) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	content, err := a.articleContent(ctx, post)
+	if err != nil {
+		// The title is still helpful to score the post
+		slogctx.FromCtx(ctx).WarnContext(ctx,
+			"fetch article for scorer failed", slog.Any("Error", err))
+	}
+
+	var relevance *apitypes.Relevance
+	_, err = a.chatModels.generate(ctx, a.systemPrompt, &schema.Message{
+		Role:    schema.User,
+		Content: buildScorerMessage(a.profiles, post, content),
+	}, func(text string) (err error) {
+		relevance, err = parseScoreResult(text)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return relevance, nil
+}
+
+// articleContent return the beginning of post in markdown, the page is
+// fetched once with the summarizer if the context is WithPostPages.
+func (a *scorerImpl) articleContent(ctx context.Context, post apitypes.Post) (string, error) {
+	page, err := fetchPostPage(ctx, a.policy.Client(post.Domain), post.Path, a.paperMaxBytes)
+	if err != nil {
+		return "", err
+	}
+	if page.html == "" {
+		return "", fmt.Errorf("%w: content type %q", errArticleResponse, page.contentType)
+	}
+	return articleMarkdown(page.html, post.Path, maxScorerArticleBytes)
+}
+
+func buildScorerMessage(profiles []interestProfile, post apitypes.Post, content string) string {
+	var b strings.Builder
+	b.WriteString("Interest profiles:\n")
+	for _, profile := range profiles {
+		fmt.Fprintf(&b, "- %s: %s\n", profile.Name, profile.Description)
+	}
+	fmt.Fprintf(&b, "\nTitle: %s\nURL: %s\nContent:\n%s", post.Title, post.Path, content)
+	return b.String()
+}
+
+// parseScoreResult return the relevance of the best matched profile.
+func parseScoreResult(text string) (*apitypes.Relevance, error) {
+	var result scoreResult
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("%w: %s", errScorerResponse, result.Error)
+	}
+	if len(result.Scores) == 0 {
+		return nil, fmt.Errorf("%w: scores is empty", errScorerResponse)
+	}
+
+	var relevance *apitypes.Relevance
+	for _, item := range result.Scores {
+		score := min(max(item.Score, 0), 100)
+		if relevance != nil && relevance.Score >= score {
+			continue
+		}
+		relevance = &apitypes.Relevance{
+			Score:   score,
+			Profile: strings.TrimSpace(item.Profile),
+			Reason:  strings.TrimSpace(item.Reason),
+		}
+	}
+	return relevance, nil
+}
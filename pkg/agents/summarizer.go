@@ -3,13 +3,19 @@ package agents
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
-	"os"
+	"net/http"
+	"net/url"
 	"reflect"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aliyun/alibabacloud-oss-go-sdk-v2/oss"
@@ -19,11 +25,12 @@ import (
 	airapp "github.com/anyvoxel/airmid/app"
 	"github.com/anyvoxel/airmid/ioc"
 	"github.com/anyvoxel/vela/pkg/apitypes"
+	"github.com/anyvoxel/vela/pkg/fetch"
+	"github.com/anyvoxel/vela/pkg/storage"
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp"
 	slogctx "github.com/veqryn/slog-context"
 
-	openai "github.com/cloudwego/eino-ext/components/model/openai"
 	"github.com/cloudwego/eino/schema"
 )
 
@@ -39,23 +46,64 @@ func init() {
 //go:embed system_prompts.md
 var systemPrompts string
 
-// Summarizer is the interface for summarizer.
-type Summarizer interface {
-	// Summary summarizes the given content.
-	Summary(ctx context.Context, post apitypes.Post) (string, error)
-}
+// maxSummarizerArticleBytes is the max bytes of article sent by the markdown
+// summarize type.
+const maxSummarizerArticleBytes = 64 * 1024
 
 // summarizerImpl is a agent that can summarize a blog post.
 type summarizerImpl struct {
-	chatModel     *openai.ChatModel
-	summarizeType string `airmid:"value:${vela.summarize.type:=image}"`
+	chatModels    chatModelChain
+	cache         *responseCache `airmid:"autowire:vela.agents.responseCache"`
+	summarizeType string         `airmid:"value:${vela.summarize.type:=image}"`
+	languages     []string       `airmid:"value:${vela.summarize.languages:=zh-CN}"`
+	taxonomyFile  string         `airmid:"value:${vela.summarize.taxonomy_file:=./taxonomy.json}"`
+	taxonomy      taxonomy
 	systemPrompt  string
 
 	ossRegion string `airmid:"value:${vela.summarize.oss.region:=cn-beijing}"`
 	ossBucket string `airmid:"value:${vela.summarize.oss.bucket:=anyvoxel-vela}"`
 	ossClient *oss.Client
 
-	summaryFn func(ctx context.Context, post apitypes.Post) (string, error)
+	// policy limits the article fetching and rendering
+	policy *fetch.Policy `airmid:"autowire:vela.fetch.policy"`
+	// client fetches the article of markdown summarize type and the paper
+	// pdf, it's injected in tests, otherwise the client of post source is used
+	client *http.Client
+
+	// paperMaxPages is the max pages of the paper pdf which are summarized,
+	// and paperMaxBytes is the max size of the paper pdf
+	paperMaxPages int   `airmid:"value:${vela.summarize.paper.max_pages:=30}"`
+	paperMaxBytes int64 `airmid:"value:${vela.summarize.paper.max_bytes:=31457280}"`
+
+	// sourceModels caches the chat model chains of the posts which override
+	// the summarizer models, keyed by the model name
+	mu           sync.Mutex
+	sourceModels map[string]chatModelChain
+
+	// summaryFn summarize the post by the summarize type, the page is nil if
+	// it's failed to fetch
+	summaryFn func(ctx context.Context, post apitypes.Post, page *postPage) (*SummaryOutput, error)
+	// renderFn renders the post to a file in oss, it's injected in tests
+	// because chrome and oss are not available
+	renderFn func(ctx context.Context, post apitypes.Post, capture captureFunc) (*renderedFile, error)
+}
+
+// captureFunc capture the page rendered in chrome, e.g. a screenshot.
+type captureFunc func(ctx context.Context) ([]byte, error)
+
+// renderedFile is the captured file of post which is put to oss.
+type renderedFile struct {
+	url string
+	// digest is the sha256 of the captured content, the url is derived from
+	// the post path and doesn't change with the content
+	digest string
+	// clean deletes the file from oss
+	clean func()
+}
+
+func newRenderedFile(url string, data []byte, clean func()) *renderedFile {
+	sum := sha256.Sum256(data)
+	return &renderedFile{url: url, digest: hex.EncodeToString(sum[:]), clean: clean}
 }
 
 var (
@@ -65,23 +113,25 @@ var (
 
 // AfterPropertiesSet implement InitializingBean
 func (a *summarizerImpl) AfterPropertiesSet(ctx context.Context) error {
-	responseFormat := &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
-	chatModel, err := openai.NewChatModel(ctx, &openai.ChatModelConfig{
-		APIKey:         os.Getenv("OPENAI_API_KEY_SUMMARIZER"),
-		Model:          os.Getenv("OPENAI_MODEL_SUMMARIZER"),
-		BaseURL:        os.Getenv("OPENAI_BASE_URL_SUMMARIZER"),
-		ResponseFormat: responseFormat,
-		ByAzure:        os.Getenv("OPENAI_BY_AZURE_SUMMARIZER") == "true",
-	})
-	if err != nil {
-		return err
+	// The chat models are injected in tests
+	if a.chatModels == nil {
+		chatModels, err := newChatModelChain(ctx, "SUMMARIZER", a.cache)
+		if err != nil {
+			return err
+		}
+		a.chatModels = chatModels
 	}
 
+	if a.renderFn == nil {
+		a.renderFn = a.render
+	}
 	switch a.summarizeType {
 	case "image":
 		a.summaryFn = a.summarizeByImage
 	case "pdf":
 		a.summaryFn = a.summarizeByPdf
+	case "markdown":
+		a.summaryFn = a.summarizeByMarkdown
 	default:
 		return xerrors.Errorf("Unknown summary type: %s", a.summarizeType)
 	}
@@ -91,36 +141,200 @@ func (a *summarizerImpl) AfterPropertiesSet(ctx context.Context) error {
 		WithRegion(a.ossRegion)
 	a.ossClient = oss.NewClient(cfg)
 
-	a.chatModel = chatModel
-	a.systemPrompt = systemPrompts
+	languages := make([]string, 0, len(a.languages))
+	for _, lang := range a.languages {
+		lang = strings.TrimSpace(lang)
+		if lang == "" || slices.Contains(languages, lang) {
+			continue
+		}
+		languages = append(languages, lang)
+	}
+	if len(languages) == 0 {
+		languages = []string{storage.DefaultLanguage}
+	}
+
+	taxonomy, err := loadTaxonomy(a.taxonomyFile)
+	if err != nil {
+		return err
+	}
+
+	a.languages = languages
+	a.taxonomy = taxonomy
+	a.systemPrompt = renderSystemPrompt(systemPrompts, languages, taxonomy)
 	return nil
 }
 
+// languageNames is used to describe the language tag to llm.
+var languageNames = map[string]string{
+	"zh-CN": "simplified chinese",
+	"zh-TW": "traditional chinese",
+	"en":    "english",
+	"ja":    "japanese",
+	"ko":    "korean",
+	"fr":    "french",
+	"de":    "german",
+	"es":    "spanish",
+}
+
+// renderSystemPrompt will fill the languages and taxonomy into the prompt template.
+func renderSystemPrompt(prompt string, languages []string, taxonomy taxonomy) string {
+	descs := make([]string, 0, len(languages))
+	for _, lang := range languages {
+		name, ok := languageNames[lang]
+		if !ok {
+			descs = append(descs, fmt.Sprintf("`%s`", lang))
+			continue
+		}
+		descs = append(descs, fmt.Sprintf("`%s` (%s)", lang, name))
+	}
+	contentTypes := make([]string, 0, len(apitypes.ContentTypes))
+	for _, typ := range apitypes.ContentTypes {
+		contentTypes = append(contentTypes, string(typ))
+	}
+
+	return strings.NewReplacer(
+		"{{LANGUAGES}}", strings.Join(descs, ", "),
+		"{{CONTENT_TYPES}}", strings.Join(contentTypes, ", "),
+		"{{TAXONOMY}}", taxonomy.prompt(),
+	).Replace(prompt)
+}
+
 type generateResult struct {
-	Error   string `json:"error"`
+	Error     string            `json:"error"`
+	Summaries map[string]string `json:"summaries"`
+
+	// Summary is used when the llm ignore the summaries field
 	Summary string `json:"summary"`
+
+	Title       string `json:"title"`
+	PublishedAt string `json:"published_at"`
+
+	apitypes.SummaryDetail
 }
 
-// Summary implement Summarizer.Summary
-func (a *summarizerImpl) Summary(ctx context.Context, post apitypes.Post) (string, error) {
-	text, err := a.summaryFn(ctx, post)
-	if err != nil {
-		return "", err
+// Summary implement Summarizer.Summary, the pdf is summarized as a research
+// paper and the video or audio by its transcript regardless of the summarize
+// type. The post is fetched once to detect them, and the fetched page is
+// reused by the markdown summarize type.
+func (a *summarizerImpl) Summary(ctx context.Context, post apitypes.Post) (*SummaryOutput, error) {
+	client := a.postClient(post)
+	page, err := fetchPostPage(ctx, client, post.Path, a.paperMaxBytes)
+	switch {
+	case err != nil && (a.summarizeType == "markdown" || isPDFPath(post.Path) ||
+		errors.Is(err, errNotPDF) || errors.Is(err, errPaperTooLarge)):
+		return nil, err
+	case err != nil:
+		// The page may be rendered by chrome, e.g. it rejects the http client
+		slogctx.FromCtx(ctx).DebugContext(ctx, "fetch page to detect pdf and media failed",
+			slog.Any("Error", err),
+		)
+		page = nil
+	case page.pdf != nil:
+		return a.summarizePaper(ctx, post, page.pdf)
+	// The page which is not html is not a media page, e.g. an image
+	case page.html != "":
+		output, err := a.summarizeMedia(ctx, client, post, page.html)
+		if !errors.Is(err, errNotMediaPage) {
+			return output, err
+		}
 	}
+	return a.summaryFn(ctx, post, page)
+}
 
-	slogctx.FromCtx(ctx).InfoContext(ctx,
-		"generate llm output", slog.String("Output", text))
+// postClient return the http client to fetch the post.
+func (a *summarizerImpl) postClient(post apitypes.Post) *http.Client {
+	if a.client != nil {
+		return a.client
+	}
+	return a.policy.Client(post.Domain)
+}
+
+func (a *summarizerImpl) parseSummaryOutput(ctx context.Context, text string) (*SummaryOutput, error) {
+	languages := a.languages
 	var result generateResult
-	err = json.Unmarshal([]byte(text), &result)
+	err := json.Unmarshal([]byte(text), &result)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if result.Error != "" {
-		return "", errors.New(result.Error) //nolint
+		return nil, errors.New(result.Error) //nolint
 	}
 
-	return result.Summary, nil
+	if len(result.Summaries) == 0 && result.Summary != "" {
+		result.Summaries = map[string]string{languages[0]: result.Summary}
+	}
+
+	output := &SummaryOutput{
+		Summaries: make(map[string]string, len(languages)),
+	}
+	for _, lang := range languages {
+		summary := strings.TrimSpace(result.Summaries[lang])
+		if summary == "" {
+			slogctx.FromCtx(ctx).WarnContext(ctx,
+				"summary of language is missing", slog.String("Language", lang))
+			continue
+		}
+		output.Summaries[lang] = summary
+	}
+	output.Summary = output.Summaries[languages[0]]
+	output.Title = strings.TrimSpace(result.Title)
+	output.PublishedAt = parsePublishedAt(strings.TrimSpace(result.PublishedAt))
+	output.SummaryDetail = normalizeSummaryDetail(result.SummaryDetail)
+	output.Tags = a.taxonomy.filter(output.Tags)
+	return output, nil
+}
+
+func normalizeSummaryDetail(detail apitypes.SummaryDetail) apitypes.SummaryDetail {
+	detail.Thesis = strings.TrimSpace(detail.Thesis)
+	detail.KeyPoints = compactStrings(detail.KeyPoints)
+	detail.Takeaways = compactStrings(detail.Takeaways)
+	detail.Audience = strings.TrimSpace(detail.Audience)
+	detail.Keywords = compactStrings(detail.Keywords)
+	detail.ReadingTimeMinutes = max(detail.ReadingTimeMinutes, 0)
+
+	detail.ContentType = apitypes.ContentType(strings.ToLower(strings.TrimSpace(string(detail.ContentType))))
+	if detail.ContentType != "" && !slices.Contains(apitypes.ContentTypes, detail.ContentType) {
+		detail.ContentType = apitypes.ContentTypeOther
+	}
+	detail.Paper = normalizePaperDetail(detail.Paper)
+	return detail
+}
+
+// normalizePaperDetail return nil if the paper has nothing, e.g. the llm
+// outputs the empty fields for a blog post.
+func normalizePaperDetail(paper *apitypes.PaperDetail) *apitypes.PaperDetail {
+	if paper == nil {
+		return nil
+	}
+	normalized := apitypes.PaperDetail{
+		Authors:     compactStrings(paper.Authors),
+		Venue:       strings.TrimSpace(paper.Venue),
+		Problem:     strings.TrimSpace(paper.Problem),
+		Method:      strings.TrimSpace(paper.Method),
+		Results:     strings.TrimSpace(paper.Results),
+		Limitations: strings.TrimSpace(paper.Limitations),
+	}
+	if reflect.ValueOf(normalized).IsZero() {
+		return nil
+	}
+	return &normalized
+}
+
+// compactStrings trims all items and drops the empty or duplicated ones.
+func compactStrings(items []string) []string {
+	ret := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" || slices.Contains(ret, item) {
+			continue
+		}
+		ret = append(ret, item)
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	return ret
 }
 
 func (a *summarizerImpl) putFileToOSS(ctx context.Context, filename string, data []byte) (string, func(), error) {
@@ -184,15 +398,26 @@ func (a *summarizerImpl) putFileToOSS(ctx context.Context, filename string, data
 	}, nil
 }
 
-func (a *summarizerImpl) runActionInChrome(ctx context.Context, path string, fn chromedp.ActionFunc) error {
+func (a *summarizerImpl) runActionInChrome(ctx context.Context, post apitypes.Post, fn chromedp.ActionFunc) error {
+	u, err := url.Parse(post.Path)
+	if err != nil {
+		return err
+	}
+	// Only the page is limited by the policy, not its resources
+	release, err := a.policy.Acquire(ctx, post.Domain, u)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
 		chromedp.Flag("no-sandbox", true),
 		chromedp.Flag("disable-gpu", true),
 		chromedp.Flag("headless", true),
-		//nolint
-		// See https://stackoverflow.com/questions/70535305/getting-403-forbidden-error-when-using-headless-chrome-with-python-selenium
-		chromedp.Flag("user-agent", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/60.0.3112.50 Safari/537.36"),
 	)
+	// The User-Agent is set because the default one of headless chrome is
+	// rejected by some sites
+	opts = append(opts, a.policy.ChromeOptions(post.Domain)...)
 
 	allocCtx, cancel := chromedp.NewExecAllocator(ctx, opts...)
 	defer cancel()
@@ -200,62 +425,65 @@ func (a *summarizerImpl) runActionInChrome(ctx context.Context, path string, fn
 	defer cancel()
 
 	return chromedp.Run(dpctx,
-		chromedp.Navigate(path),
+		a.policy.ChromeActions(post.Domain, u),
+		chromedp.Navigate(post.Path),
 		chromedp.Sleep(10*time.Second),
 		chromedp.WaitReady("body"),
 		fn,
 	)
 }
 
-func (a *summarizerImpl) summarizeByPdf(ctx context.Context, post apitypes.Post) (string, error) {
+// render renders the post in chrome and put the captured file to oss.
+func (a *summarizerImpl) render(ctx context.Context, post apitypes.Post, capture captureFunc) (*renderedFile, error) {
 	var buf []byte
-	var err error
-
-	err = a.runActionInChrome(ctx, post.Path, chromedp.ActionFunc(func(ctx context.Context) error {
-		buf, _, err = page.PrintToPDF().Do(ctx)
+	err := a.runActionInChrome(ctx, post, chromedp.ActionFunc(func(ctx context.Context) (err error) {
+		buf, err = capture(ctx)
 		return err
 	}))
-
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	url, clean, err := a.putFileToOSS(ctx, post.Path, buf)
+	if err != nil {
+		return nil, err
+	}
+	return newRenderedFile(url, buf, clean), nil
+}
 
-	path, clean, err := a.putFileToOSS(ctx, post.Path, buf)
+func (a *summarizerImpl) summarizeByPdf(
+	ctx context.Context, post apitypes.Post, _ *postPage,
+) (*SummaryOutput, error) {
+	file, err := a.renderFn(ctx, post, func(ctx context.Context) ([]byte, error) {
+		buf, _, err := page.PrintToPDF().Do(ctx)
+		return buf, err
+	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer clean()
+	defer file.clean()
 
 	message := &schema.Message{
 		Role:    schema.User,
-		Content: fmt.Sprintf("Please summarize the following blog post in the pdf {%s}", path),
+		Content: fmt.Sprintf("Please summarize the following blog post in the pdf {%s}", file.url),
+		Extra:   map[string]any{attachmentDigestKey: file.digest},
 	}
-	return a.generate(ctx, message)
+	return a.generate(ctx, post, message)
 }
 
-func (a *summarizerImpl) summarizeByImage(ctx context.Context, post apitypes.Post) (string, error) {
-	var buf []byte
-	var err error
-
-	err = a.runActionInChrome(ctx, post.Path, chromedp.ActionFunc(func(ctx context.Context) error {
-		var err error
-		buf, err = page.CaptureScreenshot().
+func (a *summarizerImpl) summarizeByImage(
+	ctx context.Context, post apitypes.Post, _ *postPage,
+) (*SummaryOutput, error) {
+	file, err := a.renderFn(ctx, post, func(ctx context.Context) ([]byte, error) {
+		return page.CaptureScreenshot().
 			WithQuality(90).
 			WithCaptureBeyondViewport(true).
 			WithFromSurface(true).
 			Do(ctx)
-		return err
-	}))
-
-	if err != nil {
-		return "", err
-	}
-
-	path, clean, err := a.putFileToOSS(ctx, post.Path, buf)
+	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer clean()
+	defer file.clean()
 
 	message := &schema.Message{
 		Role: schema.User,
@@ -268,30 +496,110 @@ func (a *summarizerImpl) summarizeByImage(ctx context.Context, post apitypes.Pos
 				Type: schema.ChatMessagePartTypeImageURL,
 				Image: &schema.MessageInputImage{
 					MessagePartCommon: schema.MessagePartCommon{
-						URL: &path,
+						URL: &file.url,
 					},
 					Detail: schema.ImageURLDetailAuto,
 				},
+				Extra: map[string]any{attachmentDigestKey: file.digest},
 			},
 		},
 	}
-	return a.generate(ctx, message)
+	return a.generate(ctx, post, message)
 }
 
-func (a *summarizerImpl) generate(ctx context.Context, userMessage *schema.Message) (string, error) {
-	resp, err := a.chatModel.Generate(ctx, []*schema.Message{
-		{
-			Role:    schema.System,
-			Content: a.systemPrompt,
-		},
-		userMessage,
+// summarizeByMarkdown send the article in markdown instead of rendering it
+// in chrome, it works with the text only models. The page fetched by Summary
+// is used, it must be html.
+func (a *summarizerImpl) summarizeByMarkdown(
+	ctx context.Context, post apitypes.Post, page *postPage,
+) (*SummaryOutput, error) {
+	if page == nil {
+		return nil, fmt.Errorf("%w: page is not fetched", errArticleResponse)
+	}
+	if page.html == "" {
+		return nil, fmt.Errorf("%w: content type %q", errArticleResponse, page.contentType)
+	}
+	content, err := articleMarkdown(page.html, post.Path, maxSummarizerArticleBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	message := &schema.Message{
+		Role: schema.User,
+		Content: fmt.Sprintf("Please summarize the following blog post in markdown\nTitle: %s\nURL: %s\nContent:\n%s",
+			post.Title, post.Path, content),
+	}
+	return a.generate(ctx, post, message)
+}
+
+func (a *summarizerImpl) generate(
+	ctx context.Context, post apitypes.Post, userMessage *schema.Message,
+) (*SummaryOutput, error) {
+	chatModels, err := a.chatModelsOf(ctx, post)
+	if err != nil {
+		return nil, err
+	}
+
+	var output *SummaryOutput
+	modelID, err := chatModels.generate(ctx, a.systemPrompt, userMessage, func(text string) (err error) {
+		slogctx.FromCtx(ctx).InfoContext(ctx,
+			"generate llm output", slog.String("Output", text))
+		output, err = a.parseSummaryOutput(ctx, text)
+		return err
 	})
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	output.Model = modelID
+	return output, nil
+}
+
+// chatModelsOf return the chat models to summarize the post, the models of
+// post and their LLM_FALLBACKS_<NAME> are tried before the summarizer ones.
+// Each model is tried once, the summarizer instance is used for its models.
+func (a *summarizerImpl) chatModelsOf(ctx context.Context, post apitypes.Post) (chatModelChain, error) {
+	names := splitNames(strings.Join(post.Models, ","))
+	if len(names) == 0 {
+		return a.chatModels, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.sourceModels == nil {
+		a.sourceModels = make(map[string]chatModelChain)
+	}
+
+	chain := make(chatModelChain, 0, len(names)+len(a.chatModels))
+	add := func(m *chatModel) {
+		if slices.ContainsFunc(chain, func(c *chatModel) bool { return c.name == m.name }) {
+			return
+		}
+		if idx := slices.IndexFunc(a.chatModels, func(c *chatModel) bool { return c.name == m.name }); idx >= 0 {
+			m = a.chatModels[idx]
+		}
+		chain = append(chain, m)
+	}
+	for _, name := range names {
+		if idx := slices.IndexFunc(a.chatModels, func(m *chatModel) bool { return m.name == name }); idx >= 0 {
+			add(a.chatModels[idx])
+			continue
+		}
+
+		models, ok := a.sourceModels[name]
+		if !ok {
+			var err error
+			models, err = newChatModelChain(ctx, name, a.cache)
+			if err != nil {
+				return nil, err
+			}
+			a.sourceModels[name] = models
+		}
+		for _, m := range models {
+			add(m)
+		}
 	}
-	text := extractMessageText(resp)
-	if text == "" {
-		return "", xerrors.Errorf("empty response from llm")
+	for _, m := range a.chatModels {
+		add(m)
 	}
-	return text, nil
+	return chain, nil
 }
@@ -0,0 +1,81 @@
+package agents
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var errTaxonomyTagNameEmpty = errors.New("taxonomy tag name is empty")
+
+// taxonomyTag is a tag which can be attached to summarized posts.
+type taxonomyTag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// taxonomy is the configured tag set, it's loaded from a JSON file that
+// contains an array of taxonomyTag.
+// Example file content:
+//
+//	[{"name":"databases","description":"Storage engines, query processing, transactions"}]
+type taxonomy []taxonomyTag
+
+func loadTaxonomy(filePath string) (taxonomy, error) {
+	filePath = strings.TrimSpace(filePath)
+	if filePath == "" {
+		return nil, nil
+	}
+
+	b, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("read vela.summarize.taxonomy_file %q failed: %w", filePath, err)
+	}
+
+	var tags taxonomy
+	if err := json.Unmarshal(b, &tags); err != nil {
+		return nil, fmt.Errorf("invalid taxonomy file %q: %w", filePath, err)
+	}
+	for i := range tags {
+		tags[i].Name = strings.TrimSpace(tags[i].Name)
+		if tags[i].Name == "" {
+			return nil, fmt.Errorf("invalid taxonomy file %q item[%d]: %w", filePath, i, errTaxonomyTagNameEmpty)
+		}
+	}
+	return tags, nil
+}
+
+// prompt render the taxonomy as markdown list for llm.
+func (t taxonomy) prompt() string {
+	if len(t) == 0 {
+		return "- (no taxonomy is configured, the tags MUST be an empty list)"
+	}
+
+	lines := make([]string, 0, len(t))
+	for _, tag := range t {
+		if tag.Description == "" {
+			lines = append(lines, fmt.Sprintf("- `%s`", tag.Name))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("- `%s`: %s", tag.Name, tag.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// filter return the canonical name of tags which are defined in taxonomy,
+// all unknown tags are dropped.
+func (t taxonomy) filter(tags []string) []string {
+	ret := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		for _, known := range t {
+			if !strings.EqualFold(strings.TrimSpace(tag), known.Name) {
+				continue
+			}
+			ret = append(ret, known.Name)
+			break
+		}
+	}
+	return compactStrings(ret)
+}
@@ -15,4 +15,14 @@ type Post struct {
 	// It's the full URL path of current post
 	Path        string
 	PublishedAt time.Time
+
+	// Source is empty for the collected post, or SourceManual
+	Source string
+
+	// Models is the names of chat model to summarize the post before the
+	// summarizer ones, e.g. LONG_CONTEXT refers to the LLM_*_LONG_CONTEXT env
+	Models []string
 }
+
+// SourceManual is the source of post submitted by user.
+const SourceManual = "manual"
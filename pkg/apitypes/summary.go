@@ -0,0 +1,81 @@
+package apitypes
+
+// ContentType is the kind of a post.
+type ContentType string
+
+// All known content types, the summarizer will fallback to ContentTypeOther.
+const (
+	ContentTypeTutorial     ContentType = "tutorial"
+	ContentTypeOpinion      ContentType = "opinion"
+	ContentTypePaper        ContentType = "paper"
+	ContentTypeNews         ContentType = "news"
+	ContentTypeAnnouncement ContentType = "announcement"
+	ContentTypeCaseStudy    ContentType = "case_study"
+	ContentTypeDeepDive     ContentType = "deep_dive"
+	ContentTypeListicle     ContentType = "listicle"
+	ContentTypeOther        ContentType = "other"
+)
+
+// ContentTypes is all known content types.
+var ContentTypes = []ContentType{
+	ContentTypeTutorial,
+	ContentTypeOpinion,
+	ContentTypePaper,
+	ContentTypeNews,
+	ContentTypeAnnouncement,
+	ContentTypeCaseStudy,
+	ContentTypeDeepDive,
+	ContentTypeListicle,
+	ContentTypeOther,
+}
+
+// SummaryDetail is the structured fields of a summary, the text fields are
+// written in the first configured summary language.
+type SummaryDetail struct {
+	// Thesis is the main thesis of the post in one sentence
+	Thesis string `json:"thesis,omitempty"`
+	// KeyPoints is the key findings/insights of the post
+	KeyPoints []string `json:"key_points,omitempty"`
+	// Takeaways is the practical applications of the post
+	Takeaways []string `json:"takeaways,omitempty"`
+	// Audience is the recommended audience of the post
+	Audience string `json:"audience,omitempty"`
+	// Tags is the tags chosen from the configured taxonomy
+	Tags []string `json:"tags,omitempty"`
+	// Keywords is the free-form topics/keywords of the post
+	Keywords []string `json:"keywords,omitempty"`
+	// ReadingTimeMinutes is the estimated reading time of the original post
+	ReadingTimeMinutes int `json:"reading_time_minutes,omitempty"`
+	// ContentType is the kind of the post
+	ContentType ContentType `json:"content_type,omitempty"`
+	// Paper is the metadata and the structured summary of a research paper,
+	// it's nil for the other posts
+	Paper *PaperDetail `json:"paper,omitempty"`
+}
+
+// PaperDetail is the metadata and the structured summary of a research
+// paper, the text fields are written in the first configured summary language.
+type PaperDetail struct {
+	// Authors is the authors of the paper in order
+	Authors []string `json:"authors,omitempty"`
+	// Venue is the conference, journal or preprint server, e.g. OSDI 2024
+	Venue string `json:"venue,omitempty"`
+	// Problem is the problem addressed by the paper
+	Problem string `json:"problem,omitempty"`
+	// Method is the approach of the paper
+	Method string `json:"method,omitempty"`
+	// Results is the main results of the evaluation
+	Results string `json:"results,omitempty"`
+	// Limitations is the limitations stated or implied by the paper
+	Limitations string `json:"limitations,omitempty"`
+}
+
+// Relevance is the score of a post against the interest profiles.
+type Relevance struct {
+	// Score is in [0, 100], the higher the more relevant
+	Score int `json:"score"`
+	// Profile is the name of the best matched interest profile
+	Profile string `json:"profile,omitempty"`
+	// Reason is a short justification of the score
+	Reason string `json:"reason,omitempty"`
+}
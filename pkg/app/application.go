@@ -2,10 +2,14 @@
 package app
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"log/slog"
 	"reflect"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/anyvoxel/airmid/anvil"
 	airapp "github.com/anyvoxel/airmid/app"
@@ -15,6 +19,8 @@ import (
 	"github.com/anyvoxel/vela/pkg/agents"
 	"github.com/anyvoxel/vela/pkg/apitypes"
 	"github.com/anyvoxel/vela/pkg/collectors/framework"
+	"github.com/anyvoxel/vela/pkg/notifiers"
+	"github.com/anyvoxel/vela/pkg/search"
 	"github.com/anyvoxel/vela/pkg/storage"
 )
 
@@ -31,7 +37,17 @@ func init() {
 type Application struct {
 	f            *framework.Framework `airmid:"autowire:?"`
 	summaryAgent agents.Summarizer    `airmid:"autowire:vela.agents.summarizer"`
+	scorer       agents.Scorer        `airmid:"autowire:vela.agents.scorer"`
 	store        storage.Storage      `airmid:"autowire:vela.storage.storage"`
+	notifiers    []notifiers.Notifier `airmid:"autowire:?"`
+	searcher     search.Searcher      `airmid:"autowire:vela.search.searcher"`
+
+	command  string    `airmid:"value:${vela.command:=collect}"`
+	commands []command `airmid:"autowire:?"`
+
+	// threshold is the min relevance score to summarize the post, the post
+	// below it is recorded without summary.
+	threshold int `airmid:"value:${vela.relevance.threshold:=0}"`
 
 	airmidApplication airapp.Application
 }
@@ -44,9 +60,11 @@ var (
 // Run implement Runner.Run
 func (a *Application) Run(ctx context.Context) {
 	go func() {
-		err := a.Start(ctx)
+		err := a.execute(ctx)
 		if err != nil {
-			slogctx.FromCtx(ctx).ErrorContext(ctx, "start application failed", slog.Any("Error", err))
+			slogctx.FromCtx(ctx).ErrorContext(ctx, "start application failed",
+				slog.String("Command", a.command),
+				slog.Any("Error", err))
 		}
 
 		a.airmidApplication.Shutdown()
@@ -65,6 +83,8 @@ func (a *Application) SetApplication(application airapp.Application) {
 func (a *Application) Start(ctx context.Context) error {
 	ch := make(chan apitypes.Post, 100)
 	results := make([]*storage.SummaryResult, 0)
+	run := &storage.Run{StartedAt: time.Now().UTC()}
+	run.ID = run.StartedAt.Format("20060102T150405Z")
 
 	var wg sync.WaitGroup
 	wg.Add(2)
@@ -92,38 +112,293 @@ func (a *Application) Start(ctx context.Context) error {
 				slog.String("Path", post.Path),
 				slog.String("Domain", post.Domain),
 				slog.String("Title", post.Title))
-			result, err := a.summaryAgent.Summary(cctx, post)
-			if err != nil {
-				slogctx.FromCtx(cctx).ErrorContext(ctx,
-					"summary post failed",
-					slog.Any("Error", err),
-				)
+			run.Collected++
+			result := a.process(cctx, post)
+			if result == nil {
+				run.Failed++
 				continue
 			}
-
-			if result == "" {
-				slogctx.FromCtx(cctx).ErrorContext(ctx,
-					"post summary is empty",
-				)
+			if result.Status != "" {
+				run.Skipped++
+			} else {
+				run.Summarized++
 			}
 
 			existPaths[post.Path] = true
-			results = append(results, &storage.SummaryResult{
-				Domain:      post.Domain,
-				Path:        post.Path,
-				Title:       post.Title,
-				Summary:     result,
-				PublishedAt: post.PublishedAt,
-			})
+			results = append(results, result)
 		}
 	}()
 
 	wg.Wait()
 
 	err := a.store.Put(ctx, results)
+	if err == nil {
+		err = a.dequeueSubmissions(ctx, results)
+	}
+	if err == nil {
+		err = a.resummarize(ctx, run)
+	}
+	a.putRun(ctx, run, err)
 	if err != nil {
 		return err
 	}
+	a.notify(ctx, results)
+	a.index(ctx)
 	slogctx.FromCtx(ctx).InfoContext(ctx, "process done")
 	return nil
 }
+
+// dequeueSubmissions will remove the persisted submitted posts from queue.
+func (a *Application) dequeueSubmissions(ctx context.Context, results []*storage.SummaryResult) error {
+	for _, result := range results {
+		if result.Source != apitypes.SourceManual {
+			continue
+		}
+
+		err := a.store.DequeueSubmission(ctx, result.Path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resummarize will summary the queued posts again, the failed post is
+// kept in queue to be retried in next run.
+func (a *Application) resummarize(ctx context.Context, run *storage.Run) error {
+	paths, err := a.store.ResummarizeQueue(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		previous, err := a.store.Get(ctx, storage.PostID(path))
+		if err != nil {
+			if !errors.Is(err, storage.ErrNotFound) {
+				return err
+			}
+
+			slogctx.FromCtx(ctx).WarnContext(ctx, "drop unknown post in resummarize queue",
+				slog.String("Path", path))
+			err = a.store.DequeueResummarize(ctx, path)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		post := apitypes.Post{
+			Domain:      previous.Domain,
+			Title:       previous.Title,
+			Path:        previous.Path,
+			PublishedAt: previous.PublishedAt,
+			Source:      previous.Source,
+		}
+		if a.f != nil {
+			post.Models = a.f.SourceModels(post.Domain)
+		}
+		cctx := slogctx.With(ctx,
+			slog.String("Path", post.Path),
+			slog.String("Domain", post.Domain),
+			slog.String("Title", post.Title))
+		// The cached response is the summary to be replaced
+		output, err := a.summaryAgent.Summary(agents.WithCacheRefresh(cctx), post)
+		var result *storage.SummaryResult
+		switch {
+		case errors.Is(err, agents.ErrUnsupportedMedia):
+			// The previous summary of the media is meaningless
+			result = newSkippedResult(post, previous.Relevance, storage.StatusUnsupportedMedia)
+		case err != nil:
+			slogctx.FromCtx(cctx).ErrorContext(ctx,
+				"resummary post failed",
+				slog.Any("Error", err),
+			)
+			continue
+		default:
+			// The explicit request ignores the relevance threshold
+			result = newSummaryResult(post, output, previous.Relevance)
+		}
+
+		err = a.store.Update(ctx, []*storage.SummaryResult{result})
+		if err != nil {
+			return err
+		}
+		err = a.store.DequeueResummarize(ctx, path)
+		if err != nil {
+			return err
+		}
+		run.Resummarized++
+	}
+	return nil
+}
+
+// putRun will persist the run history, the failure is logged because the
+// results are more important.
+func (a *Application) putRun(ctx context.Context, run *storage.Run, runErr error) {
+	run.FinishedAt = time.Now().UTC()
+	if runErr != nil {
+		run.Error = runErr.Error()
+	}
+
+	err := a.store.PutRun(ctx, run)
+	if err != nil {
+		slogctx.FromCtx(ctx).ErrorContext(ctx, "save run failed",
+			slog.Any("Error", err),
+		)
+	}
+}
+
+// process will score and summary the post, it return nil if the post
+// should be retried in next run.
+func (a *Application) process(ctx context.Context, post apitypes.Post) *storage.SummaryResult {
+	// The post page is fetched once by the scorer and summarizer
+	ctx = agents.WithPostPages(ctx)
+	relevance := a.score(ctx, post)
+	// The submitted post is always summarized
+	if relevance != nil && relevance.Score < a.threshold && post.Source != apitypes.SourceManual {
+		slogctx.FromCtx(ctx).InfoContext(ctx,
+			"skip summary post with low relevance",
+			slog.Int("Score", relevance.Score),
+		)
+		return newSkippedResult(post, relevance, storage.StatusSkippedLowRelevance)
+	}
+
+	output, err := a.summaryAgent.Summary(ctx, post)
+	if errors.Is(err, agents.ErrUnsupportedMedia) {
+		slogctx.FromCtx(ctx).InfoContext(ctx,
+			"skip summary post of unsupported media",
+			slog.Any("Error", err),
+		)
+		return newSkippedResult(post, relevance, storage.StatusUnsupportedMedia)
+	}
+	if err != nil {
+		slogctx.FromCtx(ctx).ErrorContext(ctx,
+			"summary post failed",
+			slog.Any("Error", err),
+		)
+		return nil
+	}
+
+	if output.Summary == "" {
+		slogctx.FromCtx(ctx).ErrorContext(ctx,
+			"post summary is empty",
+		)
+	}
+
+	if post.Title == "" {
+		post.Title = output.Title
+	}
+	if post.PublishedAt.IsZero() {
+		post.PublishedAt = output.PublishedAt
+	}
+	return newSummaryResult(post, output, relevance)
+}
+
+func newSummaryResult(
+	post apitypes.Post, output *agents.SummaryOutput, relevance *apitypes.Relevance,
+) *storage.SummaryResult {
+	return &storage.SummaryResult{
+		Domain:        post.Domain,
+		Path:          post.Path,
+		Title:         post.Title,
+		Summary:       output.Summary,
+		Summaries:     output.Summaries,
+		SummaryDetail: output.SummaryDetail,
+		Relevance:     relevance,
+		Source:        post.Source,
+		Model:         output.Model,
+		CollectedAt:   time.Now().UTC(),
+		PublishedAt:   post.PublishedAt,
+	}
+}
+
+// newSkippedResult return the result of post which is recorded with status
+// but without summary.
+func newSkippedResult(post apitypes.Post, relevance *apitypes.Relevance, status string) *storage.SummaryResult {
+	return &storage.SummaryResult{
+		Domain:      post.Domain,
+		Path:        post.Path,
+		Title:       post.Title,
+		Relevance:   relevance,
+		Status:      status,
+		Source:      post.Source,
+		CollectedAt: time.Now().UTC(),
+		PublishedAt: post.PublishedAt,
+	}
+}
+
+// score return nil if the scorer is not configured or failed, the post
+// will be summarized as usual.
+func (a *Application) score(ctx context.Context, post apitypes.Post) *apitypes.Relevance {
+	if a.scorer == nil {
+		return nil
+	}
+
+	relevance, err := a.scorer.Score(ctx, post)
+	if err != nil {
+		slogctx.FromCtx(ctx).ErrorContext(ctx,
+			"score post failed",
+			slog.Any("Error", err),
+		)
+		return nil
+	}
+	return relevance
+}
+
+// index will embed the new results for search, the failure only affects
+// the search so it's logged.
+func (a *Application) index(ctx context.Context) {
+	if a.searcher == nil {
+		return
+	}
+
+	_, err := a.searcher.Index(ctx)
+	if err != nil {
+		slogctx.FromCtx(ctx).ErrorContext(ctx, "index results failed",
+			slog.Any("Error", err),
+		)
+	}
+}
+
+// notify will deliver the results to all notifiers, a failed notifier
+// will not block the others.
+func (a *Application) notify(ctx context.Context, results []*storage.SummaryResult) {
+	results = rankResults(results)
+	if len(results) == 0 {
+		return
+	}
+
+	for _, n := range a.notifiers {
+		err := n.Notify(ctx, results)
+		if err != nil {
+			slogctx.FromCtx(ctx).ErrorContext(ctx, "notify results failed",
+				slog.String("Notifier", n.Name()),
+				slog.Any("Error", err),
+			)
+		}
+	}
+}
+
+// rankResults drop the skipped results, and sort the others by relevance
+// score in descending order.
+func rankResults(results []*storage.SummaryResult) []*storage.SummaryResult {
+	ranked := make([]*storage.SummaryResult, 0, len(results))
+	for _, result := range results {
+		if result.Status != "" {
+			continue
+		}
+		ranked = append(ranked, result)
+	}
+
+	slices.SortStableFunc(ranked, func(x, y *storage.SummaryResult) int {
+		return cmp.Compare(relevanceScore(y), relevanceScore(x))
+	})
+	return ranked
+}
+
+func relevanceScore(result *storage.SummaryResult) int {
+	if result.Relevance == nil {
+		return -1
+	}
+	return result.Relevance.Score
+}
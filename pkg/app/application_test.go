@@ -2,17 +2,23 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/onsi/gomega"
 	"go.uber.org/mock/gomock"
 
+	"github.com/anyvoxel/vela/pkg/agents"
 	mock_agents "github.com/anyvoxel/vela/pkg/agents/mocks"
 	"github.com/anyvoxel/vela/pkg/apitypes"
 	"github.com/anyvoxel/vela/pkg/collectors"
 	"github.com/anyvoxel/vela/pkg/collectors/framework"
 	mock_collectors "github.com/anyvoxel/vela/pkg/collectors/mocks"
+	"github.com/anyvoxel/vela/pkg/notifiers"
+	mock_notifiers "github.com/anyvoxel/vela/pkg/notifiers/mocks"
+	"github.com/anyvoxel/vela/pkg/storage"
 	mock_storage "github.com/anyvoxel/vela/pkg/storage/mocks"
 )
 
@@ -34,10 +40,12 @@ func TestApplication_Start_ChannelClosing(t *testing.T) {
 	s := mock_storage.NewMockStorage(mockCtrl)
 	s.EXPECT().SummaryExists(gomock.Any(), "/post1").Return(false)
 	s.EXPECT().Put(gomock.Any(), gomock.Any()).Return(nil)
+	s.EXPECT().ResummarizeQueue(gomock.Any()).Return(nil, nil)
+	s.EXPECT().PutRun(gomock.Any(), gomock.Any()).Return(nil)
 
 	// Create a summarizer and mock the summary function
 	summarizer := mock_agents.NewMockSummarizer(mockCtrl)
-	summarizer.EXPECT().Summary(gomock.Any(), gomock.Any()).Return("summary", nil)
+	summarizer.EXPECT().Summary(gomock.Any(), gomock.Any()).Return(&agents.SummaryOutput{Summary: "summary"}, nil)
 
 	app := &Application{
 		f:            f,
@@ -59,3 +67,296 @@ func TestApplication_Start_ChannelClosing(t *testing.T) {
 		t.Fatal("Test timed out. The channel was not closed.")
 	}
 }
+
+func TestApplication_Start_Notify(t *testing.T) {
+	g := gomega.NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCollector := mock_collectors.NewMockCollector(mockCtrl)
+	mockCollector.EXPECT().Name().Return("test-collector").AnyTimes()
+	mockCollector.EXPECT().Initialize(gomock.Any()).Return(nil).AnyTimes()
+	mockCollector.EXPECT().Start(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, ch chan<- apitypes.Post) error {
+			ch <- apitypes.Post{Title: "post1", Path: "/post1"}
+			return nil
+		}).AnyTimes()
+	f := framework.NewFramework([]collectors.Collector{mockCollector})
+
+	s := mock_storage.NewMockStorage(mockCtrl)
+	s.EXPECT().SummaryExists(gomock.Any(), "/post1").Return(false)
+	s.EXPECT().Put(gomock.Any(), gomock.Any()).Return(nil)
+	s.EXPECT().ResummarizeQueue(gomock.Any()).Return(nil, nil)
+	s.EXPECT().PutRun(gomock.Any(), gomock.Any()).Return(nil)
+
+	summarizer := mock_agents.NewMockSummarizer(mockCtrl)
+	summarizer.EXPECT().Summary(gomock.Any(), gomock.Any()).Return(&agents.SummaryOutput{Summary: "summary"}, nil)
+
+	// A failed notifier should not block the others
+	n1 := mock_notifiers.NewMockNotifier(mockCtrl)
+	n1.EXPECT().Name().Return("n1").AnyTimes()
+	n1.EXPECT().Notify(gomock.Any(), gomock.Any()).Return(errors.New("unavailable"))
+	n2 := mock_notifiers.NewMockNotifier(mockCtrl)
+	n2.EXPECT().Notify(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, results []*storage.SummaryResult) error {
+			g.Expect(results).To(gomega.HaveLen(1))
+			g.Expect(results[0].Summary).To(gomega.Equal("summary"))
+			return nil
+		})
+
+	app := &Application{
+		f:            f,
+		store:        s,
+		summaryAgent: summarizer,
+		notifiers:    []notifiers.Notifier{n1, n2},
+	}
+
+	err := app.Start(context.Background())
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+}
+
+func TestApplication_Start_SkipLowRelevance(t *testing.T) {
+	g := gomega.NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCollector := mock_collectors.NewMockCollector(mockCtrl)
+	mockCollector.EXPECT().Name().Return("test-collector").AnyTimes()
+	mockCollector.EXPECT().Initialize(gomock.Any()).Return(nil).AnyTimes()
+	mockCollector.EXPECT().Start(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, ch chan<- apitypes.Post) error {
+			ch <- apitypes.Post{Title: "post1", Path: "/post1"}
+			ch <- apitypes.Post{Title: "post2", Path: "/post2"}
+			ch <- apitypes.Post{Title: "post3", Path: "/post3"}
+			return nil
+		}).AnyTimes()
+	f := framework.NewFramework([]collectors.Collector{mockCollector})
+
+	var persisted []*storage.SummaryResult
+	s := mock_storage.NewMockStorage(mockCtrl)
+	s.EXPECT().SummaryExists(gomock.Any(), gomock.Any()).Return(false).Times(3)
+	s.EXPECT().Put(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, results []*storage.SummaryResult) error {
+			persisted = results
+			return nil
+		})
+	s.EXPECT().ResummarizeQueue(gomock.Any()).Return(nil, nil)
+	var run *storage.Run
+	s.EXPECT().PutRun(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, r *storage.Run) error {
+			run = r
+			return nil
+		})
+
+	scorer := mock_agents.NewMockScorer(mockCtrl)
+	scorer.EXPECT().Score(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, post apitypes.Post) (*apitypes.Relevance, error) {
+			switch post.Path {
+			case "/post1":
+				return &apitypes.Relevance{Score: 10}, nil
+			case "/post2":
+				return &apitypes.Relevance{Score: 60}, nil
+			default:
+				return &apitypes.Relevance{Score: 90}, nil
+			}
+		}).Times(3)
+
+	// Only the relevant posts will be summarized
+	summarizer := mock_agents.NewMockSummarizer(mockCtrl)
+	summarizer.EXPECT().Summary(gomock.Any(), gomock.Any()).
+		Return(&agents.SummaryOutput{Summary: "summary"}, nil).Times(2)
+
+	n := mock_notifiers.NewMockNotifier(mockCtrl)
+	n.EXPECT().Notify(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, results []*storage.SummaryResult) error {
+			g.Expect(results).To(gomega.HaveLen(2))
+			g.Expect(results[0].Path).To(gomega.Equal("/post3"))
+			g.Expect(results[1].Path).To(gomega.Equal("/post2"))
+			return nil
+		})
+
+	app := &Application{
+		f:            f,
+		store:        s,
+		summaryAgent: summarizer,
+		scorer:       scorer,
+		notifiers:    []notifiers.Notifier{n},
+		threshold:    50,
+	}
+
+	err := app.Start(context.Background())
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(persisted).To(gomega.HaveLen(3))
+	g.Expect(persisted[0].Status).To(gomega.Equal(storage.StatusSkippedLowRelevance))
+	g.Expect(persisted[0].Summary).To(gomega.BeEmpty())
+	g.Expect(persisted[1].Status).To(gomega.BeEmpty())
+	g.Expect(persisted[1].Relevance.Score).To(gomega.Equal(60))
+	g.Expect(run.Collected).To(gomega.Equal(3))
+	g.Expect(run.Summarized).To(gomega.Equal(2))
+	g.Expect(run.Skipped).To(gomega.Equal(1))
+	g.Expect(run.FinishedAt).ToNot(gomega.BeZero())
+}
+
+func TestApplication_Start_Resummarize(t *testing.T) {
+	g := gomega.NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCollector := mock_collectors.NewMockCollector(mockCtrl)
+	mockCollector.EXPECT().Name().Return("test-collector").AnyTimes()
+	mockCollector.EXPECT().Initialize(gomock.Any()).Return(nil).AnyTimes()
+	mockCollector.EXPECT().Start(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	f := framework.NewFramework([]collectors.Collector{mockCollector})
+
+	previous := &storage.SummaryResult{
+		Domain:    "charap",
+		Path:      "https://charap.co/3",
+		Title:     "Paxos",
+		Summary:   "old",
+		Relevance: &apitypes.Relevance{Score: 10},
+		Status:    storage.StatusSkippedLowRelevance,
+	}
+	s := mock_storage.NewMockStorage(mockCtrl)
+	s.EXPECT().Put(gomock.Any(), gomock.Any()).Return(nil)
+	s.EXPECT().ResummarizeQueue(gomock.Any()).Return([]string{previous.Path, "https://charap.co/unknown"}, nil)
+	s.EXPECT().Get(gomock.Any(), storage.PostID(previous.Path)).Return(previous, nil)
+	s.EXPECT().Get(gomock.Any(), storage.PostID("https://charap.co/unknown")).Return(nil, storage.ErrNotFound)
+	s.EXPECT().Update(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, results []*storage.SummaryResult) error {
+			g.Expect(results).To(gomega.HaveLen(1))
+			g.Expect(results[0].Summary).To(gomega.Equal("new"))
+			g.Expect(results[0].Status).To(gomega.BeEmpty())
+			g.Expect(results[0].Relevance).To(gomega.Equal(previous.Relevance))
+			return nil
+		})
+	s.EXPECT().DequeueResummarize(gomock.Any(), previous.Path).Return(nil)
+	s.EXPECT().DequeueResummarize(gomock.Any(), "https://charap.co/unknown").Return(nil)
+	s.EXPECT().PutRun(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, run *storage.Run) error {
+			g.Expect(run.Resummarized).To(gomega.Equal(1))
+			return nil
+		})
+
+	summarizer := mock_agents.NewMockSummarizer(mockCtrl)
+	summarizer.EXPECT().Summary(gomock.Any(), apitypes.Post{Domain: "charap", Path: previous.Path, Title: "Paxos"}).
+		Return(&agents.SummaryOutput{Summary: "new"}, nil)
+
+	app := &Application{
+		f:            f,
+		store:        s,
+		summaryAgent: summarizer,
+	}
+	g.Expect(app.Start(context.Background())).To(gomega.Succeed())
+}
+
+func TestApplication_Start_UnsupportedMedia(t *testing.T) {
+	g := gomega.NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCollector := mock_collectors.NewMockCollector(mockCtrl)
+	mockCollector.EXPECT().Name().Return("test-collector").AnyTimes()
+	mockCollector.EXPECT().Initialize(gomock.Any()).Return(nil).AnyTimes()
+	mockCollector.EXPECT().Start(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, ch chan<- apitypes.Post) error {
+			ch <- apitypes.Post{Title: "talk", Path: "/talk"}
+			return nil
+		}).AnyTimes()
+	f := framework.NewFramework([]collectors.Collector{mockCollector})
+
+	previous := &storage.SummaryResult{
+		Domain: "test-collector", Path: "/episode", Title: "episode", Summary: "page chrome",
+	}
+	s := mock_storage.NewMockStorage(mockCtrl)
+	s.EXPECT().SummaryExists(gomock.Any(), "/talk").Return(false)
+	s.EXPECT().Put(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, results []*storage.SummaryResult) error {
+			g.Expect(results).To(gomega.HaveLen(1))
+			g.Expect(results[0].Status).To(gomega.Equal(storage.StatusUnsupportedMedia))
+			g.Expect(results[0].Summary).To(gomega.BeEmpty())
+			return nil
+		})
+	s.EXPECT().ResummarizeQueue(gomock.Any()).Return([]string{previous.Path}, nil)
+	s.EXPECT().Get(gomock.Any(), storage.PostID(previous.Path)).Return(previous, nil)
+	// The meaningless summary is replaced by the status
+	s.EXPECT().Update(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, results []*storage.SummaryResult) error {
+			g.Expect(results).To(gomega.HaveLen(1))
+			g.Expect(results[0].Status).To(gomega.Equal(storage.StatusUnsupportedMedia))
+			g.Expect(results[0].Summary).To(gomega.BeEmpty())
+			return nil
+		})
+	s.EXPECT().DequeueResummarize(gomock.Any(), previous.Path).Return(nil)
+	s.EXPECT().PutRun(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, run *storage.Run) error {
+			g.Expect(run.Skipped).To(gomega.Equal(1))
+			g.Expect(run.Failed).To(gomega.Equal(0))
+			return nil
+		})
+
+	summarizer := mock_agents.NewMockSummarizer(mockCtrl)
+	summarizer.EXPECT().Summary(gomock.Any(), gomock.Any()).
+		Return(nil, fmt.Errorf("%w: video", agents.ErrUnsupportedMedia)).Times(2)
+
+	app := &Application{
+		f:            f,
+		store:        s,
+		summaryAgent: summarizer,
+	}
+	g.Expect(app.Start(context.Background())).To(gomega.Succeed())
+}
+
+func TestApplication_Start_ManualSubmission(t *testing.T) {
+	g := gomega.NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	publishedAt := time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC)
+	mockCollector := mock_collectors.NewMockCollector(mockCtrl)
+	mockCollector.EXPECT().Name().Return("manual").AnyTimes()
+	mockCollector.EXPECT().Initialize(gomock.Any()).Return(nil).AnyTimes()
+	mockCollector.EXPECT().Start(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, ch chan<- apitypes.Post) error {
+			ch <- apitypes.Post{Domain: "example.com", Path: "https://example.com/a", Source: apitypes.SourceManual}
+			return nil
+		}).AnyTimes()
+	f := framework.NewFramework([]collectors.Collector{mockCollector})
+
+	s := mock_storage.NewMockStorage(mockCtrl)
+	s.EXPECT().SummaryExists(gomock.Any(), "https://example.com/a").Return(false)
+	s.EXPECT().Put(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, results []*storage.SummaryResult) error {
+			g.Expect(results).To(gomega.HaveLen(1))
+			g.Expect(results[0].Domain).To(gomega.Equal("example.com"))
+			g.Expect(results[0].Source).To(gomega.Equal(apitypes.SourceManual))
+			g.Expect(results[0].Title).To(gomega.Equal("Extracted"))
+			g.Expect(results[0].PublishedAt).To(gomega.Equal(publishedAt))
+			g.Expect(results[0].Model).To(gomega.Equal("openai/gpt-4o"))
+			g.Expect(results[0].Status).To(gomega.BeEmpty())
+			return nil
+		})
+	s.EXPECT().DequeueSubmission(gomock.Any(), "https://example.com/a").Return(nil)
+	s.EXPECT().ResummarizeQueue(gomock.Any()).Return(nil, nil)
+	s.EXPECT().PutRun(gomock.Any(), gomock.Any()).Return(nil)
+
+	// The submitted post is summarized regardless of the threshold
+	scorer := mock_agents.NewMockScorer(mockCtrl)
+	scorer.EXPECT().Score(gomock.Any(), gomock.Any()).Return(&apitypes.Relevance{Score: 1}, nil)
+	summarizer := mock_agents.NewMockSummarizer(mockCtrl)
+	summarizer.EXPECT().Summary(gomock.Any(), gomock.Any()).
+		Return(&agents.SummaryOutput{
+			Summary:     "summary",
+			Title:       "Extracted",
+			PublishedAt: publishedAt,
+			Model:       "openai/gpt-4o",
+		}, nil)
+
+	app := &Application{
+		f:            f,
+		store:        s,
+		summaryAgent: summarizer,
+		scorer:       scorer,
+		threshold:    50,
+	}
+	g.Expect(app.Start(context.Background())).To(gomega.Succeed())
+}
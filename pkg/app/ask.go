@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/anyvoxel/airmid/anvil"
+	airapp "github.com/anyvoxel/airmid/app"
+	"github.com/anyvoxel/airmid/ioc"
+
+	"github.com/anyvoxel/vela/pkg/agents"
+	"github.com/anyvoxel/vela/pkg/search"
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+func init() {
+	anvil.Must(airapp.RegisterBeanDefinition(
+		"vela.app.askCommand",
+		ioc.MustNewBeanDefinition(
+			reflect.TypeFor[*askCommand](),
+		),
+	))
+}
+
+// nothingRelevant is printed when the corpus can't answer the question.
+const nothingRelevant = "Nothing relevant was found in the stored summaries."
+
+var errEmptyQuestion = errors.New("vela.ask.question is empty")
+
+// askCommand will retrieve the summaries which are similar to
+// vela.ask.question, and answer it with citations.
+type askCommand struct {
+	searcher search.Searcher `airmid:"autowire:vela.search.searcher"`
+	answerer agents.Answerer `airmid:"autowire:vela.agents.answerer"`
+
+	question string  `airmid:"value:${vela.ask.question:=}"`
+	topK     int     `airmid:"value:${vela.ask.top_k:=5}"`
+	minScore float64 `airmid:"value:${vela.ask.min_score:=0}"`
+}
+
+var _ command = (*askCommand)(nil)
+
+// Name implement command.Name
+func (*askCommand) Name() string { return "ask" }
+
+// Execute implement command.Execute
+func (c *askCommand) Execute(ctx context.Context) error {
+	return c.run(ctx, os.Stdout)
+}
+
+func (c *askCommand) run(ctx context.Context, w io.Writer) error {
+	question := strings.TrimSpace(c.question)
+	if question == "" {
+		return errEmptyQuestion
+	}
+
+	_, err := c.searcher.Index(ctx)
+	if err != nil {
+		return err
+	}
+
+	hits, err := c.searcher.Search(ctx, question, max(c.topK, 1))
+	if err != nil {
+		return err
+	}
+	posts := make([]*storage.SummaryResult, 0, len(hits))
+	for _, hit := range hits {
+		if hit.Score < c.minScore {
+			continue
+		}
+		posts = append(posts, hit.Result)
+	}
+
+	answer, err := c.answerer.Answer(ctx, question, posts)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if !answer.Found {
+		fmt.Fprintf(&b, "%s\n", nothingRelevant)
+	} else {
+		fmt.Fprintf(&b, "%s\n\nSources:\n", answer.Text)
+		// The answer cites the sources by their position in posts
+		for i, post := range posts {
+			if slices.Contains(answer.Citations, post.Path) {
+				fmt.Fprintf(&b, "[%d] %s\n    %s\n", i+1, post.Title, post.Path)
+			}
+		}
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
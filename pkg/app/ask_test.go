@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+
+	"github.com/anyvoxel/vela/pkg/agents"
+	mock_agents "github.com/anyvoxel/vela/pkg/agents/mocks"
+	"github.com/anyvoxel/vela/pkg/search"
+	mock_search "github.com/anyvoxel/vela/pkg/search/mocks"
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+func TestAskCommand_Execute(t *testing.T) {
+	g := gomega.NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	raft := &storage.SummaryResult{Title: "Raft", Path: "https://brooker.co.za/blog/1"}
+	paxos := &storage.SummaryResult{Title: "Paxos", Path: "https://charap.co/2"}
+	llm := &storage.SummaryResult{Title: "LLM", Path: "https://simonwillison.net/3"}
+
+	searcher := mock_search.NewMockSearcher(mockCtrl)
+	searcher.EXPECT().Index(gomock.Any()).Return(0, nil).Times(2)
+	searcher.EXPECT().Search(gomock.Any(), "how to snapshot raft?", 3).Return([]*search.Hit{
+		{Score: 0.9, Result: raft},
+		{Score: 0.6, Result: paxos},
+		{Score: 0.1, Result: llm},
+	}, nil).Times(2)
+
+	// The hits below min score are not sources
+	answerer := mock_agents.NewMockAnswerer(mockCtrl)
+	answerer.EXPECT().Answer(gomock.Any(), "how to snapshot raft?", []*storage.SummaryResult{raft, paxos}).
+		Return(&agents.Answer{Found: true, Text: "Snapshot the state machine [2].", Citations: []string{paxos.Path}}, nil)
+	answerer.EXPECT().Answer(gomock.Any(), "how to snapshot raft?", gomock.Any()).Return(&agents.Answer{}, nil)
+
+	c := &askCommand{
+		searcher: searcher,
+		answerer: answerer,
+		question: " how to snapshot raft? ",
+		topK:     3,
+		minScore: 0.5,
+	}
+	var b strings.Builder
+	g.Expect(c.run(context.Background(), &b)).To(gomega.Succeed())
+	g.Expect(b.String()).To(gomega.Equal(
+		"Snapshot the state machine [2].\n\nSources:\n[2] Paxos\n    https://charap.co/2\n"))
+
+	b.Reset()
+	g.Expect(c.run(context.Background(), &b)).To(gomega.Succeed())
+	g.Expect(b.String()).To(gomega.Equal(nothingRelevant + "\n"))
+
+	c.question = ""
+	g.Expect(c.run(context.Background(), &b)).To(gomega.MatchError(errEmptyQuestion))
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// commandCollect is the default command, it will collect and summarize posts.
+const commandCollect = "collect"
+
+var errUnknownCommand = errors.New("unknown command")
+
+// command is an alternative entry of vela, it's selected by vela.command.
+type command interface {
+	// Name return unique command name
+	Name() string
+
+	// Execute will run the command until it's done
+	Execute(ctx context.Context) error
+}
+
+// execute will run the command selected by vela.command.
+func (a *Application) execute(ctx context.Context) error {
+	if a.command == "" || a.command == commandCollect {
+		return a.Start(ctx)
+	}
+
+	for _, c := range a.commands {
+		if c.Name() == a.command {
+			return c.Execute(ctx)
+		}
+	}
+	return fmt.Errorf("%w: %s", errUnknownCommand, a.command)
+}
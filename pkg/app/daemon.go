@@ -0,0 +1,71 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/anyvoxel/airmid/anvil"
+	airapp "github.com/anyvoxel/airmid/app"
+	"github.com/anyvoxel/airmid/ioc"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+func init() {
+	anvil.Must(airapp.RegisterBeanDefinition(
+		"vela.app.daemonCommand",
+		ioc.MustNewBeanDefinition(
+			reflect.TypeFor[*daemonCommand](),
+		),
+	))
+}
+
+var errDaemonIntervalInvalid = errors.New("vela.daemon.interval must be positive")
+
+// daemonCommand will collect and summarize posts periodically, the sources
+// file is reloaded when it's changed.
+type daemonCommand struct {
+	// app is the application which runs the collect, the circular autowire
+	// is resolved by the singleton in creating
+	app *Application `airmid:"autowire:vela.application"`
+
+	// interval is the interval of collect
+	interval time.Duration `airmid:"value:${vela.daemon.interval:=1h}"`
+}
+
+var _ command = (*daemonCommand)(nil)
+
+// Name implement command.Name
+func (*daemonCommand) Name() string { return "daemon" }
+
+// Execute implement command.Execute, it will run the collect every
+// vela.daemon.interval until the context is done, a failed run is logged and
+// retried in the next interval.
+func (c *daemonCommand) Execute(ctx context.Context) error {
+	if c.interval <= 0 {
+		return fmt.Errorf("%w: %s", errDaemonIntervalInvalid, c.interval)
+	}
+	if c.app.f != nil {
+		go c.app.f.Watch(ctx)
+	}
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		err := c.app.Start(ctx)
+		if err != nil {
+			slogctx.FromCtx(ctx).ErrorContext(ctx, "collect failed",
+				slog.Any("Error", err),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+	"github.com/anyvoxel/vela/pkg/collectors"
+	"github.com/anyvoxel/vela/pkg/collectors/framework"
+	mock_collectors "github.com/anyvoxel/vela/pkg/collectors/mocks"
+	mock_storage "github.com/anyvoxel/vela/pkg/storage/mocks"
+)
+
+func TestApplication_Daemon(t *testing.T) {
+	g := gomega.NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var runs atomic.Int32
+	mockCollector := mock_collectors.NewMockCollector(mockCtrl)
+	mockCollector.EXPECT().Name().Return("test-collector").AnyTimes()
+	mockCollector.EXPECT().Initialize(gomock.Any()).Return(nil).AnyTimes()
+	mockCollector.EXPECT().Start(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ chan<- apitypes.Post) error {
+			runs.Add(1)
+			return nil
+		}).AnyTimes()
+
+	s := mock_storage.NewMockStorage(mockCtrl)
+	s.EXPECT().Put(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	s.EXPECT().ResummarizeQueue(gomock.Any()).Return(nil, nil).AnyTimes()
+	s.EXPECT().PutRun(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	app := &Application{
+		f:       framework.NewFramework([]collectors.Collector{mockCollector}),
+		store:   s,
+		command: "daemon",
+	}
+	daemon := &daemonCommand{app: app, interval: 10 * time.Millisecond}
+	app.commands = []command{daemon}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- app.execute(ctx)
+	}()
+	g.Eventually(runs.Load).Should(gomega.BeNumerically(">=", 2))
+	cancel()
+	g.Eventually(done).Should(gomega.Receive(gomega.BeNil()))
+
+	daemon.interval = 0
+	g.Expect(app.execute(context.Background())).To(gomega.MatchError(errDaemonIntervalInvalid))
+}
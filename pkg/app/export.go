@@ -0,0 +1,148 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/anyvoxel/airmid/anvil"
+	airapp "github.com/anyvoxel/airmid/app"
+	"github.com/anyvoxel/airmid/ioc"
+
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+func init() {
+	anvil.Must(airapp.RegisterBeanDefinition(
+		"vela.app.exportCommand",
+		ioc.MustNewBeanDefinition(
+			reflect.TypeFor[*exportCommand](),
+		),
+	))
+}
+
+const (
+	exportFormatJSONL    = "jsonl"
+	exportFormatMarkdown = "markdown"
+)
+
+var errUnknownExportFormat = errors.New("unknown export format")
+
+// exportCommand will write the persisted results which match the query to
+// vela.export.output, or stdout if it's empty.
+type exportCommand struct {
+	store storage.Storage `airmid:"autowire:vela.storage.storage"`
+
+	domain   string `airmid:"value:${vela.export.domain:=}"`
+	tag      string `airmid:"value:${vela.export.tag:=}"`
+	keyword  string `airmid:"value:${vela.export.keyword:=}"`
+	since    string `airmid:"value:${vela.export.since:=}"`
+	until    string `airmid:"value:${vela.export.until:=}"`
+	format   string `airmid:"value:${vela.export.format:=jsonl}"`
+	language string `airmid:"value:${vela.export.language:=}"`
+	output   string `airmid:"value:${vela.export.output:=}"`
+}
+
+var _ command = (*exportCommand)(nil)
+
+// Name implement command.Name
+func (*exportCommand) Name() string { return "export" }
+
+// Execute implement command.Execute
+func (c *exportCommand) Execute(ctx context.Context) error {
+	q, err := c.query()
+	if err != nil {
+		return err
+	}
+
+	results, err := c.store.Query(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	if c.output == "" {
+		return c.write(os.Stdout, results)
+	}
+
+	f, err := os.Create(c.output)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint
+
+	return c.write(f, results)
+}
+
+func (c *exportCommand) query() (*storage.Query, error) {
+	since, err := storage.ParseQueryTime(c.since)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vela.export.since %q: %w", c.since, err)
+	}
+	until, err := storage.ParseQueryTime(c.until)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vela.export.until %q: %w", c.until, err)
+	}
+
+	return &storage.Query{
+		Domain:  strings.TrimSpace(c.domain),
+		Tag:     strings.TrimSpace(c.tag),
+		Keyword: strings.TrimSpace(c.keyword),
+		Since:   since,
+		Until:   until,
+	}, nil
+}
+
+func (c *exportCommand) write(w io.Writer, results []*storage.SummaryResult) error {
+	switch c.format {
+	case exportFormatJSONL:
+		encoder := json.NewEncoder(w)
+		for _, result := range results {
+			err := encoder.Encode(result)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case exportFormatMarkdown:
+		return writeMarkdown(w, results, c.language)
+	default:
+		return fmt.Errorf("%w: %s", errUnknownExportFormat, c.format)
+	}
+}
+
+func writeMarkdown(w io.Writer, results []*storage.SummaryResult, language string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# vela export\n\n%d posts\n", len(results))
+	for _, result := range results {
+		fmt.Fprintf(&b, "\n## [%s](%s)\n\n", result.Title, result.Path)
+		fmt.Fprintf(&b, "- Domain: %s\n", result.Domain)
+		if !result.PublishedAt.IsZero() {
+			fmt.Fprintf(&b, "- Published: %s\n", result.PublishedAt.Format("2006-01-02"))
+		}
+		if len(result.Tags) > 0 {
+			fmt.Fprintf(&b, "- Tags: %s\n", strings.Join(result.Tags, ", "))
+		}
+		if result.Relevance != nil {
+			fmt.Fprintf(&b, "- Relevance: %d %s\n", result.Relevance.Score, result.Relevance.Profile)
+		}
+		if len(result.Keywords) > 0 {
+			fmt.Fprintf(&b, "- Keywords: %s\n", strings.Join(result.Keywords, ", "))
+		}
+
+		summary := result.Summary
+		if language != "" {
+			summary = result.SummaryIn(language)
+		}
+		if summary != "" {
+			fmt.Fprintf(&b, "\n%s\n", summary)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
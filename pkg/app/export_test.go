@@ -0,0 +1,76 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+	"github.com/anyvoxel/vela/pkg/storage"
+	mock_storage "github.com/anyvoxel/vela/pkg/storage/mocks"
+)
+
+func TestExportCommand_Execute(t *testing.T) {
+	g := gomega.NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	s := mock_storage.NewMockStorage(mockCtrl)
+	s.EXPECT().Query(gomock.Any(), &storage.Query{
+		Tag:   "consensus",
+		Since: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+	}).Return([]*storage.SummaryResult{
+		{
+			Domain:        "brooker",
+			Path:          "https://brooker.co.za/blog/1",
+			Title:         "Raft",
+			Summary:       "摘要",
+			Summaries:     map[string]string{"zh-CN": "摘要", "en": "summary"},
+			SummaryDetail: apitypes.SummaryDetail{Tags: []string{"consensus"}},
+			PublishedAt:   time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC),
+		},
+	}, nil).Times(2)
+
+	output := filepath.Join(t.TempDir(), "export")
+	c := &exportCommand{
+		store:    s,
+		tag:      "consensus",
+		since:    "2026-10-01",
+		format:   exportFormatMarkdown,
+		language: "en",
+		output:   output,
+	}
+	g.Expect(c.Execute(context.Background())).To(gomega.Succeed())
+	data, err := os.ReadFile(output)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(string(data)).To(gomega.ContainSubstring("## [Raft](https://brooker.co.za/blog/1)"))
+	g.Expect(string(data)).To(gomega.ContainSubstring("- Tags: consensus\n\nsummary\n"))
+
+	c.format = exportFormatJSONL
+	g.Expect(c.Execute(context.Background())).To(gomega.Succeed())
+	data, err = os.ReadFile(output)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	var result storage.SummaryResult
+	g.Expect(json.Unmarshal(data, &result)).To(gomega.Succeed())
+	g.Expect(result.Path).To(gomega.Equal("https://brooker.co.za/blog/1"))
+}
+
+func TestExportCommand_InvalidQuery(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	c := &exportCommand{since: "last month"}
+	g.Expect(c.Execute(context.Background())).To(gomega.HaveOccurred())
+}
+
+func TestApplication_UnknownCommand(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	app := &Application{command: "unknown", commands: []command{&exportCommand{}}}
+	g.Expect(app.execute(context.Background())).To(gomega.MatchError(errUnknownCommand))
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/anyvoxel/airmid/anvil"
+	airapp "github.com/anyvoxel/airmid/app"
+	"github.com/anyvoxel/airmid/ioc"
+
+	"github.com/anyvoxel/vela/pkg/search"
+)
+
+func init() {
+	anvil.Must(airapp.RegisterBeanDefinition(
+		"vela.app.searchCommand",
+		ioc.MustNewBeanDefinition(
+			reflect.TypeFor[*searchCommand](),
+		),
+	))
+}
+
+// searchExcerptRunes is the max runes of summary printed for each hit.
+const searchExcerptRunes = 200
+
+// searchCommand will index the new results, and print the top k results
+// which are most similar to vela.search.query.
+type searchCommand struct {
+	searcher search.Searcher `airmid:"autowire:vela.search.searcher"`
+
+	query    string `airmid:"value:${vela.search.query:=}"`
+	topK     int    `airmid:"value:${vela.search.top_k:=10}"`
+	language string `airmid:"value:${vela.search.language:=}"`
+}
+
+var _ command = (*searchCommand)(nil)
+
+// Name implement command.Name
+func (*searchCommand) Name() string { return "search" }
+
+// Execute implement command.Execute
+func (c *searchCommand) Execute(ctx context.Context) error {
+	return c.run(ctx, os.Stdout)
+}
+
+func (c *searchCommand) run(ctx context.Context, w io.Writer) error {
+	_, err := c.searcher.Index(ctx)
+	if err != nil {
+		return err
+	}
+
+	hits, err := c.searcher.Search(ctx, c.query, max(c.topK, 1))
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for i, hit := range hits {
+		result := hit.Result
+		fmt.Fprintf(&b, "%d. [%.3f] %s\n   %s\n", i+1, hit.Score, result.Title, result.Path)
+
+		summary := result.Summary
+		if c.language != "" {
+			summary = result.SummaryIn(c.language)
+		}
+		if summary != "" {
+			fmt.Fprintf(&b, "   %s\n", excerpt(summary, searchExcerptRunes))
+		}
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// excerpt return the first line of s truncated to n runes.
+func excerpt(s string, n int) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
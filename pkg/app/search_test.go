@@ -0,0 +1,39 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+
+	"github.com/anyvoxel/vela/pkg/search"
+	mock_search "github.com/anyvoxel/vela/pkg/search/mocks"
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+func TestSearchCommand_Execute(t *testing.T) {
+	g := gomega.NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	searcher := mock_search.NewMockSearcher(mockCtrl)
+	searcher.EXPECT().Index(gomock.Any()).Return(1, nil)
+	searcher.EXPECT().Search(gomock.Any(), "raft snapshotting", 3).Return([]*search.Hit{
+		{
+			Score: 0.87,
+			Result: &storage.SummaryResult{
+				Title:     "Raft",
+				Path:      "https://brooker.co.za/blog/1",
+				Summary:   "摘要",
+				Summaries: map[string]string{"zh-CN": "摘要", "en": "snapshot\nsecond line"},
+			},
+		},
+	}, nil)
+
+	c := &searchCommand{searcher: searcher, query: "raft snapshotting", topK: 3, language: "en"}
+	var b strings.Builder
+	g.Expect(c.run(context.Background(), &b)).To(gomega.Succeed())
+	g.Expect(b.String()).To(gomega.Equal("1. [0.870] Raft\n   https://brooker.co.za/blog/1\n   snapshot\n"))
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/anyvoxel/airmid/anvil"
+	airapp "github.com/anyvoxel/airmid/app"
+	"github.com/anyvoxel/airmid/ioc"
+	slogctx "github.com/veqryn/slog-context"
+
+	"github.com/anyvoxel/vela/pkg/search"
+	"github.com/anyvoxel/vela/pkg/server"
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+func init() {
+	anvil.Must(airapp.RegisterBeanDefinition(
+		"vela.app.serveCommand",
+		ioc.MustNewBeanDefinition(
+			reflect.TypeFor[*serveCommand](),
+		),
+	))
+}
+
+// shutdownTimeout is the max duration to wait for the inflight requests.
+const shutdownTimeout = 10 * time.Second
+
+// serveCommand will serve the http read api on vela.server.addr until the
+// context is done.
+type serveCommand struct {
+	store    storage.Storage `airmid:"autowire:vela.storage.storage"`
+	searcher search.Searcher `airmid:"autowire:vela.search.searcher"`
+
+	addr string `airmid:"value:${vela.server.addr:=:8080}"`
+}
+
+var _ command = (*serveCommand)(nil)
+
+// Name implement command.Name
+func (*serveCommand) Name() string { return "serve" }
+
+// Execute implement command.Execute
+func (c *serveCommand) Execute(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              c.addr,
+		Handler:           server.NewHandler(c.store, c.searcher),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		slogctx.FromCtx(ctx).InfoContext(ctx, "serve http api", slog.String("Addr", c.addr))
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	sctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+	err := srv.Shutdown(sctx)
+	if err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
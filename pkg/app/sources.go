@@ -0,0 +1,130 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/anyvoxel/airmid/anvil"
+	airapp "github.com/anyvoxel/airmid/app"
+	"github.com/anyvoxel/airmid/ioc"
+
+	"github.com/anyvoxel/vela/pkg/collectors"
+	"github.com/anyvoxel/vela/pkg/collectors/framework"
+	"github.com/anyvoxel/vela/pkg/fetch"
+)
+
+func init() {
+	anvil.Must(airapp.RegisterBeanDefinition(
+		"vela.app.sourcesAddCommand",
+		ioc.MustNewBeanDefinition(
+			reflect.TypeFor[*sourcesAddCommand](),
+		),
+	))
+}
+
+// maxShownPosts is the max number of posts shown for the proposed source.
+const maxShownPosts = 10
+
+var (
+	errNoHomepage       = errors.New("vela.sources.add.url is empty")
+	errNoSourceProposed = errors.New("no feed or list page with posts is found")
+)
+
+// sourcesAddCommand will discover the feed or list page of
+// vela.sources.add.url, and add it to the sources file.
+type sourcesAddCommand struct {
+	listParser collectors.ListParser `airmid:"autowire:?"`
+	policy     *fetch.Policy         `airmid:"autowire:vela.fetch.policy"`
+
+	sourcesFile string `airmid:"value:${vela.collectors.sources_file:=./collectors.json}"`
+	homepage    string `airmid:"value:${vela.sources.add.url:=}"`
+	// name overrides the name proposed from the host
+	name   string `airmid:"value:${vela.sources.add.name:=}"`
+	dryRun bool   `airmid:"value:${vela.sources.add.dry_run:=false}"`
+}
+
+var _ command = (*sourcesAddCommand)(nil)
+
+// Name implement command.Name
+func (*sourcesAddCommand) Name() string { return "sources-add" }
+
+// Execute implement command.Execute
+func (c *sourcesAddCommand) Execute(ctx context.Context) error {
+	return c.run(ctx, os.Stdout)
+}
+
+func (c *sourcesAddCommand) run(ctx context.Context, w io.Writer) error {
+	if strings.TrimSpace(c.homepage) == "" {
+		return errNoHomepage
+	}
+
+	discovery, err := framework.Discover(ctx, c.policy.Client(""), c.homepage, c.listParser)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, candidate := range discovery.Candidates {
+		if candidate.Err != nil {
+			fmt.Fprintf(&b, "%s %s: %v\n", parserType(candidate.Source), candidate.Source.URL, candidate.Err)
+			continue
+		}
+		fmt.Fprintf(&b, "%s %s: %d posts\n", parserType(candidate.Source), candidate.Source.URL, len(candidate.Posts))
+	}
+	for _, sitemap := range discovery.Sitemaps {
+		fmt.Fprintf(&b, "sitemap %s: add it with type sitemap to collect the archive\n", sitemap)
+	}
+
+	proposed := discovery.Proposed()
+	if proposed == nil {
+		_, _ = io.WriteString(w, b.String())
+		return fmt.Errorf("%w: %s", errNoSourceProposed, c.homepage)
+	}
+	src := proposed.Source
+	if name := strings.TrimSpace(c.name); name != "" {
+		src.Name = name
+	}
+	fmt.Fprintf(&b, "\nposts of %s:\n", src.URL)
+	for i, post := range proposed.Posts {
+		if i >= maxShownPosts {
+			fmt.Fprintf(&b, "  ... %d more\n", len(proposed.Posts)-maxShownPosts)
+			break
+		}
+		date := "          "
+		if !post.PublishedAt.IsZero() {
+			date = post.PublishedAt.Format("2006-01-02")
+		}
+		fmt.Fprintf(&b, "  %s %s %s\n", date, post.Title, post.Path)
+	}
+
+	if c.dryRun {
+		fmt.Fprintf(&b, "\nproposed %q (%s) %s\n", src.Name, parserType(src), src.URL)
+	} else {
+		err = framework.AddSource(c.sourcesFile, src, c.listParser)
+		if err != nil {
+			_, _ = io.WriteString(w, b.String())
+			return err
+		}
+		fmt.Fprintf(&b, "\nadded %q (%s) %s to %s\n", src.Name, parserType(src), src.URL, c.sourcesFile)
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// parserType return the type of list parser of source.
+func parserType(src framework.CollectorSource) string {
+	switch {
+	case src.Type == framework.SourceTypeFeed:
+		return "feed"
+	case src.Selectors != nil:
+		return "selectors"
+	default:
+		return "llm"
+	}
+}
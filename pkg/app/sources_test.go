@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/anyvoxel/vela/pkg/collectors/framework"
+)
+
+func TestSourcesAddCommand_Execute(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			_, _ = w.Write([]byte(`<html><head><link rel="alternate" type="application/atom+xml" href="/atom.xml">
+</head></html>`))
+		case "/atom.xml":
+			_, _ = w.Write([]byte(`<feed xmlns="http://www.w3.org/2005/Atom">
+<entry><title>Leases</title><link href="/leases.html"/><published>2025-05-01T08:00:00Z</published></entry>
+</feed>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	sourcesFile := filepath.Join(t.TempDir(), "collectors.json")
+	c := &sourcesAddCommand{sourcesFile: sourcesFile, homepage: server.URL + "/", name: "example", dryRun: true}
+	var b strings.Builder
+	g.Expect(c.run(context.Background(), &b)).To(gomega.Succeed())
+	g.Expect(b.String()).To(gomega.Equal("feed " + server.URL + "/atom.xml: 1 posts\n\n" +
+		"posts of " + server.URL + "/atom.xml:\n" +
+		"  2025-05-01 Leases " + server.URL + "/leases.html\n\n" +
+		`proposed "example" (feed) ` + server.URL + "/atom.xml\n"))
+	_, err := framework.ReadSources(sourcesFile)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	c.dryRun = false
+	b.Reset()
+	g.Expect(c.run(context.Background(), &b)).To(gomega.Succeed())
+	g.Expect(b.String()).To(gomega.HaveSuffix(
+		`added "example" (feed) ` + server.URL + "/atom.xml to " + sourcesFile + "\n"))
+	sources, err := framework.ReadSources(sourcesFile)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(sources).To(gomega.Equal([]framework.CollectorSource{
+		{Name: "example", URL: server.URL + "/atom.xml", Type: framework.SourceTypeFeed},
+	}))
+	g.Expect(c.run(context.Background(), &b)).To(gomega.MatchError(framework.ErrDuplicateCollector))
+
+	c.homepage = server.URL + "/missing/"
+	g.Expect(c.run(context.Background(), &b)).To(gomega.HaveOccurred())
+	c.homepage = ""
+	g.Expect(c.run(context.Background(), &b)).To(gomega.MatchError(errNoHomepage))
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/anyvoxel/airmid/anvil"
+	airapp "github.com/anyvoxel/airmid/app"
+	"github.com/anyvoxel/airmid/ioc"
+
+	"github.com/anyvoxel/vela/pkg/collectors/manual"
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+func init() {
+	anvil.Must(airapp.RegisterBeanDefinition(
+		"vela.app.submitCommand",
+		ioc.MustNewBeanDefinition(
+			reflect.TypeFor[*submitCommand](),
+		),
+	))
+}
+
+var errNoSubmittedURL = errors.New("vela.submit.urls is empty")
+
+// submitCommand will queue vela.submit.urls, they are collected by the
+// manual collector in next run.
+type submitCommand struct {
+	store storage.Storage `airmid:"autowire:vela.storage.storage"`
+
+	urls []string `airmid:"value:${vela.submit.urls:=}"`
+}
+
+var _ command = (*submitCommand)(nil)
+
+// Name implement command.Name
+func (*submitCommand) Name() string { return "submit" }
+
+// Execute implement command.Execute
+func (c *submitCommand) Execute(ctx context.Context) error {
+	return c.run(ctx, os.Stdout)
+}
+
+func (c *submitCommand) run(ctx context.Context, w io.Writer) error {
+	paths := make([]string, 0, len(c.urls))
+	for _, raw := range c.urls {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+
+		path, err := manual.NormalizeURL(raw)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 {
+		return errNoSubmittedURL
+	}
+
+	var b strings.Builder
+	for _, path := range paths {
+		err := c.store.Submit(ctx, path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "queued %s\n", path)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+
+	mock_storage "github.com/anyvoxel/vela/pkg/storage/mocks"
+)
+
+func TestSubmitCommand_Execute(t *testing.T) {
+	g := gomega.NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	s := mock_storage.NewMockStorage(mockCtrl)
+	s.EXPECT().Submit(gomock.Any(), "https://example.com/a").Return(nil)
+	s.EXPECT().Submit(gomock.Any(), "https://example.com/b").Return(nil)
+
+	c := &submitCommand{store: s, urls: []string{"https://example.com/a#top", "", " https://example.com/b"}}
+	var b strings.Builder
+	g.Expect(c.run(context.Background(), &b)).To(gomega.Succeed())
+	g.Expect(b.String()).To(gomega.Equal("queued https://example.com/a\nqueued https://example.com/b\n"))
+
+	c.urls = []string{""}
+	g.Expect(c.run(context.Background(), &b)).To(gomega.MatchError(errNoSubmittedURL))
+	c.urls = []string{"example.com"}
+	g.Expect(c.run(context.Background(), &b)).To(gomega.HaveOccurred())
+}
@@ -0,0 +1,410 @@
+package framework
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	slogctx "github.com/veqryn/slog-context"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+	"github.com/anyvoxel/vela/pkg/collectors"
+	"github.com/anyvoxel/vela/pkg/fetch"
+)
+
+const (
+	// SourceTypeArXiv is the papers of arXiv category or search
+	SourceTypeArXiv = "arxiv"
+	// SourceTypeGitHubReleases is the releases of GitHub repos
+	SourceTypeGitHubReleases = "github_releases"
+	// SourceTypeHackerNews is the stories of Hacker News front page or search
+	SourceTypeHackerNews = "hackernews"
+
+	defaultArXivURL      = "https://export.arxiv.org/api/query"
+	defaultGitHubURL     = "https://github.com/"
+	defaultHackerNewsURL = "https://hn.algolia.com/api/v1/"
+	hackerNewsItemURL    = "https://news.ycombinator.com/item"
+
+	// defaultAPIMaxResults is the max results of a request if it's not
+	// configured
+	defaultAPIMaxResults = 50
+	// maxAPIBody is the max size of api response
+	maxAPIBody = 10 << 20
+)
+
+var (
+	errArXivQueryEmpty   = errors.New("arxiv category and query are empty")
+	errGitHubReposEmpty  = errors.New("github repos are empty")
+	errGitHubRepoInvalid = errors.New("github repo must be owner/repo")
+	errMaxResultsInvalid = errors.New("max_results must not be negative")
+)
+
+// githubRepoRegexp is the owner/repo of GitHub.
+var githubRepoRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+
+// arxivVersionRegexp is the version suffix of arXiv id, e.g. v2.
+var arxivVersionRegexp = regexp.MustCompile(`v[0-9]+$`)
+
+// ArXivOptions query the arXiv API, e.g.
+//
+//	{"category":"cs.DC","query":"abs:consensus","max_results":50}
+type ArXivOptions struct {
+	// Category is the arXiv category, e.g. cs.DC
+	Category string `json:"category,omitempty" yaml:"category,omitempty" toml:"category,omitempty"`
+	// Query is the search_query of arXiv API, it's ANDed with category
+	Query string `json:"query,omitempty" yaml:"query,omitempty" toml:"query,omitempty"`
+	// MaxResults is the number of latest papers, it defaults to 50
+	MaxResults int `json:"max_results,omitempty" yaml:"max_results,omitempty" toml:"max_results,omitempty"`
+}
+
+// GitHubReleasesOptions are the repos whose releases are collected, e.g.
+//
+//	{"repos":["etcd-io/etcd","tikv/tikv"]}
+type GitHubReleasesOptions struct {
+	// Repos are the owner/repo of GitHub
+	Repos []string `json:"repos" yaml:"repos" toml:"repos"`
+}
+
+// HackerNewsOptions search the stories by Algolia, the front page is
+// collected if the query is empty, e.g.
+//
+//	{"query":"postgres","min_points":100}
+type HackerNewsOptions struct {
+	// Query is the full-text query of the recent stories
+	Query string `json:"query,omitempty" yaml:"query,omitempty" toml:"query,omitempty"`
+	// MinPoints drops the stories with less points
+	MinPoints int `json:"min_points,omitempty" yaml:"min_points,omitempty" toml:"min_points,omitempty"`
+	// MaxResults is the number of stories, it defaults to 50
+	MaxResults int `json:"max_results,omitempty" yaml:"max_results,omitempty" toml:"max_results,omitempty"`
+}
+
+// apiRequest is a request of apiCollector and the parser of its response.
+type apiRequest struct {
+	url   string
+	parse func(ctx context.Context, body []byte, requestURL, domain string) ([]apitypes.Post, error)
+}
+
+// apiCollector collect the posts of the public APIs, e.g. arXiv, GitHub
+// releases and Hacker News.
+type apiCollector struct {
+	name          string
+	header        http.Header
+	secretHeaders []string
+	models        []string
+	requests      []apiRequest
+	// policy limits the fetching of api
+	policy *fetch.Policy
+
+	// transport is nil for the default transport, it's used in tests
+	transport http.RoundTripper
+}
+
+var _ collectors.Collector = (*apiCollector)(nil)
+
+// sourceURL return the url of source, the url of api source is the base url
+// of api which defaults to the public one.
+func sourceURL(src CollectorSource) string {
+	if strings.TrimSpace(src.URL) != "" {
+		return src.URL
+	}
+	switch src.Type {
+	case SourceTypeArXiv:
+		return defaultArXivURL
+	case SourceTypeGitHubReleases:
+		return defaultGitHubURL
+	case SourceTypeHackerNews:
+		return defaultHackerNewsURL
+	}
+	return src.URL
+}
+
+func newAPICollector(src CollectorSource) (*apiCollector, error) {
+	src.URL = sourceURL(src)
+	name, base, err := sourceNameURL(src)
+	if err != nil {
+		return nil, err
+	}
+	if src.Selectors != nil {
+		return nil, fmt.Errorf("%w: %q", errSelectorsUnsupported, src.Type)
+	}
+	hdr, secretHeaders, err := resolveHeaders(src.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	var requests []apiRequest
+	switch src.Type {
+	case SourceTypeArXiv:
+		requests, err = arxivRequests(base, src.ArXiv)
+	case SourceTypeGitHubReleases:
+		requests, err = githubReleasesRequests(base, src.GitHubReleases)
+	case SourceTypeHackerNews:
+		requests, err = hackerNewsRequests(base, src.HackerNews)
+	default:
+		err = fmt.Errorf("%w: %q", errSourceTypeUnknown, src.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiCollector{
+		name:          name,
+		header:        hdr,
+		secretHeaders: secretHeaders,
+		models:        src.Models,
+		requests:      requests,
+	}, nil
+}
+
+func maxResults(n int) (int, error) {
+	if n < 0 {
+		return 0, fmt.Errorf("%w: %d", errMaxResultsInvalid, n)
+	}
+	if n == 0 {
+		return defaultAPIMaxResults, nil
+	}
+	return n, nil
+}
+
+// arxivRequests return the request of the latest submitted papers.
+func arxivRequests(base *url.URL, opts *ArXivOptions) ([]apiRequest, error) {
+	if opts == nil || (strings.TrimSpace(opts.Category) == "" && strings.TrimSpace(opts.Query) == "") {
+		return nil, errArXivQueryEmpty
+	}
+	n, err := maxResults(opts.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := make([]string, 0, 2)
+	if category := strings.TrimSpace(opts.Category); category != "" {
+		terms = append(terms, "cat:"+category)
+	}
+	if query := strings.TrimSpace(opts.Query); query != "" {
+		terms = append(terms, "("+query+")")
+	}
+	u := *base
+	q := u.Query()
+	q.Set("search_query", strings.Join(terms, " AND "))
+	q.Set("sortBy", "submittedDate")
+	q.Set("sortOrder", "descending")
+	q.Set("max_results", strconv.Itoa(n))
+	u.RawQuery = q.Encode()
+	return []apiRequest{{url: u.String(), parse: parseArXivFeed}}, nil
+}
+
+// parseArXivFeed return the abstract pages of the papers, the version of id
+// is dropped so the revisions are the same post.
+func parseArXivFeed(ctx context.Context, body []byte, requestURL, domain string) ([]apitypes.Post, error) {
+	posts, err := feedListParser{}.ParseList(ctx, string(body), requestURL, domain)
+	if err != nil {
+		return nil, err
+	}
+	for i := range posts {
+		u, err := url.Parse(posts[i].Path)
+		if err != nil {
+			continue
+		}
+		u.Scheme = "https"
+		u.Path = arxivVersionRegexp.ReplaceAllString(u.Path, "")
+		posts[i].Path = u.String()
+	}
+	return posts, nil
+}
+
+// githubReleasesRequests return the requests of the releases.atom of repos.
+func githubReleasesRequests(base *url.URL, opts *GitHubReleasesOptions) ([]apiRequest, error) {
+	if opts == nil || len(opts.Repos) == 0 {
+		return nil, errGitHubReposEmpty
+	}
+
+	requests := make([]apiRequest, 0, len(opts.Repos))
+	for _, repo := range opts.Repos {
+		repo = strings.Trim(strings.TrimSpace(repo), "/")
+		if !githubRepoRegexp.MatchString(repo) {
+			return nil, fmt.Errorf("%w: %q", errGitHubRepoInvalid, repo)
+		}
+		requests = append(requests, apiRequest{
+			url: base.JoinPath(repo, "releases.atom").String(),
+			parse: func(ctx context.Context, body []byte, requestURL, domain string) ([]apitypes.Post, error) {
+				posts, err := feedListParser{}.ParseList(ctx, string(body), requestURL, domain)
+				if err != nil {
+					return nil, err
+				}
+				// The release title is usually the bare version
+				for i := range posts {
+					posts[i].Title = repo + " " + posts[i].Title
+				}
+				return posts, nil
+			},
+		})
+	}
+	return requests, nil
+}
+
+// hackerNewsRequests return the request of Algolia search, the stories of
+// query are sorted by date.
+func hackerNewsRequests(base *url.URL, opts *HackerNewsOptions) ([]apiRequest, error) {
+	if opts == nil {
+		opts = &HackerNewsOptions{}
+	}
+	n, err := maxResults(opts.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+
+	u := base.JoinPath("search")
+	q := u.Query()
+	if query := strings.TrimSpace(opts.Query); query != "" {
+		u = base.JoinPath("search_by_date")
+		q.Set("query", query)
+		q.Set("tags", "story")
+	} else {
+		q.Set("tags", "front_page")
+	}
+	if opts.MinPoints > 0 {
+		q.Set("numericFilters", "points>="+strconv.Itoa(opts.MinPoints))
+	}
+	q.Set("hitsPerPage", strconv.Itoa(n))
+	u.RawQuery = q.Encode()
+
+	minPoints := opts.MinPoints
+	return []apiRequest{{
+		url: u.String(),
+		parse: func(_ context.Context, body []byte, _, domain string) ([]apitypes.Post, error) {
+			return parseHackerNewsHits(body, domain, minPoints)
+		},
+	}}, nil
+}
+
+type hackerNewsHit struct {
+	ObjectID  string `json:"objectID"`
+	Title     string `json:"title"`
+	URL       string `json:"url"`
+	Points    int    `json:"points"`
+	CreatedAt string `json:"created_at"`
+}
+
+// parseHackerNewsHits return the linked pages of stories, the story itself
+// is the post if it has no link, e.g. Ask HN.
+func parseHackerNewsHits(body []byte, domain string, minPoints int) ([]apitypes.Post, error) {
+	var resp struct {
+		Hits []hackerNewsHit `json:"hits"`
+	}
+	err := json.Unmarshal(body, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	posts := make([]apitypes.Post, 0, len(resp.Hits))
+	seen := make(map[string]struct{}, len(resp.Hits))
+	for _, hit := range resp.Hits {
+		title := normalizeText(hit.Title)
+		if title == "" || hit.Points < minPoints {
+			continue
+		}
+		link := strings.TrimSpace(hit.URL)
+		if link == "" {
+			if hit.ObjectID == "" {
+				continue
+			}
+			link = hackerNewsItemURL + "?id=" + url.QueryEscape(hit.ObjectID)
+		}
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		u.Fragment = ""
+		if _, ok := seen[u.String()]; ok {
+			continue
+		}
+		seen[u.String()] = struct{}{}
+
+		publishedAt, _ := time.Parse(time.RFC3339, hit.CreatedAt)
+		posts = append(posts, apitypes.Post{
+			Domain:      domain,
+			Title:       title,
+			Path:        u.String(),
+			PublishedAt: publishedAt.UTC(),
+		})
+	}
+	return posts, nil
+}
+
+// Name implement collectors.Collector
+func (c *apiCollector) Name() string { return c.name }
+
+// Initialize implement collectors.Collector
+func (c *apiCollector) Initialize(_ context.Context) error { return nil }
+
+// Start implement collectors.Collector, the failed request doesn't stop the
+// other ones, e.g. a renamed repo.
+func (c *apiCollector) Start(ctx context.Context, ch chan<- apitypes.Post) error {
+	client := &http.Client{
+		Transport: c.policy.Transport(c.name, c.transport),
+		Jar:       c.policy.Jar(c.name),
+		Timeout:   time.Minute,
+	}
+
+	var errs []error
+	for _, r := range c.requests {
+		posts, err := c.request(ctx, client, r)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errs = append(errs, fmt.Errorf("request %q failed: %w", r.url, err))
+			continue
+		}
+		for _, post := range posts {
+			post.Models = c.models
+			slogctx.FromCtx(ctx).InfoContext(ctx, "collect article",
+				slog.String("Path", post.Path),
+				slog.String("Title", post.Title),
+				slog.Any("PublishedAt", post.PublishedAt),
+			)
+			select {
+			case ch <- post:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *apiCollector) request(ctx context.Context, client *http.Client, r apiRequest) ([]apitypes.Post, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	slogctx.FromCtx(ctx).DebugContext(ctx, "fetch api",
+		slog.String("URL", r.url),
+		slog.Any("Headers", redactedHeader{header: c.header, secrets: c.secretHeaders}),
+	)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", errUnexpectedPage, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxAPIBody))
+	if err != nil {
+		return nil, err
+	}
+	return r.parse(ctx, body, r.url, c.name)
+}
@@ -0,0 +1,184 @@
+package framework
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+)
+
+func startAPICollector(g *gomega.WithT, src CollectorSource) []apitypes.Post {
+	c, err := newSourceCollector(src, nil, nil)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	ch := make(chan apitypes.Post, 10)
+	g.Expect(c.Start(context.Background(), ch)).To(gomega.Succeed())
+	close(ch)
+	posts := make([]apitypes.Post, 0)
+	for post := range ch {
+		posts = append(posts, post)
+	}
+	return posts
+}
+
+func TestAPICollector_ArXiv(t *testing.T) {
+	g := gomega.NewWithT(t)
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		_, _ = w.Write([]byte(`<feed xmlns="http://www.w3.org/2005/Atom">
+<entry>
+  <id>http://arxiv.org/abs/2501.00001v2</id>
+  <published>2025-01-01T18:00:00Z</published>
+  <title>Fast Raft
+    Snapshots</title>
+  <summary>We make the snapshots fast.</summary>
+  <link href="http://arxiv.org/abs/2501.00001v2" rel="alternate" type="text/html"/>
+  <link title="pdf" href="http://arxiv.org/pdf/2501.00001v2" rel="related" type="application/pdf"/>
+</entry>
+</feed>`))
+	}))
+	defer server.Close()
+
+	posts := startAPICollector(g, CollectorSource{
+		Name:   "arxivdc",
+		URL:    server.URL + "/api/query",
+		Type:   SourceTypeArXiv,
+		Models: []string{"LONG_CONTEXT"},
+		ArXiv:  &ArXivOptions{Category: "cs.DC", Query: "abs:raft"},
+	})
+	g.Expect(posts).To(gomega.Equal([]apitypes.Post{{
+		Domain:      "arxivdc",
+		Title:       "Fast Raft Snapshots",
+		Path:        "https://arxiv.org/abs/2501.00001",
+		PublishedAt: time.Date(2025, 1, 1, 18, 0, 0, 0, time.UTC),
+		Models:      []string{"LONG_CONTEXT"},
+	}}))
+	g.Expect(queries).To(gomega.Equal([]string{
+		"max_results=50&search_query=cat%3Acs.DC+AND+%28abs%3Araft%29&sortBy=submittedDate&sortOrder=descending",
+	}))
+}
+
+func TestAPICollector_GitHubReleases(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/etcd-io/etcd/releases.atom" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`<feed xmlns="http://www.w3.org/2005/Atom">
+<entry>
+  <updated>2025-03-01T08:00:00Z</updated>
+  <link rel="alternate" type="text/html" href="https://github.com/etcd-io/etcd/releases/tag/v3.6.0"/>
+  <title>v3.6.0</title>
+</entry>
+</feed>`))
+	}))
+	defer server.Close()
+
+	c, err := newSourceCollector(CollectorSource{
+		Name:           "releases",
+		URL:            server.URL,
+		Type:           SourceTypeGitHubReleases,
+		GitHubReleases: &GitHubReleasesOptions{Repos: []string{"etcd-io/etcd", "/tikv/tikv/"}},
+	}, nil, nil)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	ch := make(chan apitypes.Post, 10)
+	// The missing repo doesn't stop the other ones
+	err = c.Start(context.Background(), ch)
+	g.Expect(err).To(gomega.MatchError(errUnexpectedPage))
+	g.Expect(err.Error()).To(gomega.ContainSubstring("/tikv/tikv/releases.atom"))
+	close(ch)
+	g.Expect(<-ch).To(gomega.Equal(apitypes.Post{
+		Domain:      "releases",
+		Title:       "etcd-io/etcd v3.6.0",
+		Path:        "https://github.com/etcd-io/etcd/releases/tag/v3.6.0",
+		PublishedAt: time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC),
+	}))
+	g.Expect(ch).To(gomega.BeClosed())
+}
+
+func TestAPICollector_HackerNews(t *testing.T) {
+	g := gomega.NewWithT(t)
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path+"?"+r.URL.RawQuery)
+		_, _ = w.Write([]byte(`{"hits":[
+{"objectID":"1","title":"Postgres 18","url":"https://example.com/pg18#top","points":300,
+ "created_at":"2025-09-25T10:00:00Z"},
+{"objectID":"2","title":"Ask HN: Postgres or MySQL?","points":120,"created_at":"2025-09-24T10:00:00.000Z"},
+{"objectID":"3","title":"Low points","url":"https://example.com/low","points":3},
+{"objectID":"4","title":"","url":"https://example.com/untitled","points":500}
+]}`))
+	}))
+	defer server.Close()
+
+	posts := startAPICollector(g, CollectorSource{
+		Name:       "hn",
+		URL:        server.URL + "/api/v1/",
+		Type:       SourceTypeHackerNews,
+		HackerNews: &HackerNewsOptions{Query: "postgres", MinPoints: 100, MaxResults: 20},
+	})
+	g.Expect(posts).To(gomega.Equal([]apitypes.Post{
+		{
+			Domain:      "hn",
+			Title:       "Postgres 18",
+			Path:        "https://example.com/pg18",
+			PublishedAt: time.Date(2025, 9, 25, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			Domain:      "hn",
+			Title:       "Ask HN: Postgres or MySQL?",
+			Path:        "https://news.ycombinator.com/item?id=2",
+			PublishedAt: time.Date(2025, 9, 24, 10, 0, 0, 0, time.UTC),
+		},
+	}))
+
+	// The front page is collected without query
+	startAPICollector(g, CollectorSource{Name: "hn", URL: server.URL + "/api/v1/", Type: SourceTypeHackerNews})
+	g.Expect(requests).To(gomega.Equal([]string{
+		"/api/v1/search_by_date?hitsPerPage=20&numericFilters=points%3E%3D100&query=postgres&tags=story",
+		"/api/v1/search?hitsPerPage=50&tags=front_page",
+	}))
+}
+
+func TestNewSourceCollector_API(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	// The url defaults to the public api
+	c, err := newSourceCollector(CollectorSource{Name: "arxiv", Type: SourceTypeArXiv,
+		ArXiv: &ArXivOptions{Category: "cs.DB"}}, nil, nil)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(c.(*apiCollector).requests[0].url).To(gomega.HavePrefix(defaultArXivURL + "?"))
+	c, err = newSourceCollector(CollectorSource{Name: "releases", Type: SourceTypeGitHubReleases,
+		GitHubReleases: &GitHubReleasesOptions{Repos: []string{"etcd-io/etcd"}}}, nil, nil)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(c.(*apiCollector).requests[0].url).To(gomega.Equal("https://github.com/etcd-io/etcd/releases.atom"))
+	c, err = newSourceCollector(CollectorSource{Name: "hn", Type: SourceTypeHackerNews}, nil, nil)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(c.(*apiCollector).requests[0].url).To(gomega.HavePrefix(defaultHackerNewsURL + "search?"))
+	// The auth of transport is scoped to the default url
+	g.Expect(sourceURL(CollectorSource{Type: SourceTypeGitHubReleases})).To(gomega.Equal(defaultGitHubURL))
+	g.Expect(sourceURL(CollectorSource{Type: SourceTypeFeed})).To(gomega.BeEmpty())
+
+	for src, expected := range map[*CollectorSource]error{
+		{Name: "arxiv", Type: SourceTypeArXiv}:                                                   errArXivQueryEmpty,
+		{Name: "arxiv", Type: SourceTypeArXiv, ArXiv: &ArXivOptions{Query: " "}}:                 errArXivQueryEmpty,
+		{Name: "releases", Type: SourceTypeGitHubReleases}:                                       errGitHubReposEmpty,
+		{Name: "hn", Type: SourceTypeHackerNews, HackerNews: &HackerNewsOptions{MaxResults: -1}}: errMaxResultsInvalid,
+		{Name: "hn", Type: SourceTypeHackerNews, Selectors: &ListSelectors{Item: "li"}}:          errSelectorsUnsupported,
+		{Name: "", Type: SourceTypeHackerNews}:                                                   errCollectorNameEmpty,
+		{Name: "hn", Type: SourceTypeFeed, HackerNews: &HackerNewsOptions{}}:                     errOptionsUnsupported,
+		{Name: "releases", Type: SourceTypeGitHubReleases,
+			GitHubReleases: &GitHubReleasesOptions{Repos: []string{"etcd"}}}: errGitHubRepoInvalid,
+	} {
+		_, err = newSourceCollector(*src, nil, nil)
+		g.Expect(err).To(gomega.MatchError(expected), src.Name)
+	}
+	_, err = SourceListParser(CollectorSource{Type: SourceTypeArXiv}, stubListParser{})
+	g.Expect(err).To(gomega.MatchError(errListParserUnsupported))
+}
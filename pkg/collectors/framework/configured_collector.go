@@ -14,6 +14,7 @@ import (
 
 	"github.com/anyvoxel/vela/pkg/apitypes"
 	"github.com/anyvoxel/vela/pkg/collectors"
+	"github.com/anyvoxel/vela/pkg/fetch"
 )
 
 var (
@@ -22,61 +23,176 @@ var (
 	errListParserNil       = errors.New("listParser is nil")
 	errURLMustBeAbsolute   = errors.New("url must be absolute")
 	errCollectorURLInvalid = errors.New("collector url is invalid")
+	errOptionsUnsupported  = errors.New("options are not supported by source type")
 )
 
 // CollectorSource describes a list page source that can be collected.
-// It is intended to be loaded from JSON config.
+// It is intended to be loaded from the JSON, YAML or TOML sources file.
 type CollectorSource struct {
-	Name    string            `json:"name"`
-	URL     string            `json:"url"`
-	Headers map[string]string `json:"headers,omitempty"`
+	Name string `json:"name" yaml:"name" toml:"name"`
+	URL  string `json:"url" yaml:"url" toml:"url"`
+	// Type is the type of url, it's SourceTypeList if it's empty, see also
+	// SourceTypeFeed, SourceTypeSitemap and the api types, e.g. SourceTypeArXiv
+	Type string `json:"type,omitempty" yaml:"type,omitempty" toml:"type,omitempty"`
+	// Headers are sent with the request of list page, the values may
+	// reference the secrets by ${env:NAME} or ${file:/path}
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty" toml:"headers,omitempty"`
+	// Models overrides the chat models to summarize the posts of the source,
+	// e.g. a long context model for papers, see apitypes.Post.Models
+	Models []string `json:"models,omitempty" yaml:"models,omitempty" toml:"models,omitempty"`
+	// Selectors parse the list page by css selectors instead of llm
+	Selectors *ListSelectors `json:"selectors,omitempty" yaml:"selectors,omitempty" toml:"selectors,omitempty"`
+	// Sitemap filters the urls of the sitemap source
+	Sitemap *SitemapOptions `json:"sitemap,omitempty" yaml:"sitemap,omitempty" toml:"sitemap,omitempty"`
+	// ArXiv is the query of the arxiv source
+	ArXiv *ArXivOptions `json:"arxiv,omitempty" yaml:"arxiv,omitempty" toml:"arxiv,omitempty"`
+	// GitHubReleases are the repos of the github_releases source
+	GitHubReleases *GitHubReleasesOptions `json:"github_releases,omitempty" yaml:"github_releases,omitempty" toml:"github_releases,omitempty"` //nolint:lll
+	// HackerNews is the query of the hackernews source
+	HackerNews *HackerNewsOptions `json:"hackernews,omitempty" yaml:"hackernews,omitempty" toml:"hackernews,omitempty"`
+	// Transport overrides the global transport options of the list page and
+	// posts, e.g. a proxy or a session cookie
+	Transport *fetch.TransportOptions `json:"transport,omitempty" yaml:"transport,omitempty" toml:"transport,omitempty"`
 }
 
 type configuredCollector struct {
 	name       string
 	url        string
 	header     http.Header
+	models     []string
 	listParser collectors.ListParser
+	// secretHeaders are the canonical keys of header which contain secrets
+	secretHeaders []string
+	// policy limits the fetching of list page
+	policy *fetch.Policy
+
+	// transport is nil for the default transport, it's used to replay the
+	// recorded responses in tests
+	transport http.RoundTripper
 }
 
 func newConfiguredCollector(src CollectorSource, listParser collectors.ListParser) (*configuredCollector, error) {
+	name, parsed, err := sourceNameURL(src)
+	if err != nil {
+		return nil, err
+	}
+	if listParser == nil {
+		return nil, errListParserNil
+	}
+
+	hdr, secretHeaders, err := resolveHeaders(src.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &configuredCollector{
+		name:          name,
+		url:           parsed.String(),
+		header:        hdr,
+		secretHeaders: secretHeaders,
+		models:        src.Models,
+		listParser:    listParser,
+	}, nil
+}
+
+// sourceNameURL return the trimmed name and the absolute url of source.
+func sourceNameURL(src CollectorSource) (string, *url.URL, error) {
 	name := strings.TrimSpace(src.Name)
 	if name == "" {
-		return nil, errCollectorNameEmpty
+		return "", nil, errCollectorNameEmpty
 	}
 	urlStr := strings.TrimSpace(src.URL)
 	if urlStr == "" {
-		return nil, errCollectorURLEmpty
+		return "", nil, errCollectorURLEmpty
 	}
 	parsed, err := url.Parse(urlStr)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %q: %w", errCollectorURLInvalid, urlStr, err)
+		return "", nil, fmt.Errorf("%w: %q: %w", errCollectorURLInvalid, urlStr, err)
 	}
 	if !parsed.IsAbs() {
-		return nil, fmt.Errorf("%w: %q", errURLMustBeAbsolute, urlStr)
+		return "", nil, fmt.Errorf("%w: %q", errURLMustBeAbsolute, urlStr)
 	}
-	if listParser == nil {
-		return nil, errListParserNil
+	return name, parsed, nil
+}
+
+// resolveHeaders return the header of source with the secrets resolved, and
+// the canonical keys of header which contain secrets.
+func resolveHeaders(headers map[string]string) (http.Header, []string, error) {
+	if len(headers) == 0 {
+		return nil, nil, nil
 	}
 
-	var hdr http.Header
-	if len(src.Headers) > 0 {
-		hdr = make(http.Header, len(src.Headers))
-		for k, v := range src.Headers {
-			k = strings.TrimSpace(k)
-			if k == "" {
-				continue
-			}
-			hdr.Set(k, strings.TrimSpace(v))
+	hdr := make(http.Header, len(headers))
+	var secretHeaders []string
+	for k, v := range headers {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		v, secret, err := resolveSecretRefs(strings.TrimSpace(v))
+		if err != nil {
+			return nil, nil, fmt.Errorf("header %q: %w", k, err)
+		}
+		hdr.Set(k, v)
+		if secret {
+			secretHeaders = append(secretHeaders, http.CanonicalHeaderKey(k))
 		}
 	}
+	return hdr, secretHeaders, nil
+}
 
-	return &configuredCollector{
-		name:       name,
-		url:        parsed.String(),
-		header:     hdr,
-		listParser: listParser,
-	}, nil
+// checkTypeOptions return an error if the source has the options of the
+// other type, e.g. a typo of type.
+func checkTypeOptions(src CollectorSource) error {
+	for typ, set := range map[string]bool{
+		SourceTypeSitemap:        src.Sitemap != nil,
+		SourceTypeArXiv:          src.ArXiv != nil,
+		SourceTypeGitHubReleases: src.GitHubReleases != nil,
+		SourceTypeHackerNews:     src.HackerNews != nil,
+	} {
+		if set && src.Type != typ {
+			return fmt.Errorf("%w: %s options of %q", errOptionsUnsupported, typ, src.Type)
+		}
+	}
+	return nil
+}
+
+// newSourceCollector return the collector of source by its type.
+func newSourceCollector(
+	src CollectorSource, llmParser collectors.ListParser, policy *fetch.Policy,
+) (collectors.Collector, error) {
+	err := checkTypeOptions(src)
+	if err != nil {
+		return nil, err
+	}
+
+	switch src.Type {
+	case SourceTypeSitemap:
+		sc, err := newSitemapCollector(src)
+		if err != nil {
+			return nil, err
+		}
+		sc.policy = policy
+		return sc, nil
+	case SourceTypeArXiv, SourceTypeGitHubReleases, SourceTypeHackerNews:
+		ac, err := newAPICollector(src)
+		if err != nil {
+			return nil, err
+		}
+		ac.policy = policy
+		return ac, nil
+	}
+
+	listParser, err := SourceListParser(src, llmParser)
+	if err != nil {
+		return nil, err
+	}
+	cc, err := newConfiguredCollector(src, listParser)
+	if err != nil {
+		return nil, err
+	}
+	cc.policy = policy
+	return cc, nil
 }
 
 func (c *configuredCollector) Name() string { return c.name }
@@ -84,7 +200,11 @@ func (c *configuredCollector) Name() string { return c.name }
 func (c *configuredCollector) Initialize(_ context.Context) error { return nil }
 
 func (c *configuredCollector) Start(ctx context.Context, ch chan<- apitypes.Post) error {
-	listCollector := colly.NewCollector()
+	listCollector := colly.NewCollector(colly.UserAgent(c.policy.UserAgent()))
+	listCollector.WithTransport(c.policy.Transport(c.name, c.transport))
+	if jar := c.policy.Jar(c.name); jar != nil {
+		listCollector.SetCookieJar(jar)
+	}
 	listCollector.OnResponse(func(r *colly.Response) {
 		posts, err := c.listParser.ParseList(ctx, string(r.Body), r.Request.URL.String(), c.Name())
 		if err != nil {
@@ -96,6 +216,7 @@ func (c *configuredCollector) Start(ctx context.Context, ch chan<- apitypes.Post
 			return
 		}
 		for _, post := range posts {
+			post.Models = c.models
 			slogctx.FromCtx(ctx).InfoContext(ctx, "collect article",
 				slog.String("Path", post.Path),
 				slog.String("Title", post.Title),
@@ -105,6 +226,10 @@ func (c *configuredCollector) Start(ctx context.Context, ch chan<- apitypes.Post
 		}
 	})
 
+	slogctx.FromCtx(ctx).DebugContext(ctx, "fetch list page",
+		slog.String("URL", c.url),
+		slog.Any("Headers", redactedHeader{header: c.header, secrets: c.secretHeaders}),
+	)
 	err := listCollector.Request("GET", c.url, nil, colly.NewContext(), c.header)
 	if err != nil {
 		return err
@@ -0,0 +1,271 @@
+package framework
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/publicsuffix"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+	"github.com/anyvoxel/vela/pkg/collectors"
+)
+
+const (
+	// maxDiscoverBody is the max size of the probed page
+	maxDiscoverBody = 5 << 20
+	// maxListCandidates is the max number of list pages parsed by llm
+	maxListCandidates = 3
+)
+
+var (
+	errHomepageInvalid = errors.New("homepage must be an absolute http url")
+	errUnexpectedPage  = errors.New("unexpected page")
+)
+
+// feedPaths are the common paths of feeds, they are relative to the homepage
+// or the root of site.
+var feedPaths = []string{"feed", "feed.xml", "rss.xml", "atom.xml", "index.xml", "rss"}
+
+// archivePaths are the common paths of the list pages.
+var archivePaths = []string{"archive", "archives", "blog", "posts", "articles", "all-posts"}
+
+// archiveTexts are the texts of the links to the list pages.
+var archiveTexts = []string{"archive", "archives", "blog", "posts", "all posts", "articles", "writing"}
+
+// Candidate is a feed or list page of a site found by Discover.
+type Candidate struct {
+	Source CollectorSource
+	Posts  []apitypes.Post
+	// Err is the failure to fetch or parse it
+	Err error
+}
+
+// Discovery is the result of Discover.
+type Discovery struct {
+	// Candidates are sorted by preference, the first one with posts is
+	// proposed
+	Candidates []*Candidate
+	// Sitemaps are the sitemaps found in robots.txt or at /sitemap.xml
+	Sitemaps []string
+}
+
+// Proposed return the first candidate with posts, it's nil if all of them
+// are failed or empty.
+func (d *Discovery) Proposed() *Candidate {
+	for _, c := range d.Candidates {
+		if c.Err == nil && len(c.Posts) > 0 {
+			return c
+		}
+	}
+	return nil
+}
+
+// discoverer probe the pages of a site by client.
+type discoverer struct {
+	client *http.Client
+	// probed are the probed urls, to avoid probing twice
+	probed map[string]struct{}
+}
+
+// Discover probe the feeds, sitemaps and list pages of homepage. The feeds
+// are parsed as is, and the list pages are parsed by llmParser if it's not
+// nil. The feeds are preferred to the list pages because they don't cost llm
+// calls, and the candidates with more posts are preferred.
+func Discover(
+	ctx context.Context, client *http.Client, homepage string, llmParser collectors.ListParser,
+) (*Discovery, error) {
+	home, err := url.Parse(strings.TrimSpace(homepage))
+	if err != nil || !home.IsAbs() || (home.Scheme != "http" && home.Scheme != "https") {
+		return nil, fmt.Errorf("%w: %q", errHomepageInvalid, homepage)
+	}
+	home.Fragment = ""
+	d := &discoverer{client: client, probed: make(map[string]struct{})}
+	name := ProposeSourceName(home)
+
+	body, err := d.get(ctx, home.String())
+	if err != nil {
+		return nil, fmt.Errorf("fetch homepage %q failed: %w", home.String(), err)
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	discovery := &Discovery{Sitemaps: d.sitemaps(ctx, home)}
+	for _, feedURL := range feedURLs(doc, home) {
+		c := d.candidate(ctx, CollectorSource{Name: name, URL: feedURL, Type: SourceTypeFeed}, feedListParser{})
+		if c != nil {
+			discovery.Candidates = append(discovery.Candidates, c)
+		}
+	}
+
+	if llmParser != nil {
+		lists := 0
+		for _, listURL := range listURLs(doc, home) {
+			if lists >= maxListCandidates {
+				break
+			}
+			c := d.candidate(ctx, CollectorSource{Name: name, URL: listURL}, llmParser)
+			if c != nil {
+				discovery.Candidates = append(discovery.Candidates, c)
+				lists++
+			}
+		}
+	}
+
+	slices.SortStableFunc(discovery.Candidates, func(x, y *Candidate) int {
+		if (x.Source.Type == SourceTypeFeed) != (y.Source.Type == SourceTypeFeed) {
+			if x.Source.Type == SourceTypeFeed {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(len(y.Posts), len(x.Posts))
+	})
+	return discovery, nil
+}
+
+// candidate fetch and parse the url of src, it's nil if the url is not
+// found, e.g. the probed path doesn't exist.
+func (d *discoverer) candidate(ctx context.Context, src CollectorSource, parser collectors.ListParser) *Candidate {
+	if _, ok := d.probed[src.URL]; ok {
+		return nil
+	}
+	d.probed[src.URL] = struct{}{}
+
+	body, err := d.get(ctx, src.URL)
+	if err != nil {
+		return nil
+	}
+	c := &Candidate{Source: src}
+	c.Posts, c.Err = parser.ParseList(ctx, body, src.URL, src.Name)
+	if errors.Is(c.Err, errNotFeed) {
+		// The probed path is a html page
+		return nil
+	}
+	return c
+}
+
+// sitemaps return the sitemaps declared in robots.txt, or the /sitemap.xml
+// if it's a sitemap.
+func (d *discoverer) sitemaps(ctx context.Context, home *url.URL) []string {
+	sitemaps := make([]string, 0)
+	robots, err := d.get(ctx, home.ResolveReference(&url.URL{Path: "/robots.txt"}).String())
+	if err == nil {
+		scanner := bufio.NewScanner(strings.NewReader(robots))
+		for scanner.Scan() {
+			key, value, ok := strings.Cut(scanner.Text(), ":")
+			if ok && strings.EqualFold(strings.TrimSpace(key), "sitemap") {
+				sitemaps = append(sitemaps, strings.TrimSpace(value))
+			}
+		}
+	}
+	if len(sitemaps) > 0 {
+		return sitemaps
+	}
+
+	sitemap := home.ResolveReference(&url.URL{Path: "/sitemap.xml"}).String()
+	body, err := d.get(ctx, sitemap)
+	if err == nil && (strings.Contains(body, "<urlset") || strings.Contains(body, "<sitemapindex")) {
+		sitemaps = append(sitemaps, sitemap)
+	}
+	return sitemaps
+}
+
+func (d *discoverer) get(ctx context.Context, u string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close() //nolint
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: %s", errUnexpectedPage, resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxDiscoverBody))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// feedURLs return the feeds linked by the homepage, and the common feed
+// paths.
+func feedURLs(doc *goquery.Document, home *url.URL) []string {
+	urls := make([]string, 0)
+	doc.Find("link[rel~=alternate][href]").Each(func(_ int, link *goquery.Selection) {
+		typ := strings.ToLower(link.AttrOr("type", ""))
+		if strings.Contains(typ, "rss") || strings.Contains(typ, "atom") {
+			urls = appendResolved(urls, home, link.AttrOr("href", ""))
+		}
+	})
+	for _, path := range feedPaths {
+		urls = appendResolved(urls, home, path)
+		urls = appendResolved(urls, home, "/"+path)
+	}
+	return urls
+}
+
+// listURLs return the homepage, the archive pages linked by the homepage, and
+// the common archive paths.
+func listURLs(doc *goquery.Document, home *url.URL) []string {
+	urls := []string{home.String()}
+	doc.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+		if slices.Contains(archiveTexts, strings.ToLower(normalizeText(a.Text()))) {
+			urls = appendResolved(urls, home, a.AttrOr("href", ""))
+		}
+	})
+	for _, path := range archivePaths {
+		urls = appendResolved(urls, home, path)
+		urls = appendResolved(urls, home, "/"+path)
+	}
+	return urls
+}
+
+// appendResolved append the href resolved against home if it's on the same
+// host and not appended yet.
+func appendResolved(urls []string, home *url.URL, href string) []string {
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return urls
+	}
+	u := home.ResolveReference(ref)
+	u.Fragment = ""
+	if u.Host != home.Host || (u.Scheme != "http" && u.Scheme != "https") || slices.Contains(urls, u.String()) {
+		return urls
+	}
+	return append(urls, u.String())
+}
+
+// ProposeSourceName return the name of source from the host of u, it's the
+// subdomains and the registered domain without the public suffix, e.g.
+// engineeringfb for https://engineering.fb.com/.
+func ProposeSourceName(u *url.URL) string {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	suffix, _ := publicsuffix.PublicSuffix(host)
+	host = strings.TrimSuffix(strings.TrimSuffix(host, suffix), ".")
+
+	var b strings.Builder
+	for _, r := range host {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return strings.ToLower(u.Hostname())
+	}
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package framework
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+)
+
+// countingListParser return a post for each link of page.
+type countingListParser struct {
+	urls []string
+}
+
+func (p *countingListParser) ParseList(_ context.Context, html, baseURL, domain string) ([]apitypes.Post, error) {
+	p.urls = append(p.urls, baseURL)
+	return (&selectorListParser{selectors: ListSelectors{Item: "a.post"}}).ParseList(context.Background(),
+		html, baseURL, domain)
+}
+
+func newDiscoverServer(t *testing.T, pages map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(page))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDiscover(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server := newDiscoverServer(t, map[string]string{
+		"/blog/": `<html><head><link rel="alternate" type="application/rss+xml" href="/blog/index.xml"></head>
+<body><a href="/blog/archive/">Archive</a><a class="post" href="/blog/a.html">A</a></body></html>`,
+		"/blog/index.xml": `<rss><channel><item><title>A</title><link>/blog/a.html</link></item></channel></rss>`,
+		"/blog/archive/": `<html><body><a class="post" href="/blog/a.html">A</a><a class="post" href="/blog/b.html">B</a>
+</body></html>`,
+		// The html page is not a feed
+		"/feed":       `<html><body>not found</body></html>`,
+		"/robots.txt": "User-agent: *\nSitemap: https://example.com/sitemap_index.xml\n",
+	})
+	llmParser := &countingListParser{}
+
+	discovery, err := Discover(context.Background(), server.Client(), server.URL+"/blog/", llmParser)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(discovery.Sitemaps).To(gomega.Equal([]string{"https://example.com/sitemap_index.xml"}))
+
+	name := ProposeSourceName(&url.URL{Host: server.Listener.Addr().String()})
+	sources := make([]CollectorSource, 0)
+	counts := make([]int, 0)
+	for _, c := range discovery.Candidates {
+		g.Expect(c.Err).ToNot(gomega.HaveOccurred())
+		sources = append(sources, c.Source)
+		counts = append(counts, len(c.Posts))
+	}
+	g.Expect(sources).To(gomega.Equal([]CollectorSource{
+		{Name: name, URL: server.URL + "/blog/index.xml", Type: SourceTypeFeed},
+		{Name: name, URL: server.URL + "/blog/archive/"},
+		{Name: name, URL: server.URL + "/blog/"},
+	}))
+	g.Expect(counts).To(gomega.Equal([]int{1, 2, 1}))
+	g.Expect(discovery.Proposed()).To(gomega.Equal(discovery.Candidates[0]))
+	g.Expect(llmParser.urls).To(gomega.ConsistOf(server.URL+"/blog/", server.URL+"/blog/archive/"))
+
+	// The list pages are not parsed without llm
+	discovery, err = Discover(context.Background(), server.Client(), server.URL+"/blog/archive/", nil)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(discovery.Proposed()).To(gomega.BeNil())
+
+	_, err = Discover(context.Background(), server.Client(), "example.com", nil)
+	g.Expect(err).To(gomega.MatchError(errHomepageInvalid))
+	_, err = Discover(context.Background(), server.Client(), server.URL+"/missing", nil)
+	g.Expect(err).To(gomega.MatchError(errUnexpectedPage))
+}
+
+func TestProposeSourceName(t *testing.T) {
+	g := gomega.NewWithT(t)
+	for u, name := range map[string]string{
+		"https://brooker.co.za/blog/":          "brooker",
+		"https://engineering.fb.com/":          "engineeringfb",
+		"https://www.brendangregg.com/blog/":   "brendangregg",
+		"https://simonwillison.net/":           "simonwillison",
+		"https://blog.allegro.tech/":           "blogallegro",
+		"https://jack-vanlightly.com/":         "jackvanlightly",
+		"https://muratbuffalo.blogspot.com/":   "muratbuffalo",
+		"https://siddharthbharath.com:8443/x/": "siddharthbharath",
+	} {
+		parsed, err := url.Parse(u)
+		g.Expect(err).ToNot(gomega.HaveOccurred())
+		g.Expect(ProposeSourceName(parsed)).To(gomega.Equal(name), u)
+	}
+}
+
+func TestAddSource(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dir := t.TempDir()
+	src := CollectorSource{Name: "example", URL: "https://example.com/feed.xml?a=1&b=2", Type: SourceTypeFeed}
+
+	// The missing file is created
+	sourcesFile := filepath.Join(dir, "collectors.json")
+	g.Expect(AddSource(sourcesFile, src, nil)).To(gomega.Succeed())
+	b, err := os.ReadFile(sourcesFile)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(string(b)).To(gomega.Equal(`[
+  {
+    "name": "example",
+    "url": "https://example.com/feed.xml?a=1&b=2",
+    "type": "feed"
+  }
+]
+`))
+	g.Expect(AddSource(sourcesFile, src, nil)).To(gomega.MatchError(ErrDuplicateCollector))
+
+	// The sources of map are appended, and the names of includes are checked
+	sourcesFile = writeSourcesFile(g, dir, "team.json", `{"include":["collectors.json"],"sources":[]}`)
+	g.Expect(AddSource(sourcesFile, src, nil)).To(gomega.MatchError(ErrDuplicateCollector))
+	src.Name = "other"
+	g.Expect(AddSource(sourcesFile, src, nil)).To(gomega.Succeed())
+	sources, err := ReadSources(sourcesFile)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(sources).To(gomega.HaveLen(2))
+	g.Expect(sources[0].Name).To(gomega.Equal("other"))
+
+	g.Expect(AddSource(sourcesFile, CollectorSource{Name: "x", URL: "example.com"}, stubListParser{})).
+		To(gomega.MatchError(errURLMustBeAbsolute))
+	g.Expect(AddSource(sourcesFile, CollectorSource{Name: "x", URL: "https://example.com/"}, nil)).
+		To(gomega.MatchError(errListParserUnavailable))
+	g.Expect(AddSource(filepath.Join(dir, "collectors.yaml"), src, nil)).To(gomega.MatchError(errSourcesFormat))
+}
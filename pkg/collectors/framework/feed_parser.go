@@ -0,0 +1,164 @@
+package framework
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+	"github.com/anyvoxel/vela/pkg/collectors"
+)
+
+var errNotFeed = errors.New("not a rss or atom feed")
+
+// feedDateLayouts are the layouts of the dates in RSS and Atom feeds.
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02",
+}
+
+// feedDocument is the RSS 2.0, RSS 1.0 (RDF) or Atom feed.
+type feedDocument struct {
+	XMLName xml.Name
+	// Channel is the channel of RSS 2.0, the items of RSS 1.0 are the
+	// siblings of channel
+	Channel struct {
+		Items []feedItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []feedItem  `xml:"item"`
+	Entries []feedEntry `xml:"entry"`
+}
+
+type feedItem struct {
+	Title   string `xml:"title"`
+	Link    string `xml:"link"`
+	GUID    string `xml:"guid"`
+	PubDate string `xml:"pubDate"`
+	// Date is the dc:date of RSS 1.0
+	Date string `xml:"date"`
+}
+
+type feedEntry struct {
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+}
+
+// feedListParser implement collectors.ListParser for the RSS and Atom feeds.
+type feedListParser struct{}
+
+var _ collectors.ListParser = feedListParser{}
+
+// ParseList implement collectors.ListParser, the links are resolved against
+// the feed url.
+func (feedListParser) ParseList(_ context.Context, body, baseURL, domain string) ([]apitypes.Post, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if !base.IsAbs() {
+		return nil, fmt.Errorf("%w: %q", errURLMustBeAbsolute, baseURL)
+	}
+
+	var doc feedDocument
+	decoder := xml.NewDecoder(strings.NewReader(body))
+	// The charset is converted by the fetcher
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	err = decoder.Decode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errNotFeed, err)
+	}
+	switch doc.XMLName.Local {
+	case "rss", "RDF", "feed":
+	default:
+		return nil, fmt.Errorf("%w: root element is %q", errNotFeed, doc.XMLName.Local)
+	}
+
+	items := slices.Concat(doc.Channel.Items, doc.Items)
+	feed := &feedPosts{base: base, domain: domain, seen: make(map[string]struct{})}
+	for _, item := range items {
+		link := item.Link
+		if link == "" {
+			// The guid is the permalink if it's a url
+			link = item.GUID
+		}
+		feed.add(item.Title, link, item.PubDate, item.Date)
+	}
+	for _, entry := range doc.Entries {
+		link := ""
+		for _, l := range entry.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = l.Href
+				break
+			}
+		}
+		feed.add(entry.Title, link, entry.Published, entry.Updated)
+	}
+	return feed.posts, nil
+}
+
+// feedPosts are the posts of feed, the duplicated links are dropped.
+type feedPosts struct {
+	base   *url.URL
+	domain string
+	seen   map[string]struct{}
+	posts  []apitypes.Post
+}
+
+// add the post of feed item if its link is a http url, the first valid date
+// is the publish date.
+func (f *feedPosts) add(title, link string, dates ...string) {
+	ref, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return
+	}
+	postURL := f.base.ResolveReference(ref)
+	if postURL.Scheme != "http" && postURL.Scheme != "https" {
+		return
+	}
+	postURL.Fragment = ""
+	if _, ok := f.seen[postURL.String()]; ok {
+		return
+	}
+	f.seen[postURL.String()] = struct{}{}
+
+	post := apitypes.Post{
+		Domain: f.domain,
+		Title:  normalizeText(title),
+		Path:   postURL.String(),
+	}
+	for _, date := range dates {
+		if post.PublishedAt = parseFeedDate(date); !post.PublishedAt.IsZero() {
+			break
+		}
+	}
+	f.posts = append(f.posts, post)
+}
+
+func parseFeedDate(value string) time.Time {
+	value = normalizeText(value)
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,76 @@
+package framework
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+)
+
+func TestFeedListParser_ParseList(t *testing.T) {
+	g := gomega.NewWithT(t)
+	published := time.Date(2025, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	for name, feed := range map[string]string{
+		"rss": `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>Example</title>
+  <item><title>Snapshotting the Raft log</title><link>/blog/raft-snapshots.html</link>
+    <pubDate>Thu, 01 May 2025 08:00:00 +0000</pubDate></item>
+  <item><title> Leases </title><guid>https://example.com/blog/leases.html</guid></item>
+  <item><title>Duplicated</title><link>https://example.com/blog/leases.html#top</link></item>
+  <item><title>Mail</title><link>mailto:blog@example.com</link></item>
+</channel></rss>`,
+		"atom": `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom"><title>Example</title>
+  <entry><title>Snapshotting the Raft log</title>
+    <link rel="self" href="https://example.com/feed/1"/>
+    <link rel="alternate" href="https://example.com/blog/raft-snapshots.html"/>
+    <published>2025-05-01T08:00:00Z</published><updated>2025-06-01T08:00:00Z</updated></entry>
+  <entry><title>Leases</title><link href="leases.html"/></entry>
+</feed>`,
+		"rdf": `<?xml version="1.0" encoding="ISO-8859-1"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/"
+  xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel><title>Example</title></channel>
+  <item><title>Snapshotting the Raft log</title><link>https://example.com/blog/raft-snapshots.html</link>
+    <dc:date>2025-05-01T08:00:00Z</dc:date></item>
+  <item><title>Leases</title><link>https://example.com/blog/leases.html</link></item>
+</rdf:RDF>`,
+	} {
+		posts, err := feedListParser{}.ParseList(context.Background(), feed, "https://example.com/blog/", "example")
+		g.Expect(err).ToNot(gomega.HaveOccurred(), name)
+		g.Expect(posts).To(gomega.Equal([]apitypes.Post{
+			{
+				Domain:      "example",
+				Title:       "Snapshotting the Raft log",
+				Path:        "https://example.com/blog/raft-snapshots.html",
+				PublishedAt: published,
+			},
+			{Domain: "example", Title: "Leases", Path: "https://example.com/blog/leases.html"},
+		}), name)
+	}
+
+	_, err := feedListParser{}.ParseList(context.Background(), "<html><body></body></html>", "https://example.com/", "x")
+	g.Expect(err).To(gomega.MatchError(errNotFeed))
+	_, err = feedListParser{}.ParseList(context.Background(), "<!DOCTYPE html><p>x", "https://example.com/", "x")
+	g.Expect(err).To(gomega.MatchError(errNotFeed))
+}
+
+func TestSourceListParser_Type(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	p, err := SourceListParser(CollectorSource{Type: SourceTypeFeed}, nil)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(p).To(gomega.Equal(feedListParser{}))
+	p, err = SourceListParser(CollectorSource{Type: SourceTypeList}, stubListParser{})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(p).To(gomega.Equal(stubListParser{}))
+
+	_, err = SourceListParser(CollectorSource{Type: SourceTypeFeed, Selectors: &ListSelectors{Item: "li"}}, nil)
+	g.Expect(err).To(gomega.MatchError(errSelectorsUnsupported))
+	_, err = SourceListParser(CollectorSource{Type: "rss"}, stubListParser{})
+	g.Expect(err).To(gomega.MatchError(errSourceTypeUnknown))
+}
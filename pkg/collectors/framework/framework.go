@@ -3,14 +3,15 @@ package framework
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
-	"os"
+	"maps"
 	"reflect"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/anyvoxel/airmid/anvil"
 	airapp "github.com/anyvoxel/airmid/app"
@@ -19,6 +20,7 @@ import (
 
 	"github.com/anyvoxel/vela/pkg/apitypes"
 	"github.com/anyvoxel/vela/pkg/collectors"
+	"github.com/anyvoxel/vela/pkg/fetch"
 )
 
 func init() {
@@ -42,12 +44,23 @@ var (
 type Framework struct {
 	cs []collectors.Collector `airmid:"autowire:?"`
 
-	// sourcesFile is a path to a JSON file that contains an array of CollectorSource.
+	// sourcesFile is a path to a JSON, YAML or TOML file that contains the CollectorSource, see ReadSources.
 	// Example file content:
 	//  [{"name":"example","url":"https://example.com/archive","headers":{"User-Agent":"..."}}]
 	sourcesFile string `airmid:"value:${vela.collectors.sources_file:=./collectors.json}"`
+	// reloadInterval is the interval to poll the sources file by Watch, it's
+	// disabled if it's not positive
+	reloadInterval time.Duration `airmid:"value:${vela.collectors.reload_interval:=10s}"`
 
 	listParser collectors.ListParser `airmid:"autowire:?"`
+	policy     *fetch.Policy         `airmid:"autowire:vela.fetch.policy"`
+
+	// mu guards the configured collectors and their sources, they are
+	// replaced by Reload
+	mu         sync.RWMutex
+	configured []collectors.Collector
+	sources    []CollectorSource
+	secrets    map[string]string
 }
 
 // NewFramework creates a new Framework with the given collectors.
@@ -58,44 +71,206 @@ func NewFramework(cs []collectors.Collector) *Framework {
 
 // AfterPropertiesSet implements ioc.InitializingBean.
 func (f *Framework) AfterPropertiesSet(_ context.Context) error {
-	if err := f.appendConfiguredCollectors(); err != nil {
+	loaded, err := f.loadSources()
+	if err != nil {
 		return err
 	}
-	return f.ensureUniqueCollectorNames()
+	f.apply(loaded)
+	return nil
+}
+
+// loadedSources are the collectors of the sources file which are validated
+// but not applied yet.
+type loadedSources struct {
+	sources    []CollectorSource
+	collectors []collectors.Collector
+	// secrets are the digests of the resolved header secrets of sources, the
+	// source is updated if its secret is changed, e.g. ${env:NAME}
+	secrets map[string]string
+	// registers register the transports of sources, and unregister the
+	// transports of removed sources
+	registers []func()
 }
 
-func (f *Framework) appendConfiguredCollectors() error {
+// loadSources read and validate the sources file, the framework is not
+// changed.
+func (f *Framework) loadSources() (*loadedSources, error) {
+	loaded := &loadedSources{secrets: make(map[string]string)}
 	filePath := strings.TrimSpace(f.sourcesFile)
-	if filePath == "" {
-		return nil
+	if filePath != "" {
+		entries, err := readSources(filePath, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			err = loaded.add(entry, f.listParser, f.policy)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	names := make(map[string]struct{}, len(loaded.sources))
+	for _, src := range loaded.sources {
+		names[src.Name] = struct{}{}
 	}
-	if f.listParser == nil {
-		return errListParserUnavailable
+	f.mu.RLock()
+	for _, src := range f.sources {
+		if _, ok := names[src.Name]; !ok {
+			register, _ := f.policy.Prepare(src.Name, src.URL, nil)
+			loaded.registers = append(loaded.registers, register)
+		}
 	}
+	f.mu.RUnlock()
 
-	b, err := os.ReadFile(filePath)
+	err := ensureUniqueCollectorNames(f.cs, loaded.collectors)
 	if err != nil {
-		return fmt.Errorf("read vela.collectors.sources_file %q failed: %w", filePath, err)
+		return nil, err
 	}
+	return loaded, nil
+}
 
-	var sources []CollectorSource
-	if err := json.Unmarshal(b, &sources); err != nil {
-		return fmt.Errorf("invalid sources file %q: %w", filePath, err)
+func (l *loadedSources) add(entry *sourceEntry, listParser collectors.ListParser, policy *fetch.Policy) error {
+	c, err := newSourceCollector(entry.CollectorSource, listParser, policy)
+	if err != nil {
+		return fmt.Errorf("invalid sources file %s: %w", entry.position(), err)
 	}
-	for i, src := range sources {
-		cc, err := newConfiguredCollector(src, f.listParser)
-		if err != nil {
-			return fmt.Errorf("invalid sources file %q item[%d]: %w", filePath, i, err)
+	register, err := policy.Prepare(c.Name(), sourceURL(entry.CollectorSource), entry.Transport)
+	if err != nil {
+		return fmt.Errorf("invalid sources file %s transport: %w", entry.position(), err)
+	}
+	secrets, err := secretsDigest(entry.Headers)
+	if err != nil {
+		return fmt.Errorf("invalid sources file %s: %w", entry.position(), err)
+	}
+
+	src := entry.CollectorSource
+	src.Name = c.Name()
+	l.sources = append(l.sources, src)
+	l.collectors = append(l.collectors, c)
+	l.secrets[src.Name] = secrets
+	l.registers = append(l.registers, register)
+	return nil
+}
+
+// apply replace the configured collectors by the loaded ones.
+func (f *Framework) apply(loaded *loadedSources) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, register := range loaded.registers {
+		register()
+	}
+	f.configured = loaded.collectors
+	f.sources = loaded.sources
+	f.secrets = loaded.secrets
+}
+
+// Reload read the sources file again, and replace the configured collectors
+// if it or the resolved header secrets are changed. The previous collectors are kept if it's invalid, and the
+// running collectors are not interrupted.
+func (f *Framework) Reload(ctx context.Context) error {
+	loaded, err := f.loadSources()
+	if err != nil {
+		return err
+	}
+
+	f.mu.RLock()
+	previous := make(map[string]CollectorSource, len(f.sources))
+	for _, src := range f.sources {
+		previous[src.Name] = src
+	}
+	previousSecrets := f.secrets
+	f.mu.RUnlock()
+
+	var added, updated []string
+	for _, src := range loaded.sources {
+		prev, ok := previous[src.Name]
+		switch {
+		case !ok:
+			added = append(added, src.Name)
+		case !reflect.DeepEqual(prev, src) || previousSecrets[src.Name] != loaded.secrets[src.Name]:
+			updated = append(updated, src.Name)
+		}
+		delete(previous, src.Name)
+	}
+	removed := slices.Sorted(maps.Keys(previous))
+	if len(added) == 0 && len(updated) == 0 && len(removed) == 0 {
+		return nil
+	}
+
+	f.apply(loaded)
+	slogctx.FromCtx(ctx).InfoContext(ctx, "reload sources file",
+		slog.String("File", f.sourcesFile),
+		slog.Any("Added", added),
+		slog.Any("Updated", updated),
+		slog.Any("Removed", removed),
+	)
+	return nil
+}
+
+// Watch poll the sources file every vela.collectors.reload_interval and
+// reload it until the context is done, the invalid change is logged once.
+// The collectors which implement Watcher are watched too.
+func (f *Framework) Watch(ctx context.Context) {
+	for _, c := range f.cs {
+		if w, ok := c.(collectors.Watcher); ok {
+			go w.Watch(ctx)
+		}
+	}
+
+	if strings.TrimSpace(f.sourcesFile) == "" || f.reloadInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(f.reloadInterval)
+	defer ticker.Stop()
+	lastErr := ""
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := f.Reload(ctx)
+		if err == nil {
+			lastErr = ""
+			continue
+		}
+		if err.Error() != lastErr {
+			lastErr = err.Error()
+			slogctx.FromCtx(ctx).ErrorContext(ctx, "reload sources file failed, keep the previous sources",
+				slog.String("File", f.sourcesFile),
+				slog.Any("Error", err),
+			)
+		}
+	}
+}
+
+// collectors return the snapshot of all collectors.
+func (f *Framework) collectors() []collectors.Collector {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return slices.Concat(f.cs, f.configured)
+}
+
+// SourceModels return the chat models of the configured source, it's used
+// when the post is summarized again.
+func (f *Framework) SourceModels(name string) []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, src := range f.sources {
+		if src.Name == name {
+			return src.Models
 		}
-		f.cs = append(f.cs, cc)
 	}
 	return nil
 }
 
-func (f *Framework) ensureUniqueCollectorNames() error {
+func ensureUniqueCollectorNames(cs ...[]collectors.Collector) error {
 	// ensure all collector names are unique
 	names := make(map[string]struct{})
-	for _, c := range f.cs {
+	for _, c := range slices.Concat(cs...) {
 		if _, ok := names[c.Name()]; ok {
 			return fmt.Errorf("%w: %s", ErrDuplicateCollector, c.Name())
 		}
@@ -106,11 +281,13 @@ func (f *Framework) ensureUniqueCollectorNames() error {
 
 // Start will collector post from all domain.
 func (f *Framework) Start(ctx context.Context, ch chan<- apitypes.Post) error {
+	// The reloaded collectors are used by the next start
+	cs := f.collectors()
 	slogctx.FromCtx(ctx).InfoContext(ctx, "start to process collector",
-		slog.Int("CollectorCount", len(f.cs)),
+		slog.Int("CollectorCount", len(cs)),
 	)
 
-	for _, c := range f.cs {
+	for _, c := range cs {
 		err := c.Initialize(ctx)
 		if err != nil {
 			return err
@@ -118,7 +295,7 @@ func (f *Framework) Start(ctx context.Context, ch chan<- apitypes.Post) error {
 	}
 
 	var wg sync.WaitGroup
-	for _, c := range f.cs {
+	for _, c := range cs {
 		wg.Add(2)
 		cch := make(chan apitypes.Post, 10)
 
@@ -141,7 +318,10 @@ func (f *Framework) Start(ctx context.Context, ch chan<- apitypes.Post) error {
 			defer wg.Done()
 
 			for post := range cch {
-				post.Domain = c.Name()
+				// The manual collector derives the domain from the url
+				if post.Domain == "" || post.Source != apitypes.SourceManual {
+					post.Domain = c.Name()
+				}
 				ch <- post
 			}
 		}(c)
@@ -1,14 +1,25 @@
 package framework
 
 import (
+	"bytes"
 	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/onsi/gomega"
+	slogctx "github.com/veqryn/slog-context"
 	"go.uber.org/mock/gomock"
 
+	"github.com/anyvoxel/vela/pkg/apitypes"
 	"github.com/anyvoxel/vela/pkg/collectors"
 	"github.com/anyvoxel/vela/pkg/collectors/mocks"
+	"github.com/anyvoxel/vela/pkg/fetch"
+	"github.com/anyvoxel/vela/test"
 )
 
 func TestFramework_AfterPropertiesSet_DuplicateCollectorName(t *testing.T) {
@@ -67,3 +78,240 @@ func TestFramework_AfterPropertiesSet_NoDuplicateCollectorName(t *testing.T) {
 	// Assert that no error is returned
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 }
+
+type stubListParser struct{}
+
+func (stubListParser) ParseList(_ context.Context, _, _, _ string) ([]apitypes.Post, error) {
+	return nil, nil
+}
+
+func TestFramework_SourceModels(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	sourcesFile := filepath.Join(t.TempDir(), "collectors.json")
+	err := os.WriteFile(sourcesFile, []byte(`[
+		{"name":"googlepubs","url":"https://research.google/pubs/","models":["LONG_CONTEXT"]},
+		{"name":"brooker","url":"https://brooker.co.za/blog/"}
+	]`), 0o600)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	framework := &Framework{sourcesFile: sourcesFile, listParser: stubListParser{}}
+	err = framework.AfterPropertiesSet(context.Background())
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	g.Expect(framework.SourceModels("googlepubs")).To(gomega.Equal([]string{"LONG_CONTEXT"}))
+	g.Expect(framework.SourceModels("brooker")).To(gomega.BeEmpty())
+	g.Expect(framework.SourceModels("unknown")).To(gomega.BeEmpty())
+}
+
+type recordingListParser struct {
+	html    string
+	baseURL string
+}
+
+func (p *recordingListParser) ParseList(_ context.Context, html, baseURL, domain string) ([]apitypes.Post, error) {
+	p.html = html
+	p.baseURL = baseURL
+	return []apitypes.Post{{Domain: domain, Path: baseURL + "2025/05/raft-snapshots.html"}}, nil
+}
+
+func TestConfiguredCollector_Replay(t *testing.T) {
+	g := gomega.NewWithT(t)
+	rec := test.NewRecorder(t, "configured_collector")
+
+	listParser := &recordingListParser{}
+	c, err := newConfiguredCollector(CollectorSource{
+		Name:   "example",
+		URL:    "https://example.com/blog/",
+		Models: []string{"LONG_CONTEXT"},
+	}, listParser)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	c.transport = rec
+
+	ch := make(chan apitypes.Post, 10)
+	err = c.Start(context.Background(), ch)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	close(ch)
+
+	g.Expect(listParser.baseURL).To(gomega.Equal("https://example.com/blog/"))
+	g.Expect(listParser.html).To(gomega.ContainSubstring("Snapshotting the Raft log"))
+	posts := make([]apitypes.Post, 0)
+	for post := range ch {
+		posts = append(posts, post)
+	}
+	g.Expect(posts).To(gomega.Equal([]apitypes.Post{{
+		Domain: "example",
+		Path:   "https://example.com/blog/2025/05/raft-snapshots.html",
+		Models: []string{"LONG_CONTEXT"},
+	}}))
+}
+
+func TestFramework_Reload(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dir := t.TempDir()
+	sourcesFile := writeSourcesFile(g, dir, "collectors.yaml", `
+- name: brooker
+  url: https://brooker.co.za/blog/
+- name: wiki
+  url: https://wiki.corp/blog/
+  transport: {cookie_jar: `+filepath.Join(dir, "jar.json")+`}
+`)
+	policy := &fetch.Policy{}
+	g.Expect(policy.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+	framework := &Framework{sourcesFile: sourcesFile, listParser: stubListParser{}, policy: policy}
+	g.Expect(framework.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+	running := framework.collectors()
+	g.Expect(policy.Jar("wiki")).ToNot(gomega.BeNil())
+
+	// The unchanged file is not reloaded
+	g.Expect(framework.Reload(context.Background())).To(gomega.Succeed())
+	g.Expect(framework.collectors()).To(gomega.Equal(running))
+
+	writeSourcesFile(g, dir, "collectors.yaml", `
+- name: brooker
+  url: https://brooker.co.za/blog/
+  models: [LONG_CONTEXT]
+- name: arxiv
+  url: https://arxiv.org/list/cs.DC/recent
+`)
+	g.Expect(framework.Reload(context.Background())).To(gomega.Succeed())
+	g.Expect(collectorNames(framework.collectors())).To(gomega.Equal([]string{"brooker", "arxiv"}))
+	g.Expect(framework.SourceModels("brooker")).To(gomega.Equal([]string{"LONG_CONTEXT"}))
+	g.Expect(policy.Jar("wiki")).To(gomega.BeNil())
+	// The running collectors are not changed
+	g.Expect(collectorNames(running)).To(gomega.Equal([]string{"brooker", "wiki"}))
+
+	// The invalid change keeps the previous collectors
+	for _, content := range []string{
+		"- name: brooker\n  url: brooker.co.za/blog/\n",
+		"- name: brooker\n  url: https://brooker.co.za/blog/\n- name: brooker\n  url: https://brooker.co.za/\n",
+		"- name: brooker\n  url: https://brooker.co.za/blog/\n  transport: {proxy: proxy.corp}\n",
+		"- name: brooker\n  urls: https://brooker.co.za/blog/\n",
+	} {
+		writeSourcesFile(g, dir, "collectors.yaml", content)
+		g.Expect(framework.Reload(context.Background())).ToNot(gomega.Succeed(), content)
+		g.Expect(collectorNames(framework.collectors())).To(gomega.Equal([]string{"brooker", "arxiv"}))
+		g.Expect(framework.SourceModels("brooker")).To(gomega.Equal([]string{"LONG_CONTEXT"}))
+	}
+}
+
+func TestFramework_ReloadSecrets(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	g.Expect(os.WriteFile(tokenFile, []byte("old-token\n"), 0o600)).To(gomega.Succeed())
+	sourcesFile := writeSourcesFile(g, dir, "collectors.yaml", `
+- name: brooker
+  url: https://brooker.co.za/blog/
+- name: wiki
+  url: https://wiki.corp/blog/
+  headers: {Authorization: "Bearer ${file:`+tokenFile+`}"}
+`)
+	framework := &Framework{sourcesFile: sourcesFile, listParser: stubListParser{}}
+	g.Expect(framework.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+	running := framework.collectors()
+
+	// The unchanged secret is not reloaded
+	g.Expect(framework.Reload(context.Background())).To(gomega.Succeed())
+	g.Expect(framework.collectors()).To(gomega.Equal(running))
+
+	g.Expect(os.WriteFile(tokenFile, []byte("new-token\n"), 0o600)).To(gomega.Succeed())
+	var buf bytes.Buffer
+	ctx := slogctx.NewCtx(context.Background(), slog.New(slog.NewTextHandler(&buf, nil)))
+	g.Expect(framework.Reload(ctx)).To(gomega.Succeed())
+	g.Expect(buf.String()).To(gomega.ContainSubstring("Updated=[wiki]"))
+	g.Expect(buf.String()).ToNot(gomega.ContainSubstring("new-token"))
+	wiki, ok := framework.collectors()[1].(*configuredCollector)
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(wiki.header.Get("Authorization")).To(gomega.Equal("Bearer new-token"))
+
+	// The missing secret keeps the previous collectors
+	g.Expect(os.Remove(tokenFile)).To(gomega.Succeed())
+	g.Expect(framework.Reload(context.Background())).To(gomega.MatchError(errSecretMissing))
+	g.Expect(framework.collectors()[1]).To(gomega.BeIdenticalTo(wiki))
+}
+
+type lockedBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+func TestFramework_Watch(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dir := t.TempDir()
+	sourcesFile := writeSourcesFile(g, dir, "collectors.yaml", `
+- name: brooker
+  url: https://brooker.co.za/blog/
+`)
+	framework := &Framework{sourcesFile: sourcesFile, reloadInterval: 10 * time.Millisecond, listParser: stubListParser{}}
+	g.Expect(framework.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+
+	logs := &lockedBuffer{}
+	ctx, cancel := context.WithCancel(slogctx.NewCtx(context.Background(), slog.New(slog.NewJSONHandler(logs, nil))))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		framework.Watch(ctx)
+	}()
+
+	writeSourcesFile(g, dir, "collectors.yaml", "- name: brooker\n  url: brooker.co.za/blog/\n")
+	g.Eventually(logs.String).Should(gomega.ContainSubstring("reload sources file failed"))
+	time.Sleep(50 * time.Millisecond)
+	g.Expect(strings.Count(logs.String(), "reload sources file failed")).To(gomega.Equal(1))
+
+	writeSourcesFile(g, dir, "collectors.yaml", "- name: brooker\n  url: https://brooker.co.za/blog/\n  models: [LONG]\n")
+	g.Eventually(func() []string {
+		return framework.SourceModels("brooker")
+	}).Should(gomega.Equal([]string{"LONG"}))
+	g.Expect(logs.String()).To(gomega.ContainSubstring(`"Updated":["brooker"]`))
+
+	cancel()
+	g.Eventually(done).Should(gomega.BeClosed())
+}
+
+// watchingCollector is a collector which records the Watch.
+type watchingCollector struct {
+	collectors.Collector
+
+	watched chan struct{}
+}
+
+func (c *watchingCollector) Watch(ctx context.Context) {
+	close(c.watched)
+	<-ctx.Done()
+}
+
+func TestFramework_Watch_Collectors(t *testing.T) {
+	g := gomega.NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	c := &watchingCollector{Collector: mocks.NewMockCollector(mockCtrl), watched: make(chan struct{})}
+	framework := NewFramework([]collectors.Collector{c})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The collectors are watched even if the sources file is disabled
+	framework.Watch(ctx)
+	g.Eventually(c.watched).Should(gomega.BeClosed())
+}
+
+func collectorNames(cs []collectors.Collector) []string {
+	names := make([]string, 0, len(cs))
+	for _, c := range cs {
+		names = append(names, c.Name())
+	}
+	return names
+}
@@ -0,0 +1,108 @@
+package framework
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// redacted replaces the header values which contain secrets in logs.
+const redacted = "[REDACTED]"
+
+var (
+	errSecretMissing = errors.New("secret is missing")
+
+	// secretRefPattern matches the ${env:NAME} and ${file:/path} references
+	secretRefPattern = regexp.MustCompile(`\$\{(env|file):([^}]*)\}`)
+)
+
+// resolveSecretRefs replace the ${env:NAME} and ${file:/path} references in
+// value, e.g. "Bearer ${env:WIKI_TOKEN}". It reports whether value contains
+// any reference, the missing or empty secret is an error instead of being
+// replaced by an empty string.
+func resolveSecretRefs(value string) (string, bool, error) {
+	var errs []error
+	resolved := secretRefPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		m := secretRefPattern.FindStringSubmatch(ref)
+		secret, err := readSecret(m[1], strings.TrimSpace(m[2]))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return secret
+	})
+	if len(errs) > 0 {
+		return "", true, errors.Join(errs...)
+	}
+	return resolved, resolved != value, nil
+}
+
+func readSecret(kind, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("%w: ${%s:} has no name", errSecretMissing, kind)
+	}
+
+	switch kind {
+	case "env":
+		secret, ok := os.LookupEnv(name)
+		if !ok || secret == "" {
+			return "", fmt.Errorf("%w: env %s is not set", errSecretMissing, name)
+		}
+		return secret, nil
+	default:
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return "", fmt.Errorf("%w: read file %s failed: %w", errSecretMissing, name, err)
+		}
+		// The trailing newline of secret file is not a part of secret
+		secret := strings.TrimRight(string(b), "\r\n")
+		if secret == "" {
+			return "", fmt.Errorf("%w: file %s is empty", errSecretMissing, name)
+		}
+		return secret, nil
+	}
+}
+
+// secretsDigest return the sha256 of the resolved secret headers, it's empty
+// if there is no secret.
+func secretsDigest(headers map[string]string) (string, error) {
+	hdr, secretHeaders, err := resolveHeaders(headers)
+	if err != nil || len(secretHeaders) == 0 {
+		return "", err
+	}
+
+	h := sha256.New()
+	for _, k := range slices.Sorted(slices.Values(secretHeaders)) {
+		_, _ = fmt.Fprintf(h, "%s: %s\n", k, hdr.Get(k))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// redactedHeader implement slog.LogValuer, the values of secret headers are
+// redacted.
+type redactedHeader struct {
+	header  http.Header
+	secrets []string
+}
+
+var _ slog.LogValuer = redactedHeader{}
+
+// LogValue implement slog.LogValuer
+func (h redactedHeader) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(h.header))
+	for _, k := range slices.Sorted(maps.Keys(h.header)) {
+		v := strings.Join(h.header.Values(k), ", ")
+		if slices.Contains(h.secrets, k) {
+			v = redacted
+		}
+		attrs = append(attrs, slog.String(k, v))
+	}
+	return slog.GroupValue(attrs...)
+}
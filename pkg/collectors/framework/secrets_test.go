@@ -0,0 +1,87 @@
+package framework
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onsi/gomega"
+	slogctx "github.com/veqryn/slog-context"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+)
+
+func TestResolveSecretRefs(t *testing.T) {
+	g := gomega.NewWithT(t)
+	t.Setenv("VELA_TEST_TOKEN", "token")
+	t.Setenv("VELA_TEST_EMPTY", "")
+	secretFile := filepath.Join(t.TempDir(), "cookie")
+	g.Expect(os.WriteFile(secretFile, []byte("session=s1\n"), 0o600)).To(gomega.Succeed())
+
+	v, secret, err := resolveSecretRefs("Bearer ${env:VELA_TEST_TOKEN}")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(secret).To(gomega.BeTrue())
+	g.Expect(v).To(gomega.Equal("Bearer token"))
+
+	v, secret, err = resolveSecretRefs("${file:" + secretFile + "}; theme=dark")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(secret).To(gomega.BeTrue())
+	g.Expect(v).To(gomega.Equal("session=s1; theme=dark"))
+
+	v, secret, err = resolveSecretRefs("en-US, ${other:x}")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(secret).To(gomega.BeFalse())
+	g.Expect(v).To(gomega.Equal("en-US, ${other:x}"))
+
+	for _, value := range []string{
+		"${env:VELA_TEST_MISSING}",
+		"${env:VELA_TEST_EMPTY}",
+		"${env:}",
+		"${file:" + secretFile + ".missing}",
+	} {
+		_, _, err = resolveSecretRefs(value)
+		g.Expect(err).To(gomega.MatchError(errSecretMissing), value)
+	}
+	_, _, err = resolveSecretRefs("${env:VELA_TEST_MISSING}")
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("env VELA_TEST_MISSING is not set")))
+}
+
+func TestConfiguredCollector_SecretHeaders(t *testing.T) {
+	g := gomega.NewWithT(t)
+	t.Setenv("VELA_TEST_COOKIE", "session=s1")
+
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	_, err := newConfiguredCollector(CollectorSource{
+		Name:    "substack",
+		URL:     server.URL,
+		Headers: map[string]string{"cookie": "${env:VELA_TEST_MISSING}"},
+	}, stubListParser{})
+	g.Expect(err).To(gomega.MatchError(errSecretMissing))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(`header "cookie"`)))
+
+	c, err := newConfiguredCollector(CollectorSource{
+		Name:    "substack",
+		URL:     server.URL,
+		Headers: map[string]string{"cookie": "${env:VELA_TEST_COOKIE}", "Accept-Language": "en"},
+	}, stubListParser{})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	err = c.Start(slogctx.NewCtx(context.Background(), logger), make(chan apitypes.Post, 1))
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	g.Expect(received.Get("Cookie")).To(gomega.Equal("session=s1"))
+	g.Expect(logs.String()).To(gomega.ContainSubstring(`"Headers":{"Accept-Language":"en","Cookie":"[REDACTED]"}`))
+	g.Expect(logs.String()).ToNot(gomega.ContainSubstring("s1"))
+}
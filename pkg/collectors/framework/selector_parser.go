@@ -0,0 +1,208 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+	"github.com/anyvoxel/vela/pkg/collectors"
+)
+
+const (
+	// SourceTypeList is the html list page, it's parsed by selectors or llm
+	SourceTypeList = "list"
+	// SourceTypeFeed is the RSS or Atom feed
+	SourceTypeFeed = "feed"
+)
+
+var (
+	errItemSelectorEmpty     = errors.New("item selector is empty")
+	errSelectorInvalid       = errors.New("selector is invalid")
+	errSourceTypeUnknown     = errors.New("unknown source type")
+	errSelectorsUnsupported  = errors.New("selectors are not supported by source type")
+	errListParserUnsupported = errors.New("list parser is not supported by source type")
+)
+
+// defaultDateLayouts are tried in order if the date layout is not configured.
+var defaultDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+}
+
+// ListSelectors are the css selectors to parse a list page without llm, e.g.
+//
+//	{"item":"ul.posts li","title":"a","date":".date","date_layout":"January 2, 2006"}
+type ListSelectors struct {
+	// Item selects each post of the list
+	Item string `json:"item" yaml:"item" toml:"item"`
+	// Link selects the link of post in item, it defaults to the first a[href],
+	// or the item itself if it's a link
+	Link string `json:"link,omitempty" yaml:"link,omitempty" toml:"link,omitempty"`
+	// Title selects the title in item, it defaults to the text of link
+	Title string `json:"title,omitempty" yaml:"title,omitempty" toml:"title,omitempty"`
+	// Date selects the publish date in item, the datetime attribute is
+	// preferred to the text
+	Date string `json:"date,omitempty" yaml:"date,omitempty" toml:"date,omitempty"`
+	// DateLayout is the go time layout of date, the common layouts are tried
+	// if it's empty
+	DateLayout string `json:"date_layout,omitempty" yaml:"date_layout,omitempty" toml:"date_layout,omitempty"`
+}
+
+// selectorListParser implement collectors.ListParser by css selectors.
+type selectorListParser struct {
+	selectors ListSelectors
+}
+
+var _ collectors.ListParser = (*selectorListParser)(nil)
+
+func newSelectorListParser(selectors *ListSelectors) (*selectorListParser, error) {
+	if strings.TrimSpace(selectors.Item) == "" {
+		return nil, errItemSelectorEmpty
+	}
+	for _, sel := range []string{selectors.Item, selectors.Link, selectors.Title, selectors.Date} {
+		if sel == "" {
+			continue
+		}
+		_, err := cascadia.Compile(sel)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %w", errSelectorInvalid, sel, err)
+		}
+	}
+	return &selectorListParser{selectors: *selectors}, nil
+}
+
+// SourceListParser return the list parser of source by its type, the list
+// page is parsed by selectors if they are configured, otherwise by llm.
+func SourceListParser(src CollectorSource, llmParser collectors.ListParser) (collectors.ListParser, error) {
+	switch src.Type {
+	case "", SourceTypeList:
+	case SourceTypeFeed:
+		if src.Selectors != nil {
+			return nil, fmt.Errorf("%w: %q", errSelectorsUnsupported, src.Type)
+		}
+		return feedListParser{}, nil
+	case SourceTypeSitemap, SourceTypeArXiv, SourceTypeGitHubReleases, SourceTypeHackerNews:
+		return nil, fmt.Errorf("%w: %q", errListParserUnsupported, src.Type)
+	default:
+		return nil, fmt.Errorf("%w: %q", errSourceTypeUnknown, src.Type)
+	}
+
+	if src.Selectors == nil {
+		if llmParser == nil {
+			return nil, errListParserUnavailable
+		}
+		return llmParser, nil
+	}
+	return newSelectorListParser(src.Selectors)
+}
+
+// ParseList implement collectors.ListParser, the links are resolved against
+// the list page url.
+func (p *selectorListParser) ParseList(_ context.Context, html, baseURL, domain string) ([]apitypes.Post, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if !base.IsAbs() {
+		return nil, fmt.Errorf("%w: %q", errURLMustBeAbsolute, baseURL)
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, err
+	}
+	if href, ok := doc.Find("base[href]").First().Attr("href"); ok {
+		if ref, err := url.Parse(strings.TrimSpace(href)); err == nil {
+			base = base.ResolveReference(ref)
+		}
+	}
+
+	seen := make(map[string]struct{})
+	posts := make([]apitypes.Post, 0)
+	doc.Find(p.selectors.Item).Each(func(_ int, item *goquery.Selection) {
+		post, ok := p.parseItem(item, base)
+		if !ok {
+			return
+		}
+		if _, ok := seen[post.Path]; ok {
+			return
+		}
+		seen[post.Path] = struct{}{}
+		post.Domain = domain
+		posts = append(posts, post)
+	})
+	return posts, nil
+}
+
+func (p *selectorListParser) parseItem(item *goquery.Selection, base *url.URL) (apitypes.Post, bool) {
+	link := item
+	switch {
+	case p.selectors.Link != "":
+		link = item.Find(p.selectors.Link).First()
+	case !item.Is("a[href]"):
+		link = item.Find("a[href]").First()
+	}
+	href, ok := link.Attr("href")
+	if !ok {
+		return apitypes.Post{}, false
+	}
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return apitypes.Post{}, false
+	}
+	postURL := base.ResolveReference(ref)
+	if postURL.Scheme != "http" && postURL.Scheme != "https" {
+		return apitypes.Post{}, false
+	}
+	postURL.Fragment = ""
+
+	title := link
+	if p.selectors.Title != "" {
+		title = item.Find(p.selectors.Title).First()
+	}
+	post := apitypes.Post{
+		Title: normalizeText(title.Text()),
+		Path:  postURL.String(),
+	}
+	if p.selectors.Date != "" {
+		post.PublishedAt = p.parseDate(item.Find(p.selectors.Date).First())
+	}
+	return post, true
+}
+
+func (p *selectorListParser) parseDate(date *goquery.Selection) time.Time {
+	value, ok := date.Attr("datetime")
+	if !ok {
+		value = date.Text()
+	}
+	value = normalizeText(value)
+	if value == "" {
+		return time.Time{}
+	}
+
+	layouts := defaultDateLayouts
+	if p.selectors.DateLayout != "" {
+		layouts = []string{p.selectors.DateLayout}
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// normalizeText collapse the whitespaces of text.
+func normalizeText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,79 @@
+package framework
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+)
+
+func TestSelectorListParser(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	html := `<html><body>
+<ul class="posts">
+  <li><time datetime="2025-05-08">May 8</time> <a href="2025/05/raft-snapshots.html">Snapshotting
+    the Raft log</a></li>
+  <li><span class="date">April 2, 2025</span> <a href="/blog/2025/04/quorum-reads.html#top">Quorum reads</a></li>
+  <li><a href="/blog/2025/04/quorum-reads.html">Quorum reads again</a></li>
+  <li><a href="mailto:blog@example.com">Contact</a></li>
+  <li>No link</li>
+</ul>
+</body></html>`
+
+	p, err := newSelectorListParser(&ListSelectors{Item: "ul.posts li", Date: "time, .date"})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	posts, err := p.ParseList(context.Background(), html, "https://example.com/blog/", "example")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(posts).To(gomega.Equal([]apitypes.Post{
+		{
+			Domain:      "example",
+			Title:       "Snapshotting the Raft log",
+			Path:        "https://example.com/blog/2025/05/raft-snapshots.html",
+			PublishedAt: time.Date(2025, 5, 8, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Domain:      "example",
+			Title:       "Quorum reads",
+			Path:        "https://example.com/blog/2025/04/quorum-reads.html",
+			PublishedAt: time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC),
+		},
+	}))
+
+	// The item itself is the link, and the links are resolved against <base>
+	p, err = newSelectorListParser(&ListSelectors{Item: "a.post", Title: "h2", DateLayout: "2006/01/02", Date: "i"})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	posts, err = p.ParseList(context.Background(),
+		`<head><base href="/archive/"></head><a class="post" href="one"><h2>One</h2><i>2025/01/02</i></a>`,
+		"https://example.com/", "example")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(posts).To(gomega.Equal([]apitypes.Post{{
+		Domain:      "example",
+		Title:       "One",
+		Path:        "https://example.com/archive/one",
+		PublishedAt: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+	}}))
+
+	_, err = newSelectorListParser(&ListSelectors{})
+	g.Expect(err).To(gomega.MatchError(errItemSelectorEmpty))
+	_, err = newSelectorListParser(&ListSelectors{Item: "li[", Title: "a"})
+	g.Expect(err).To(gomega.MatchError(errSelectorInvalid))
+}
+
+func TestSourceListParser(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	p, err := SourceListParser(CollectorSource{Name: "brooker"}, stubListParser{})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(p).To(gomega.Equal(stubListParser{}))
+
+	p, err = SourceListParser(CollectorSource{Name: "brooker", Selectors: &ListSelectors{Item: "li"}}, nil)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(p).To(gomega.BeAssignableToTypeOf(&selectorListParser{}))
+
+	_, err = SourceListParser(CollectorSource{Name: "brooker"}, nil)
+	g.Expect(err).To(gomega.MatchError(errListParserUnavailable))
+}
@@ -0,0 +1,310 @@
+package framework
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	slogctx "github.com/veqryn/slog-context"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+	"github.com/anyvoxel/vela/pkg/collectors"
+	"github.com/anyvoxel/vela/pkg/fetch"
+)
+
+const (
+	// SourceTypeSitemap is the sitemap or sitemap index
+	SourceTypeSitemap = "sitemap"
+
+	// maxSitemapBody is the max size of sitemap by the protocol
+	maxSitemapBody = 50 << 20
+	// maxSitemapDepth is the max depth of the nested sitemap indexes
+	maxSitemapDepth = 3
+	// maxSitemaps is the max number of sitemaps walked in a run
+	maxSitemaps = 1000
+)
+
+var (
+	errNotSitemap       = errors.New("not a sitemap or sitemap index")
+	errSitemapPattern   = errors.New("sitemap pattern is invalid")
+	errSitemapsExceeded = errors.New("too many sitemaps")
+)
+
+// sitemapDateLayouts are the W3C datetime layouts of lastmod.
+var sitemapDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// SitemapOptions filter the urls of the sitemap source, e.g.
+//
+//	{"path_prefix":"/blog/","pattern":"/20[0-9]{2}/"}
+type SitemapOptions struct {
+	// PathPrefix keeps the urls whose path has the prefix
+	PathPrefix string `json:"path_prefix,omitempty" yaml:"path_prefix,omitempty" toml:"path_prefix,omitempty"`
+	// Pattern keeps the urls which match the regexp
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty" toml:"pattern,omitempty"`
+}
+
+// sitemapDocument is the urlset or sitemapindex.
+type sitemapDocument struct {
+	XMLName  xml.Name
+	URLs     []sitemapURL `xml:"url"`
+	Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// sitemapCollector collect all urls of the sitemap, the lastmod is the
+// publish date and the title is filled by the summarizer.
+type sitemapCollector struct {
+	name          string
+	url           string
+	host          string
+	header        http.Header
+	secretHeaders []string
+	models        []string
+	pathPrefix    string
+	pattern       *regexp.Regexp
+	// policy limits the fetching of sitemaps
+	policy *fetch.Policy
+
+	// transport is nil for the default transport, it's used in tests
+	transport http.RoundTripper
+}
+
+var _ collectors.Collector = (*sitemapCollector)(nil)
+
+func newSitemapCollector(src CollectorSource) (*sitemapCollector, error) {
+	name, parsed, err := sourceNameURL(src)
+	if err != nil {
+		return nil, err
+	}
+	if src.Selectors != nil {
+		return nil, fmt.Errorf("%w: %q", errSelectorsUnsupported, src.Type)
+	}
+	hdr, secretHeaders, err := resolveHeaders(src.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &sitemapCollector{
+		name:          name,
+		url:           parsed.String(),
+		host:          siteHost(parsed.Hostname()),
+		header:        hdr,
+		secretHeaders: secretHeaders,
+		models:        src.Models,
+	}
+	if src.Sitemap != nil {
+		c.pathPrefix = src.Sitemap.PathPrefix
+		if src.Sitemap.Pattern != "" {
+			c.pattern, err = regexp.Compile(src.Sitemap.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q: %w", errSitemapPattern, src.Sitemap.Pattern, err)
+			}
+		}
+	}
+	return c, nil
+}
+
+// Name implement collectors.Collector
+func (c *sitemapCollector) Name() string { return c.name }
+
+// Initialize implement collectors.Collector
+func (c *sitemapCollector) Initialize(_ context.Context) error { return nil }
+
+// Start implement collectors.Collector, it walks the sitemap indexes in
+// depth first order.
+func (c *sitemapCollector) Start(ctx context.Context, ch chan<- apitypes.Post) error {
+	client := &http.Client{
+		Transport: c.policy.Transport(c.name, c.transport),
+		Jar:       c.policy.Jar(c.name),
+		Timeout:   time.Minute,
+	}
+	w := &sitemapWalker{collector: c, client: client, seen: make(map[string]struct{})}
+	return w.walk(ctx, c.url, 0, ch)
+}
+
+// keep return whether the url of sitemap should be collected, the urls of
+// the other hosts (e.g. the images on cdn) are dropped.
+func (c *sitemapCollector) keep(u *url.URL) bool {
+	if siteHost(u.Hostname()) != c.host {
+		return false
+	}
+	if c.pathPrefix != "" && !strings.HasPrefix(u.Path, c.pathPrefix) {
+		return false
+	}
+	return c.pattern == nil || c.pattern.MatchString(u.String())
+}
+
+// sitemapWalker is the state of a run of sitemapCollector.
+type sitemapWalker struct {
+	collector *sitemapCollector
+	client    *http.Client
+	sitemaps  int
+	// seen are the urls of posts and sitemaps, they are not emitted or
+	// walked twice
+	seen map[string]struct{}
+}
+
+func (w *sitemapWalker) walk(ctx context.Context, sitemap string, depth int, ch chan<- apitypes.Post) error {
+	if _, ok := w.seen[sitemap]; ok {
+		return nil
+	}
+	w.seen[sitemap] = struct{}{}
+	w.sitemaps++
+	if w.sitemaps > maxSitemaps {
+		return fmt.Errorf("%w: more than %d", errSitemapsExceeded, maxSitemaps)
+	}
+
+	doc, err := w.fetch(ctx, sitemap)
+	if err != nil {
+		return fmt.Errorf("fetch sitemap %q failed: %w", sitemap, err)
+	}
+	base, err := url.Parse(sitemap)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range doc.Sitemaps {
+		if depth+1 >= maxSitemapDepth {
+			slogctx.FromCtx(ctx).WarnContext(ctx, "skip nested sitemap index",
+				slog.String("URL", sitemap),
+				slog.Int("Depth", depth+1),
+			)
+			break
+		}
+		childURL, ok := resolveSitemapURL(base, child.Loc)
+		if !ok {
+			continue
+		}
+		err = w.walk(ctx, childURL.String(), depth+1, ch)
+		if err != nil {
+			// The other sitemaps of index are still collected
+			if errors.Is(err, errSitemapsExceeded) || ctx.Err() != nil {
+				return err
+			}
+			slogctx.FromCtx(ctx).ErrorContext(ctx, "walk sitemap failed",
+				slog.String("URL", childURL.String()),
+				slog.Any("Error", err),
+			)
+		}
+	}
+
+	for _, u := range doc.URLs {
+		postURL, ok := resolveSitemapURL(base, u.Loc)
+		if !ok || !w.collector.keep(postURL) {
+			continue
+		}
+		if _, ok := w.seen[postURL.String()]; ok {
+			continue
+		}
+		w.seen[postURL.String()] = struct{}{}
+
+		post := apitypes.Post{
+			Path:        postURL.String(),
+			PublishedAt: parseSitemapDate(u.LastMod),
+			Models:      w.collector.models,
+		}
+		select {
+		case ch <- post:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (w *sitemapWalker) fetch(ctx context.Context, sitemap string) (*sitemapDocument, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sitemap, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range w.collector.header {
+		req.Header[k] = v
+	}
+	slogctx.FromCtx(ctx).DebugContext(ctx, "fetch sitemap",
+		slog.String("URL", sitemap),
+		slog.Any("Headers", redactedHeader{header: w.collector.header, secrets: w.collector.secretHeaders}),
+	)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", errUnexpectedPage, resp.Status)
+	}
+
+	// The .xml.gz sitemap is gzipped without the Content-Encoding
+	body := bufio.NewReader(io.LimitReader(resp.Body, maxSitemapBody))
+	var r io.Reader = body
+	if magic, _ := body.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close() //nolint
+		r = io.LimitReader(gr, maxSitemapBody)
+	}
+
+	doc := &sitemapDocument{}
+	err = xml.NewDecoder(r).Decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errNotSitemap, err)
+	}
+	if doc.XMLName.Local != "urlset" && doc.XMLName.Local != "sitemapindex" {
+		return nil, fmt.Errorf("%w: root element is %q", errNotSitemap, doc.XMLName.Local)
+	}
+	return doc, nil
+}
+
+// siteHost return the host without www, the www and the bare host are the
+// same site.
+func siteHost(host string) string {
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
+
+// resolveSitemapURL return the http url of loc without fragment.
+func resolveSitemapURL(base *url.URL, loc string) (*url.URL, bool) {
+	ref, err := url.Parse(strings.TrimSpace(loc))
+	if err != nil {
+		return nil, false
+	}
+	u := base.ResolveReference(ref)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, false
+	}
+	u.Fragment = ""
+	return u, true
+}
+
+func parseSitemapDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range sitemapDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,132 @@
+package framework
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+)
+
+func gzipped(g *gomega.WithT, content string) string {
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	_, err := w.Write([]byte(content))
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(w.Close()).To(gomega.Succeed())
+	return b.String()
+}
+
+func TestSitemapCollector_Start(t *testing.T) {
+	g := gomega.NewWithT(t)
+	var cookies, paths []string
+	pages := map[string]string{
+		"/sitemap_index.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/post-sitemap.xml.gz</loc></sitemap>
+  <sitemap><loc>/missing-sitemap.xml</loc></sitemap>
+  <sitemap><loc>/page-sitemap.xml</loc></sitemap>
+  <sitemap><loc>/nested-index.xml</loc></sitemap>
+</sitemapindex>`,
+		"/page-sitemap.xml": `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/about/</loc></url>
+  <url><loc>/blog/2024/leases/</loc><lastmod>2024-03-01</lastmod></url>
+</urlset>`,
+		"/nested-index.xml": `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/too-deep-index.xml</loc></sitemap>
+</sitemapindex>`,
+		"/too-deep-index.xml": `<sitemapindex><sitemap><loc>/too-deep.xml</loc></sitemap></sitemapindex>`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookies = append(cookies, r.Header.Get("Cookie"))
+		paths = append(paths, r.URL.Path)
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(page))
+	}))
+	defer server.Close()
+	pages["/post-sitemap.xml.gz"] = gzipped(g, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>`+server.URL+`/blog/2025/raft-snapshots/#top</loc><lastmod>2025-05-01T08:00:00+08:00</lastmod></url>
+  <url><loc>`+server.URL+`/blog/2025/raft-snapshots/</loc></url>
+  <url><loc>`+server.URL+`/blog/tags/raft/</loc></url>
+  <url><loc>https://cdn.example.com/blog/2025/a.png</loc></url>
+</urlset>`)
+
+	t.Setenv("VELA_TEST_COOKIE", "session=s1")
+	c, err := newSitemapCollector(CollectorSource{
+		Name:    "example",
+		URL:     server.URL + "/sitemap_index.xml",
+		Type:    SourceTypeSitemap,
+		Headers: map[string]string{"Cookie": "${env:VELA_TEST_COOKIE}"},
+		Models:  []string{"LONG_CONTEXT"},
+		Sitemap: &SitemapOptions{PathPrefix: "/blog/", Pattern: `/20[0-9]{2}/`},
+	})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	ch := make(chan apitypes.Post, 10)
+	g.Expect(c.Start(context.Background(), ch)).To(gomega.Succeed())
+	close(ch)
+	posts := make([]apitypes.Post, 0)
+	for post := range ch {
+		posts = append(posts, post)
+	}
+	g.Expect(posts).To(gomega.Equal([]apitypes.Post{
+		{
+			Path:        server.URL + "/blog/2025/raft-snapshots/",
+			PublishedAt: time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC),
+			Models:      []string{"LONG_CONTEXT"},
+		},
+		{
+			Path:        server.URL + "/blog/2024/leases/",
+			PublishedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			Models:      []string{"LONG_CONTEXT"},
+		},
+	}))
+	// The missing sitemap is skipped, and the sitemaps deeper than 3 levels
+	// are not walked
+	g.Expect(paths).To(gomega.Equal([]string{
+		"/sitemap_index.xml",
+		"/post-sitemap.xml.gz",
+		"/missing-sitemap.xml",
+		"/page-sitemap.xml",
+		"/nested-index.xml",
+		"/too-deep-index.xml",
+	}))
+	g.Expect(cookies).To(gomega.HaveEach("session=s1"))
+
+	c.url = server.URL + "/missing-sitemap.xml"
+	g.Expect(c.Start(context.Background(), make(chan apitypes.Post, 10))).To(gomega.MatchError(errUnexpectedPage))
+	pages["/page.html"] = "<html></html>"
+	c.url = server.URL + "/page.html"
+	g.Expect(c.Start(context.Background(), make(chan apitypes.Post, 10))).To(gomega.MatchError(errNotSitemap))
+}
+
+func TestNewSourceCollector_Sitemap(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	c, err := newSourceCollector(CollectorSource{Name: " example ", URL: "https://example.com/sitemap.xml",
+		Type: SourceTypeSitemap}, nil, nil)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(c.Name()).To(gomega.Equal("example"))
+
+	_, err = newSourceCollector(CollectorSource{Name: "example", URL: "https://example.com/sitemap.xml",
+		Type: SourceTypeSitemap, Sitemap: &SitemapOptions{Pattern: "("}}, nil, nil)
+	g.Expect(err).To(gomega.MatchError(errSitemapPattern))
+	_, err = newSourceCollector(CollectorSource{Name: "example", URL: "https://example.com/sitemap.xml",
+		Type: SourceTypeSitemap, Selectors: &ListSelectors{Item: "li"}}, nil, nil)
+	g.Expect(err).To(gomega.MatchError(errSelectorsUnsupported))
+	_, err = newSourceCollector(CollectorSource{Name: "example", URL: "https://example.com/",
+		Sitemap: &SitemapOptions{PathPrefix: "/blog/"}}, stubListParser{}, nil)
+	g.Expect(err).To(gomega.MatchError(errOptionsUnsupported))
+	_, err = SourceListParser(CollectorSource{Type: SourceTypeSitemap}, stubListParser{})
+	g.Expect(err).To(gomega.MatchError(errListParserUnsupported))
+}
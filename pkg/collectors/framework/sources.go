@@ -0,0 +1,573 @@
+package framework
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+
+	"github.com/anyvoxel/vela/pkg/collectors"
+)
+
+var (
+	errSourcesFormat = errors.New("unsupported sources file format")
+	errSourcesRoot   = errors.New("sources file must be a list of sources or a map of include and sources")
+	errUnknownField  = errors.New("unknown field")
+	errIncludeCycle  = errors.New("include cycle")
+)
+
+// sourcesFile is the sources file with includes, e.g.
+//
+//	include: [teams/*.yaml]
+//	sources:
+//	  - name: brooker
+//	    url: https://brooker.co.za/blog/
+type sourcesFile struct {
+	// Schema is the json schema for editors, it's ignored
+	Schema string `json:"$schema,omitempty" yaml:"$schema,omitempty" toml:"$schema,omitempty"`
+	// Include are the paths or globs of the other sources files, they are
+	// relative to the dir of this file
+	Include []string          `json:"include,omitempty" yaml:"include,omitempty" toml:"include,omitempty"`
+	Sources []CollectorSource `json:"sources,omitempty" yaml:"sources,omitempty" toml:"sources,omitempty"`
+}
+
+// sourceEntry is a source with its position in sources file.
+type sourceEntry struct {
+	CollectorSource
+
+	file  string
+	line  int
+	index int
+}
+
+// position return the position of source for error messages.
+func (e *sourceEntry) position() string {
+	if e.line == 0 {
+		return fmt.Sprintf("%q item[%d]", e.file, e.index)
+	}
+	return fmt.Sprintf("%q line %d item[%d]", e.file, e.line, e.index)
+}
+
+// ReadSources read the sources from the json, yaml or toml file and its
+// includes, the format is decided by the file extension.
+func ReadSources(filePath string) ([]CollectorSource, error) {
+	entries, err := readSources(filePath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]CollectorSource, 0, len(entries))
+	for _, entry := range entries {
+		sources = append(sources, entry.CollectorSource)
+	}
+	return sources, nil
+}
+
+// readSources read the sources of file and its includes in order, the
+// including stack is used to detect the cycle.
+func readSources(filePath string, including []string) ([]*sourceEntry, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(including, absPath) {
+		return nil, fmt.Errorf("%w: %s", errIncludeCycle, strings.Join(append(including, absPath), " -> "))
+	}
+
+	b, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("read vela.collectors.sources_file %q failed: %w", filePath, err)
+	}
+	var file *sourcesFile
+	var lines []int
+	switch ext := strings.ToLower(filepath.Ext(filePath)); ext {
+	case ".json":
+		file, lines, err = decodeJSONSources(b)
+	case ".yaml", ".yml":
+		file, lines, err = decodeYAMLSources(b)
+	case ".toml":
+		file, lines, err = decodeTOMLSources(b)
+	default:
+		err = fmt.Errorf("%w: %q, it must be .json, .yaml, .yml or .toml", errSourcesFormat, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid sources file %q: %w", filePath, err)
+	}
+
+	entries := make([]*sourceEntry, 0, len(file.Sources))
+	for i, src := range file.Sources {
+		entry := &sourceEntry{CollectorSource: src, file: filePath, index: i}
+		if i < len(lines) {
+			entry.line = lines[i]
+		}
+		entries = append(entries, entry)
+	}
+	for _, include := range file.Include {
+		paths, err := includePaths(filepath.Dir(filePath), include)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sources file %q include %q: %w", filePath, include, err)
+		}
+		for _, path := range paths {
+			included, err := readSources(path, append(including, absPath))
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, included...)
+		}
+	}
+	return entries, nil
+}
+
+// includePaths return the sorted paths of include, the missing path is an
+// error unless it's a glob.
+func includePaths(dir string, include string) ([]string, error) {
+	if !filepath.IsAbs(include) {
+		include = filepath.Join(dir, include)
+	}
+	paths, err := filepath.Glob(include)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 && !strings.ContainsAny(include, `*?[\`) {
+		return nil, os.ErrNotExist
+	}
+	slices.Sort(paths)
+	return paths, nil
+}
+
+// decodeYAMLSources decode the list of sources or the sourcesFile, and
+// return the line of each source.
+func decodeYAMLSources(b []byte) (*sourcesFile, []int, error) {
+	var doc yaml.Node
+	err := yaml.Unmarshal(b, &doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(doc.Content) == 0 {
+		return &sourcesFile{}, nil, nil
+	}
+
+	root := doc.Content[0]
+	file := &sourcesFile{}
+	var items []*yaml.Node
+	switch root.Kind {
+	case yaml.SequenceNode:
+		err = checkKnownFields(root, reflect.TypeFor[[]CollectorSource](), "")
+		if err == nil {
+			err = root.Decode(&file.Sources)
+		}
+		items = root.Content
+	case yaml.MappingNode:
+		err = checkKnownFields(root, reflect.TypeFor[sourcesFile](), "")
+		if err == nil {
+			err = root.Decode(file)
+		}
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value == "sources" {
+				items = root.Content[i+1].Content
+			}
+		}
+	default:
+		return nil, nil, fmt.Errorf("line %d: %w", root.Line, errSourcesRoot)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lines := make([]int, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, item.Line)
+	}
+	return file, lines, nil
+}
+
+// checkKnownFields report the keys of mapping node which are not the fields
+// of t, e.g. the typos.
+func checkKnownFields(node *yaml.Node, t reflect.Type, path string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch {
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		errs := make([]error, 0)
+		for i, item := range node.Content {
+			errs = append(errs, checkKnownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i)))
+		}
+		return errors.Join(errs...)
+	case t.Kind() != reflect.Struct || node.Kind != yaml.MappingNode:
+		// The mismatched types are reported by decoding
+		return nil
+	}
+
+	fields := make(map[string]reflect.Type, t.NumField())
+	for _, field := range reflect.VisibleFields(t) {
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name != "" && name != "-" {
+			fields[name] = field.Type
+		}
+	}
+	errs := make([]error, 0)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		fieldPath := strings.TrimPrefix(path+"."+key.Value, ".")
+		fieldType, ok := fields[key.Value]
+		if !ok {
+			errs = append(errs, fmt.Errorf("line %d: %w %q%s", key.Line, errUnknownField, fieldPath,
+				suggestField(key.Value, fields)))
+			continue
+		}
+		errs = append(errs, checkKnownFields(node.Content[i+1], fieldType, fieldPath))
+	}
+	return errors.Join(errs...)
+}
+
+// suggestField return the hint of the known field which is the closest to
+// the unknown one.
+func suggestField(name string, fields map[string]reflect.Type) string {
+	best, bestDistance := "", 3
+	for _, field := range slices.Sorted(maps.Keys(fields)) {
+		if d := editDistance(strings.ToLower(name), field); d < bestDistance {
+			best, bestDistance = field, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q", best)
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// decodeJSONSources decode the list of sources or the sourcesFile by
+// encoding/json, and return the line of each source. The json is not parsed
+// as yaml, which rejects e.g. the duplicate keys and tabs.
+func decodeJSONSources(b []byte) (*sourcesFile, []int, error) {
+	file := &sourcesFile{}
+	trimmed := bytes.TrimLeft(b, " \t\r\n")
+	var target any
+	switch {
+	case len(trimmed) == 0:
+		return file, nil, nil
+	case trimmed[0] == '[':
+		target = &file.Sources
+	case trimmed[0] == '{':
+		target = file
+	default:
+		var v any
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil, nil, jsonError(b, err)
+		}
+		return nil, nil, fmt.Errorf("line %d: %w", jsonLine(b, int64(len(b)-len(trimmed)+1)), errSourcesRoot)
+	}
+
+	err := json.Unmarshal(b, target)
+	if err != nil {
+		return nil, nil, jsonError(b, err)
+	}
+
+	w := &jsonFieldsWalker{b: b, dec: json.NewDecoder(bytes.NewReader(b))}
+	err = w.walk(reflect.TypeOf(target).Elem(), "", trimmed[0] == '[')
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = errors.Join(w.errs...); err != nil {
+		return nil, nil, err
+	}
+	return file, w.lines, nil
+}
+
+// jsonFieldsWalker walk the tokens of json to report the unknown fields like
+// checkKnownFields, and collect the lines of sources.
+type jsonFieldsWalker struct {
+	b     []byte
+	dec   *json.Decoder
+	lines []int
+	// source is true if the next value is a source
+	source bool
+	errs   []error
+}
+
+// walk read the next value of type t, the t is nil if the value is not
+// checked. The lines of items are collected if the value is the sources.
+func (w *jsonFieldsWalker) walk(t reflect.Type, path string, sources bool) error {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	tok, err := w.dec.Token()
+	if err != nil {
+		return jsonError(w.b, err)
+	}
+	if w.source {
+		w.source = false
+		w.lines = append(w.lines, jsonLine(w.b, w.dec.InputOffset()))
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return nil
+	}
+
+	switch delim {
+	case '[':
+		var elem reflect.Type
+		if t != nil && t.Kind() == reflect.Slice {
+			elem = t.Elem()
+		}
+		if sources {
+			// The last one is decoded if sources are duplicate
+			w.lines = w.lines[:0]
+		}
+		for i := 0; w.dec.More(); i++ {
+			w.source = sources
+			err = w.walk(elem, fmt.Sprintf("%s[%d]", path, i), false)
+			if err != nil {
+				return err
+			}
+		}
+	case '{':
+		for w.dec.More() {
+			tok, err = w.dec.Token()
+			if err != nil {
+				return jsonError(w.b, err)
+			}
+			key, _ := tok.(string)
+			fieldPath := strings.TrimPrefix(path+"."+key, ".")
+			fieldType, known := jsonField(t, key)
+			if !known {
+				w.errs = append(w.errs, fmt.Errorf("line %d: %w %q%s", jsonLine(w.b, w.dec.InputOffset()),
+					errUnknownField, fieldPath, suggestField(key, jsonFields(t))))
+			}
+			err = w.walk(fieldType, fieldPath, path == "" && t == reflect.TypeFor[sourcesFile]() && key == "sources")
+			if err != nil {
+				return err
+			}
+		}
+	}
+	// The closing delim
+	_, err = w.dec.Token()
+	if err != nil {
+		return jsonError(w.b, err)
+	}
+	return nil
+}
+
+// jsonField return the type of the key in value of t, the key is matched
+// case insensitively like encoding/json. The key of map or unchecked value is
+// always known.
+func jsonField(t reflect.Type, key string) (reflect.Type, bool) {
+	switch {
+	case t == nil:
+		return nil, true
+	case t.Kind() == reflect.Map:
+		return t.Elem(), true
+	case t.Kind() != reflect.Struct:
+		return nil, true
+	}
+
+	fields := jsonFields(t)
+	if fieldType, ok := fields[key]; ok {
+		return fieldType, true
+	}
+	for name, fieldType := range fields {
+		if strings.EqualFold(name, key) {
+			return fieldType, true
+		}
+	}
+	return nil, false
+}
+
+// jsonFields return the fields of struct by their json names.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	if t == nil || t.Kind() != reflect.Struct {
+		return fields
+	}
+	for _, field := range reflect.VisibleFields(t) {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = field.Type
+		}
+	}
+	return fields
+}
+
+// jsonError add the line numbers to the errors of encoding/json.
+func jsonError(b []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Errorf("line %d: %w", jsonLine(b, syntaxErr.Offset), err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Errorf("line %d: %w", jsonLine(b, typeErr.Offset), err)
+	}
+	return err
+}
+
+// jsonLine return the line of the byte before offset, e.g. the last byte of
+// a token.
+func jsonLine(b []byte, offset int64) int {
+	offset = min(max(offset-1, 0), int64(len(b)))
+	return bytes.Count(b[:offset], []byte("\n")) + 1
+}
+
+// decodeTOMLSources decode the sourcesFile, the sources are the [[sources]]
+// tables whose lines are returned.
+func decodeTOMLSources(b []byte) (*sourcesFile, []int, error) {
+	file := &sourcesFile{}
+	err := toml.NewDecoder(bytes.NewReader(b)).DisallowUnknownFields().Decode(file)
+	if err != nil {
+		return nil, nil, tomlError(err)
+	}
+
+	lines := make([]int, 0, len(file.Sources))
+	p := &unstable.Parser{}
+	p.Reset(b)
+	for p.NextExpression() {
+		expr := p.Expression()
+		if expr.Kind != unstable.ArrayTable {
+			continue
+		}
+		// The range is only set on the key nodes
+		key := expr.Key()
+		if !key.Next() {
+			continue
+		}
+		first := key.Node()
+		if string(first.Data) == "sources" && !key.Next() {
+			lines = append(lines, p.Shape(first.Raw).Start.Line)
+		}
+	}
+	return file, lines, nil
+}
+
+// tomlError add the line numbers to the errors of toml.
+func tomlError(err error) error {
+	var strictErr *toml.StrictMissingError
+	if errors.As(err, &strictErr) {
+		errs := make([]error, 0, len(strictErr.Errors))
+		for _, e := range strictErr.Errors {
+			row, _ := e.Position()
+			errs = append(errs, fmt.Errorf("line %d: %w %q", row, errUnknownField, strings.Join(e.Key(), ".")))
+		}
+		return errors.Join(errs...)
+	}
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		row, _ := decodeErr.Position()
+		return fmt.Errorf("line %d: %w", row, err)
+	}
+	return err
+}
+
+// AddSource validate the source and append it to the json sources file, the
+// name must be unique in the file and its includes.
+func AddSource(filePath string, src CollectorSource, llmParser collectors.ListParser) error {
+	if ext := strings.ToLower(filepath.Ext(filePath)); ext != ".json" {
+		return fmt.Errorf("%w: %q, only the .json sources file can be added to", errSourcesFormat, ext)
+	}
+	_, err := newSourceCollector(src, llmParser, nil)
+	if err != nil {
+		return err
+	}
+
+	b, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return writeJSONFile(filePath, []CollectorSource{src})
+	}
+	if err != nil {
+		return err
+	}
+	entries, err := readSources(filePath, nil)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name == src.Name {
+			return fmt.Errorf("%w: %s is in %s", ErrDuplicateCollector, src.Name, entry.position())
+		}
+	}
+
+	var content any
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("[")) {
+		sources := make([]CollectorSource, 0)
+		err = json.Unmarshal(b, &sources)
+		content = append(sources, src)
+	} else {
+		file := &sourcesFile{}
+		err = json.Unmarshal(b, file)
+		file.Sources = append(file.Sources, src)
+		content = file
+	}
+	if err != nil {
+		return err
+	}
+	return writeJSONFile(filePath, content)
+}
+
+// writeJSONFile write the indented json to a temp file and rename it, so the
+// file is not corrupted if it's failed, e.g. it's being watched by daemon.
+func writeJSONFile(filePath string, content any) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(content)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) //nolint
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(filePath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	err = f.Chmod(mode)
+	if err == nil {
+		_, err = f.Write(buf.Bytes())
+	}
+	if err == nil {
+		err = f.Close()
+	} else {
+		_ = f.Close()
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filePath)
+}
@@ -0,0 +1,331 @@
+package framework
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/anyvoxel/vela/pkg/fetch"
+	"github.com/anyvoxel/vela/test"
+)
+
+func writeSourcesFile(g *gomega.WithT, dir string, name string, content string) string {
+	filePath := filepath.Join(dir, name)
+	g.Expect(os.MkdirAll(filepath.Dir(filePath), 0o755)).To(gomega.Succeed())
+	g.Expect(os.WriteFile(filePath, []byte(content), 0o600)).To(gomega.Succeed())
+	return filePath
+}
+
+func TestReadSources_Formats(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dir := t.TempDir()
+	expected := []CollectorSource{
+		{
+			Name:      "brooker",
+			URL:       "https://brooker.co.za/blog/",
+			Models:    []string{"LONG_CONTEXT"},
+			Selectors: &ListSelectors{Item: "li", DateLayout: "2006-01-02"},
+		},
+		{
+			Name:      "wiki",
+			URL:       "https://wiki.corp/blog/",
+			Headers:   map[string]string{"Cookie": "${env:WIKI_COOKIE}"},
+			Transport: &fetch.TransportOptions{Auth: &fetch.AuthOptions{TokenEnv: "WIKI_TOKEN"}},
+		},
+	}
+
+	for _, filePath := range []string{
+		writeSourcesFile(g, dir, "collectors.json", `[
+			{"name":"brooker","url":"https://brooker.co.za/blog/","models":["LONG_CONTEXT"],
+			 "selectors":{"item":"li","date_layout":"2006-01-02"}},
+			{"name":"wiki","url":"https://wiki.corp/blog/","headers":{"Cookie":"${env:WIKI_COOKIE}"},
+			 "transport":{"auth":{"token_env":"WIKI_TOKEN"}}}
+		]`),
+		writeSourcesFile(g, dir, "collectors.yaml", `
+- name: brooker
+  url: https://brooker.co.za/blog/
+  models: [LONG_CONTEXT]
+  selectors: {item: li, date_layout: "2006-01-02"}
+- name: wiki
+  url: https://wiki.corp/blog/
+  headers:
+    Cookie: ${env:WIKI_COOKIE}
+  transport:
+    auth:
+      token_env: WIKI_TOKEN
+`),
+		writeSourcesFile(g, dir, "collectors.yml", `
+$schema: ./collectors.schema.json
+sources:
+  - name: brooker
+    url: https://brooker.co.za/blog/
+    models: [LONG_CONTEXT]
+    selectors: {item: li, date_layout: "2006-01-02"}
+  - name: wiki
+    url: https://wiki.corp/blog/
+    headers: {Cookie: "${env:WIKI_COOKIE}"}
+    transport: {auth: {token_env: WIKI_TOKEN}}
+`),
+		writeSourcesFile(g, dir, "collectors.toml", `
+[[sources]]
+name = "brooker"
+url = "https://brooker.co.za/blog/"
+models = ["LONG_CONTEXT"]
+selectors = {item = "li", date_layout = "2006-01-02"}
+
+[[sources]]
+name = "wiki"
+url = "https://wiki.corp/blog/"
+headers = {Cookie = "${env:WIKI_COOKIE}"}
+[sources.transport.auth]
+token_env = "WIKI_TOKEN"
+`),
+	} {
+		sources, err := ReadSources(filePath)
+		g.Expect(err).ToNot(gomega.HaveOccurred(), filePath)
+		g.Expect(sources).To(gomega.Equal(expected), filePath)
+	}
+
+	_, err := ReadSources(writeSourcesFile(g, dir, "collectors.txt", ""))
+	g.Expect(err).To(gomega.MatchError(errSourcesFormat))
+	_, err = ReadSources(writeSourcesFile(g, dir, "scalar.yaml", "brooker"))
+	g.Expect(err).To(gomega.MatchError(errSourcesRoot))
+	sources, err := ReadSources(writeSourcesFile(g, dir, "empty.yaml", ""))
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(sources).To(gomega.BeEmpty())
+}
+
+func TestReadSources_Include(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dir := t.TempDir()
+	root := writeSourcesFile(g, dir, "collectors.yaml", `
+include: [teams/*.yaml, papers.toml, teams/*.json]
+sources:
+  - name: brooker
+    url: https://brooker.co.za/blog/
+`)
+	writeSourcesFile(g, dir, "teams/storage.yaml", `
+- name: storage
+  url: https://storage.corp/blog/
+`)
+	writeSourcesFile(g, dir, "teams/infra.yaml", `
+- name: infra
+  url: https://infra.corp/blog/
+`)
+	writeSourcesFile(g, dir, "papers.toml", `
+[[sources]]
+name = "arxiv"
+url = "https://arxiv.org/list/cs.DC/recent"
+`)
+
+	entries, err := readSources(root, nil)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	positions := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		positions = append(positions, entry.Name+" "+strings.TrimPrefix(entry.position(), `"`+dir+"/"))
+	}
+	g.Expect(positions).To(gomega.Equal([]string{
+		`brooker collectors.yaml" line 4 item[0]`,
+		`infra teams/infra.yaml" line 2 item[0]`,
+		`storage teams/storage.yaml" line 2 item[0]`,
+		`arxiv papers.toml" line 2 item[0]`,
+	}))
+
+	writeSourcesFile(g, dir, "teams/infra.yaml", `
+include: [../collectors.yaml]
+`)
+	_, err = readSources(root, nil)
+	g.Expect(err).To(gomega.MatchError(errIncludeCycle))
+
+	writeSourcesFile(g, dir, "teams/infra.yaml", `
+include: [missing.yaml]
+`)
+	_, err = readSources(root, nil)
+	g.Expect(err).To(gomega.MatchError(os.ErrNotExist))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(`include "missing.yaml"`)))
+}
+
+func TestReadSources_Errors(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dir := t.TempDir()
+
+	_, err := ReadSources(writeSourcesFile(g, dir, "typo.yaml", `
+- name: brooker
+  url: https://brooker.co.za/blog/
+  selecters: {item: li}
+- name: wiki
+  url: https://wiki.corp/blog/
+  transport: {proxi: "http://proxy.corp:3128"}
+  cookies: session
+`))
+	g.Expect(err).To(gomega.MatchError(errUnknownField))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(
+		`line 4: unknown field "[0].selecters", did you mean "selectors"`)))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(
+		`line 7: unknown field "[1].transport.proxi", did you mean "proxy"`)))
+	g.Expect(err).To(gomega.MatchError(gomega.HaveSuffix(`line 8: unknown field "[1].cookies"`)))
+
+	_, err = ReadSources(writeSourcesFile(g, dir, "typo.json", `{
+		"sources": [{"name":"brooker","url":"https://brooker.co.za/blog/"}],
+		"includes": ["teams/*.json"]
+	}`))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(
+		`line 3: unknown field "includes", did you mean "include"`)))
+
+	_, err = ReadSources(writeSourcesFile(g, dir, "type.yaml", `
+- name: brooker
+  url: https://brooker.co.za/blog/
+  models: LONG_CONTEXT
+`))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("line 4: cannot unmarshal")))
+
+	_, err = ReadSources(writeSourcesFile(g, dir, "typo.toml", `
+[[sources]]
+name = "brooker"
+url = "https://brooker.co.za/blog/"
+
+[[sources]]
+name = "wiki"
+url = "https://wiki.corp/blog/"
+[sources.transport]
+proxi = "http://proxy.corp:3128"
+`))
+	g.Expect(err).To(gomega.MatchError(errUnknownField))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(`line 10: unknown field "sources.transport.proxi"`)))
+
+	_, err = ReadSources(writeSourcesFile(g, dir, "syntax.toml", `
+[[sources]]
+name = "brooker
+`))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("line 3: ")))
+}
+
+// TestReadSources_JSON check the json is decoded by encoding/json, e.g. the
+// duplicate keys and tabs which are rejected by yaml.
+func TestReadSources_JSON(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dir := t.TempDir()
+
+	for _, filePath := range []string{
+		filepath.Join(test.CurrentProjectPath(), "collectors.json"),
+		filepath.Join(test.CurrentProjectPath(), "pkg", "agents", "testdata", "lists", "sources.json"),
+	} {
+		b, err := os.ReadFile(filePath)
+		g.Expect(err).ToNot(gomega.HaveOccurred())
+		expected := make([]CollectorSource, 0)
+		g.Expect(json.Unmarshal(b, &expected)).To(gomega.Succeed())
+
+		sources, err := ReadSources(filePath)
+		g.Expect(err).ToNot(gomega.HaveOccurred(), filePath)
+		g.Expect(sources).To(gomega.Equal(expected), filePath)
+	}
+
+	entries, err := readSources(writeSourcesFile(g, dir, "tabs.json", "{\n"+
+		"\t\"sources\": [\n"+
+		"\t\t{\"name\": \"draft\", \"url\": \"https://brooker.co.za/blog/\", \"name\": \"brooker\"},\n"+
+		"\t\t{\n"+
+		"\t\t\t\"Name\": \"wiki\",\n"+
+		"\t\t\t\"url\": \"https://wiki.corp/blog/\"\n"+
+		"\t\t}\n"+
+		"\t]\n"+
+		"}\n"), nil)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(entries).To(gomega.HaveLen(2))
+	g.Expect(entries[0].Name).To(gomega.Equal("brooker"))
+	g.Expect(entries[0].position()).To(gomega.HaveSuffix(`tabs.json" line 3 item[0]`))
+	g.Expect(entries[1].Name).To(gomega.Equal("wiki"))
+	g.Expect(entries[1].position()).To(gomega.HaveSuffix(`tabs.json" line 4 item[1]`))
+
+	_, err = ReadSources(writeSourcesFile(g, dir, "type.json", `[
+		{"name": "brooker", "url": "https://brooker.co.za/blog/"},
+		{"name": "wiki", "url": "https://wiki.corp/blog/",
+		 "models": "LONG_CONTEXT"}
+	]`))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("line 4: json: cannot unmarshal")))
+
+	_, err = ReadSources(writeSourcesFile(g, dir, "typo.json", `[
+		{"name": "brooker", "url": "https://brooker.co.za/blog/",
+		 "transport": {"proxi": "http://proxy.corp:3128"}}
+	]`))
+	g.Expect(err).To(gomega.MatchError(errUnknownField))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(
+		`line 3: unknown field "[0].transport.proxi", did you mean "proxy"`)))
+
+	_, err = ReadSources(writeSourcesFile(g, dir, "syntax.json", `[
+		{"name": "brooker",}
+	]`))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("line 2: invalid character")))
+
+	_, err = ReadSources(writeSourcesFile(g, dir, "root.json", "\n\"brooker\""))
+	g.Expect(err).To(gomega.MatchError(errSourcesRoot))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("line 2: ")))
+}
+
+func TestFramework_SourcesFileErrors(t *testing.T) {
+	g := gomega.NewWithT(t)
+	sourcesFile := writeSourcesFile(g, t.TempDir(), "collectors.yaml", `
+- name: brooker
+  url: https://brooker.co.za/blog/
+- name: wiki
+  url: wiki.corp/blog/
+`)
+
+	framework := &Framework{sourcesFile: sourcesFile, listParser: stubListParser{}}
+	err := framework.AfterPropertiesSet(context.Background())
+	g.Expect(err).To(gomega.MatchError(errURLMustBeAbsolute))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(`collectors.yaml" line 4 item[1]`)))
+}
+
+// TestSourcesSchema check the collectors.schema.json and the tags of the
+// sources are in sync.
+func TestSourcesSchema(t *testing.T) {
+	g := gomega.NewWithT(t)
+	b, err := os.ReadFile(filepath.Join(test.CurrentProjectPath(), "collectors.schema.json"))
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	var schema struct {
+		OneOf []struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"oneOf"`
+		Definitions map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"definitions"`
+	}
+	g.Expect(json.Unmarshal(b, &schema)).To(gomega.Succeed())
+
+	for name, t := range map[string]reflect.Type{
+		"source":          reflect.TypeFor[CollectorSource](),
+		"selectors":       reflect.TypeFor[ListSelectors](),
+		"sitemap":         reflect.TypeFor[SitemapOptions](),
+		"arxiv":           reflect.TypeFor[ArXivOptions](),
+		"github_releases": reflect.TypeFor[GitHubReleasesOptions](),
+		"hackernews":      reflect.TypeFor[HackerNewsOptions](),
+		"transport":       reflect.TypeFor[fetch.TransportOptions](),
+		"auth":            reflect.TypeFor[fetch.AuthOptions](),
+		"":                reflect.TypeFor[sourcesFile](),
+	} {
+		properties := schema.Definitions[name].Properties
+		if name == "" {
+			properties = schema.OneOf[1].Properties
+		}
+
+		fields := make([]string, 0, t.NumField())
+		for i := range t.NumField() {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			g.Expect(field.Tag.Get("yaml")).To(gomega.Equal(tag), field.Name)
+			g.Expect(field.Tag.Get("toml")).To(gomega.Equal(tag), field.Name)
+			jsonName, _, _ := strings.Cut(tag, ",")
+			fields = append(fields, jsonName)
+		}
+		slices.Sort(fields)
+		g.Expect(slices.Sorted(maps.Keys(properties))).To(gomega.Equal(fields), name)
+	}
+}
@@ -0,0 +1,253 @@
+// Package manual implement the collector of urls submitted by user.
+package manual
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyvoxel/airmid/anvil"
+	airapp "github.com/anyvoxel/airmid/app"
+	"github.com/anyvoxel/airmid/ioc"
+	slogctx "github.com/veqryn/slog-context"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+	"github.com/anyvoxel/vela/pkg/collectors"
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+func init() {
+	anvil.Must(airapp.RegisterBeanDefinition(
+		"vela.collectors.manual",
+		ioc.MustNewBeanDefinition(
+			reflect.TypeFor[*collector](),
+		),
+	))
+}
+
+var errInvalidURL = errors.New("invalid url")
+
+// inboxImportingSuffix is appended to the inbox file while it's imported, the
+// lines appended by others meanwhile go to a new inbox file.
+const inboxImportingSuffix = ".importing"
+
+// NormalizeURL return the absolute http(s) url without fragment.
+func NormalizeURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("%w: %q: %w", errInvalidURL, raw, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%w: %q must be an absolute http(s) url", errInvalidURL, raw)
+	}
+	u.Fragment = ""
+	return u.String(), nil
+}
+
+// Domain return the domain of submitted url, which is its host without www.
+func Domain(path string) string {
+	u, err := url.Parse(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// collector emit the submitted urls, the url is dequeued by the application
+// once it's persisted, so the failed one will be retried in next run.
+type collector struct {
+	store storage.Storage `airmid:"autowire:vela.storage.storage"`
+
+	// inboxFile is a text file of urls, one per line. It's imported into the
+	// submission queue when the collector is initialized, and polled every
+	// pollInterval in the daemon command.
+	inboxFile    string        `airmid:"value:${vela.inbox.file:=./inbox.txt}"`
+	pollInterval time.Duration `airmid:"value:${vela.inbox.poll_interval:=10s}"`
+
+	// mu serializes the imports of Initialize and Watch
+	mu sync.Mutex
+}
+
+var (
+	_ collectors.Collector = (*collector)(nil)
+	_ collectors.Watcher   = (*collector)(nil)
+)
+
+// Name implement Collector.Name
+func (*collector) Name() string { return apitypes.SourceManual }
+
+// Initialize implement Collector.Initialize
+func (c *collector) Initialize(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return ImportInbox(ctx, c.store, c.inboxFile)
+}
+
+// Watch implement Watcher.Watch, it import the inbox every pollInterval so
+// the urls are queued before the next run, the failure is logged once.
+func (c *collector) Watch(ctx context.Context) {
+	if strings.TrimSpace(c.inboxFile) == "" || c.pollInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+	lastErr := ""
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := c.Initialize(ctx)
+		if err == nil {
+			lastErr = ""
+			continue
+		}
+		if err.Error() != lastErr {
+			lastErr = err.Error()
+			slogctx.FromCtx(ctx).ErrorContext(ctx, "import inbox failed",
+				slog.String("File", c.inboxFile),
+				slog.Any("Error", err),
+			)
+		}
+	}
+}
+
+// Start implement Collector.Start
+func (c *collector) Start(ctx context.Context, ch chan<- apitypes.Post) error {
+	paths, err := c.store.Submissions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if c.store.SummaryExists(ctx, path) {
+			err = c.store.DequeueSubmission(ctx, path)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		slogctx.FromCtx(ctx).InfoContext(ctx, "collect submitted article",
+			slog.String("Path", path))
+		ch <- apitypes.Post{
+			Domain: Domain(path),
+			Path:   path,
+			Source: apitypes.SourceManual,
+		}
+	}
+	return nil
+}
+
+// ImportInbox will take the inbox file by renaming it and submit its urls,
+// so the lines appended meanwhile are kept for the next import. The lines
+// start with # are kept in the inbox, the blank lines and invalid urls are
+// dropped.
+func ImportInbox(ctx context.Context, store storage.Storage, inboxFile string) error {
+	inboxFile = strings.TrimSpace(inboxFile)
+	if inboxFile == "" {
+		return nil
+	}
+
+	// The file left by an interrupted import is imported again, the urls
+	// are queued only once
+	importing := inboxFile + inboxImportingSuffix
+	_, err := os.Stat(importing)
+	if err == nil {
+		err = importInboxFile(ctx, store, inboxFile, importing)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// The inbox of only comments is kept as it is
+	b, err := os.ReadFile(inboxFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if !slices.ContainsFunc(strings.Split(string(b), "\n"), isInboxURLLine) {
+		return nil
+	}
+
+	err = os.Rename(inboxFile, importing)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return importInboxFile(ctx, store, inboxFile, importing)
+}
+
+// isInboxURLLine return true if the line of inbox is neither blank nor a comment.
+func isInboxURLLine(line string) bool {
+	line = strings.TrimSpace(line)
+	return line != "" && !strings.HasPrefix(line, "#")
+}
+
+// importInboxFile submit the urls in the importing file, and append its
+// comments back to the inbox before it's removed.
+func importInboxFile(ctx context.Context, store storage.Storage, inboxFile string, importing string) error {
+	b, err := os.ReadFile(importing)
+	if err != nil {
+		return err
+	}
+
+	var comments bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !isInboxURLLine(line) {
+			if line != "" {
+				comments.WriteString(line + "\n")
+			}
+			continue
+		}
+
+		path, err := NormalizeURL(line)
+		if err != nil {
+			slogctx.FromCtx(ctx).WarnContext(ctx, "drop invalid url in inbox",
+				slog.String("Line", line),
+				slog.Any("Error", err))
+			continue
+		}
+		err = store.Submit(ctx, path)
+		if err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if comments.Len() > 0 {
+		f, err := os.OpenFile(inboxFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(comments.Bytes())
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return os.Remove(importing)
+}
@@ -0,0 +1,106 @@
+package manual
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anyvoxel/airmid/ioc"
+	"github.com/onsi/gomega"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	path, err := NormalizeURL(" https://www.Example.com/a/b?c=d#section ")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(path).To(gomega.Equal("https://www.Example.com/a/b?c=d"))
+	g.Expect(Domain(path)).To(gomega.Equal("example.com"))
+
+	_, err = NormalizeURL("/a/b")
+	g.Expect(err).To(gomega.MatchError(errInvalidURL))
+	_, err = NormalizeURL("ftp://example.com/a")
+	g.Expect(err).To(gomega.MatchError(errInvalidURL))
+}
+
+func TestCollector_Start(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store := storage.NewStorage(dir)
+	g.Expect(store.(ioc.InitializingBean).AfterPropertiesSet(ctx)).To(gomega.Succeed())
+	g.Expect(store.Put(ctx, []*storage.SummaryResult{{Path: "https://charap.co/3"}})).To(gomega.Succeed())
+
+	inboxFile := filepath.Join(dir, "inbox.txt")
+	g.Expect(os.WriteFile(inboxFile, []byte("# reading list\nhttps://blog.example.com/post\n\nnot a url\n"+
+		"https://charap.co/3\n"), 0o600)).To(gomega.Succeed())
+
+	c := &collector{store: store, inboxFile: inboxFile}
+	g.Expect(c.Initialize(ctx)).To(gomega.Succeed())
+	// The comments are kept in the inbox
+	b, err := os.ReadFile(inboxFile)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(string(b)).To(gomega.Equal("# reading list\n"))
+	g.Expect(c.Initialize(ctx)).To(gomega.Succeed())
+	b, err = os.ReadFile(inboxFile)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(string(b)).To(gomega.Equal("# reading list\n"))
+
+	ch := make(chan apitypes.Post, 10)
+	g.Expect(c.Start(ctx, ch)).To(gomega.Succeed())
+	close(ch)
+	posts := []apitypes.Post{}
+	for post := range ch {
+		posts = append(posts, post)
+	}
+	g.Expect(posts).To(gomega.Equal([]apitypes.Post{
+		{Domain: "blog.example.com", Path: "https://blog.example.com/post", Source: apitypes.SourceManual},
+	}))
+
+	// The persisted url is dequeued, the others wait for the application
+	g.Expect(store.Submissions(ctx)).To(gomega.Equal([]string{"https://blog.example.com/post"}))
+}
+
+func TestImportInbox(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store := storage.NewStorage(dir)
+	g.Expect(store.(ioc.InitializingBean).AfterPropertiesSet(ctx)).To(gomega.Succeed())
+
+	// The file left by an interrupted import is imported before the inbox
+	inboxFile := filepath.Join(dir, "inbox.txt")
+	g.Expect(os.WriteFile(inboxFile+inboxImportingSuffix, []byte("https://example.com/1\n"), 0o600)).
+		To(gomega.Succeed())
+	g.Expect(os.WriteFile(inboxFile, []byte("https://example.com/2\n"), 0o600)).To(gomega.Succeed())
+	g.Expect(ImportInbox(ctx, store, inboxFile)).To(gomega.Succeed())
+	g.Expect(store.Submissions(ctx)).To(gomega.Equal([]string{"https://example.com/1", "https://example.com/2"}))
+	g.Expect(inboxFile + inboxImportingSuffix).ToNot(gomega.BeAnExistingFile())
+	g.Expect(inboxFile).ToNot(gomega.BeAnExistingFile())
+
+	g.Expect(ImportInbox(ctx, store, inboxFile)).To(gomega.Succeed())
+}
+
+func TestCollector_Watch(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := t.TempDir()
+
+	store := storage.NewStorage(dir)
+	g.Expect(store.(ioc.InitializingBean).AfterPropertiesSet(ctx)).To(gomega.Succeed())
+	inboxFile := filepath.Join(dir, "inbox.txt")
+	c := &collector{store: store, inboxFile: inboxFile, pollInterval: 10 * time.Millisecond}
+	go c.Watch(ctx)
+
+	g.Expect(os.WriteFile(inboxFile, []byte("https://example.com/1\n"), 0o600)).To(gomega.Succeed())
+	g.Eventually(func() ([]string, error) { return store.Submissions(ctx) }).
+		Should(gomega.Equal([]string{"https://example.com/1"}))
+}
@@ -19,6 +19,13 @@ type Collector interface {
 	Start(ctx context.Context, ch chan<- apitypes.Post) error
 }
 
+// Watcher is implemented by the collector which watches its input in the
+// daemon command, e.g. the inbox file of manual collector.
+type Watcher interface {
+	// Watch will poll the input until the context is done
+	Watch(ctx context.Context)
+}
+
 // ListParser extracts post metadata from a list page.
 // It should return absolute URLs in Post.Path.
 type ListParser interface {
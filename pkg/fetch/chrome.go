@@ -0,0 +1,83 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/chromedp/cdproto/cdp"
+	cdpfetch "github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// ChromeOptions return the chrome flags of the transport options of source.
+func (p *Policy) ChromeOptions(source string) []chromedp.ExecAllocatorOption {
+	opts := []chromedp.ExecAllocatorOption{chromedp.UserAgent(p.UserAgent())}
+	st := p.resolve(source)
+	if st == nil {
+		return opts
+	}
+
+	if st.opts.Proxy != "" {
+		opts = append(opts, chromedp.ProxyServer(st.opts.Proxy))
+	}
+	if st.opts.InsecureSkipVerify {
+		opts = append(opts, chromedp.Flag("ignore-certificate-errors", true))
+	}
+	if len(st.spki) > 0 {
+		opts = append(opts, chromedp.Flag("ignore-certificate-errors-spki-list", strings.Join(st.spki, ",")))
+	}
+	return opts
+}
+
+// ChromeActions return the actions to run before navigating to the url of
+// source, they set the cookies of jar and the authorization.
+func (p *Policy) ChromeActions(source string, u *url.URL) chromedp.Tasks {
+	st := p.resolve(source)
+	if st == nil {
+		return nil
+	}
+
+	tasks := chromedp.Tasks{}
+	if st.jar != nil {
+		cookies := st.jar.Cookies(u)
+		params := make([]*network.CookieParam, 0, len(cookies))
+		for _, c := range cookies {
+			params = append(params, &network.CookieParam{Name: c.Name, Value: c.Value, URL: u.String()})
+		}
+		if len(params) > 0 {
+			tasks = append(tasks, network.SetCookies(params))
+		}
+	}
+	if st.authorization != "" {
+		tasks = append(tasks, chromeAuthorization(st.origin, st.authorization))
+	}
+	return tasks
+}
+
+// chromeAuthorization intercept the requests to origin and set their
+// authorization, the requests to the other hosts are not intercepted.
+func chromeAuthorization(origin string, authorization string) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		chromedp.ListenTarget(ctx, func(ev any) {
+			e, ok := ev.(*cdpfetch.EventRequestPaused)
+			if !ok {
+				return
+			}
+			go func() {
+				headers := []*cdpfetch.HeaderEntry{{Name: "Authorization", Value: authorization}}
+				for name, value := range e.Request.Headers {
+					if strings.EqualFold(name, "Authorization") {
+						continue
+					}
+					headers = append(headers, &cdpfetch.HeaderEntry{Name: name, Value: fmt.Sprint(value)})
+				}
+				c := chromedp.FromContext(ctx)
+				_ = cdpfetch.ContinueRequest(e.RequestID).WithHeaders(headers).Do(cdp.WithExecutor(ctx, c.Target))
+			}()
+		})
+		return cdpfetch.Enable().WithPatterns([]*cdpfetch.RequestPattern{{URLPattern: origin + "/*"}}).Do(ctx)
+	}
+}
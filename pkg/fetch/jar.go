@@ -0,0 +1,164 @@
+package fetch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+var errCookieJar = errors.New("cookie jar is invalid")
+
+// jarEntry is the cookies of an url in the jar file, e.g. the session cookie
+// obtained by a login flow
+//
+//	[{"url":"https://wiki.corp/","cookies":[{"name":"session","value":"..."}]}]
+type jarEntry struct {
+	URL     string      `json:"url"`
+	Cookies []jarCookie `json:"cookies"`
+}
+
+type jarCookie struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Path     string    `json:"path,omitempty"`
+	Domain   string    `json:"domain,omitempty"`
+	Expires  time.Time `json:"expires,omitzero"`
+	Secure   bool      `json:"secure,omitempty"`
+	HttpOnly bool      `json:"http_only,omitempty"` //nolint:revive // It's the name of http.Cookie
+}
+
+// persistentJar is the cookie jar which is saved to file on change.
+type persistentJar struct {
+	file string
+	*cookiejar.Jar
+
+	mu      sync.Mutex
+	entries map[string]map[string]jarCookie
+}
+
+var _ http.CookieJar = (*persistentJar)(nil)
+
+func newPersistentJar(file string) (*persistentJar, error) {
+	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	if err != nil {
+		return nil, err
+	}
+	j := &persistentJar{file: file, Jar: jar, entries: make(map[string]map[string]jarCookie)}
+
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errCookieJar, err)
+	}
+	var entries []jarEntry
+	err = json.Unmarshal(b, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", errCookieJar, file, err)
+	}
+	for _, entry := range entries {
+		u, err := url.Parse(entry.URL)
+		if err != nil || !u.IsAbs() {
+			return nil, fmt.Errorf("%w: %s: url %q must be absolute", errCookieJar, file, entry.URL)
+		}
+		cookies := make([]*http.Cookie, 0, len(entry.Cookies))
+		for _, c := range entry.Cookies {
+			if !c.Expires.IsZero() && c.Expires.Before(time.Now()) {
+				continue
+			}
+			j.remember(u, c)
+			cookies = append(cookies, c.httpCookie())
+		}
+		j.Jar.SetCookies(u, cookies)
+	}
+	return j, nil
+}
+
+// SetCookies implement http.CookieJar, the failure of saving is ignored
+// because the cookies are still kept in memory.
+func (j *persistentJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.Jar.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, c := range cookies {
+		j.remember(u, jarCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			Expires:  cookieExpires(c),
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+		})
+	}
+	_ = j.save()
+}
+
+func (j *persistentJar) remember(u *url.URL, c jarCookie) {
+	key := originOf(u) + "/"
+	if j.entries[key] == nil {
+		j.entries[key] = make(map[string]jarCookie)
+	}
+	if !c.Expires.IsZero() && c.Expires.Before(time.Now()) {
+		delete(j.entries[key], c.Name)
+		return
+	}
+	j.entries[key][c.Name] = c
+}
+
+func (j *persistentJar) save() error {
+	entries := make([]jarEntry, 0, len(j.entries))
+	for _, u := range slices.Sorted(maps.Keys(j.entries)) {
+		entry := jarEntry{URL: u, Cookies: make([]jarCookie, 0, len(j.entries[u]))}
+		for _, name := range slices.Sorted(maps.Keys(j.entries[u])) {
+			entry.Cookies = append(entry.Cookies, j.entries[u][name])
+		}
+		entries = append(entries, entry)
+	}
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(j.file), 0o755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(j.file, b, 0o600)
+}
+
+func (c jarCookie) httpCookie() *http.Cookie {
+	return &http.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Path:     c.Path,
+		Domain:   c.Domain,
+		Expires:  c.Expires,
+		Secure:   c.Secure,
+		HttpOnly: c.HttpOnly,
+	}
+}
+
+// cookieExpires return the expire time of cookie, the Max-Age is preferred.
+func cookieExpires(c *http.Cookie) time.Time {
+	switch {
+	case c.MaxAge > 0:
+		return time.Now().Add(time.Duration(c.MaxAge) * time.Second)
+	case c.MaxAge < 0:
+		return time.Unix(1, 0)
+	default:
+		return c.Expires
+	}
+}
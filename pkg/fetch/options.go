@@ -0,0 +1,180 @@
+package fetch
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+var (
+	errProxyInvalid = errors.New("proxy is invalid")
+	errCAFile       = errors.New("ca file is invalid")
+	errAuthInvalid  = errors.New("auth is invalid")
+	errAuthEnvEmpty = errors.New("auth env is empty")
+)
+
+// TransportOptions are the transport options of the requests, the options of
+// source override the global ones, e.g.
+//
+//	{"proxy":"http://proxy.corp:3128","ca_file":"./corp-ca.pem","auth":{"token_env":"WIKI_TOKEN"}}
+type TransportOptions struct {
+	// Proxy is the proxy url, the proxy env (e.g. HTTPS_PROXY) is used if it's
+	// empty
+	Proxy string `json:"proxy,omitempty" yaml:"proxy,omitempty" toml:"proxy,omitempty"`
+	// CAFile is the pem file of the CA certificates trusted besides the system
+	// ones
+	CAFile string `json:"ca_file,omitempty" yaml:"ca_file,omitempty" toml:"ca_file,omitempty"`
+	// InsecureSkipVerify skips the verification of certificates, e.g. for the
+	// internal mirrors
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty" toml:"insecure_skip_verify,omitempty"` //nolint:lll
+	// CookieJar is the json file which persists the cookies
+	CookieJar string `json:"cookie_jar,omitempty" yaml:"cookie_jar,omitempty" toml:"cookie_jar,omitempty"`
+	// Auth is only sent to the host of source
+	Auth *AuthOptions `json:"auth,omitempty" yaml:"auth,omitempty" toml:"auth,omitempty"`
+}
+
+// AuthOptions are the basic or bearer auth, the secrets are read from env.
+type AuthOptions struct {
+	Username    string `json:"username,omitempty" yaml:"username,omitempty" toml:"username,omitempty"`
+	PasswordEnv string `json:"password_env,omitempty" yaml:"password_env,omitempty" toml:"password_env,omitempty"`
+	TokenEnv    string `json:"token_env,omitempty" yaml:"token_env,omitempty" toml:"token_env,omitempty"`
+}
+
+// merge return the options whose empty fields are taken from base, the auth
+// is never global.
+func (o *TransportOptions) merge(base TransportOptions) TransportOptions {
+	if o == nil {
+		return base
+	}
+
+	merged := *o
+	if merged.Proxy == "" {
+		merged.Proxy = base.Proxy
+	}
+	if merged.CAFile == "" {
+		merged.CAFile = base.CAFile
+	}
+	merged.InsecureSkipVerify = merged.InsecureSkipVerify || base.InsecureSkipVerify
+	if merged.CookieJar == "" {
+		merged.CookieJar = base.CookieJar
+	}
+	return merged
+}
+
+// authorization return the Authorization header of auth.
+func (a *AuthOptions) authorization() (string, error) {
+	switch {
+	case a == nil:
+		return "", nil
+	case a.TokenEnv != "" && (a.Username != "" || a.PasswordEnv != ""):
+		return "", fmt.Errorf("%w: token_env conflicts with username and password_env", errAuthInvalid)
+	case a.TokenEnv != "":
+		token := os.Getenv(a.TokenEnv)
+		if token == "" {
+			return "", fmt.Errorf("%w: %s", errAuthEnvEmpty, a.TokenEnv)
+		}
+		return "Bearer " + token, nil
+	case a.Username != "":
+		password := ""
+		if a.PasswordEnv != "" {
+			password = os.Getenv(a.PasswordEnv)
+			if password == "" {
+				return "", fmt.Errorf("%w: %s", errAuthEnvEmpty, a.PasswordEnv)
+			}
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(a.Username+":"+password)), nil
+	default:
+		return "", fmt.Errorf("%w: either token_env or username is required", errAuthInvalid)
+	}
+}
+
+// loadCAFile return the system certificates with the ones of file, and the
+// certificates of file.
+func loadCAFile(file string) (*x509.CertPool, []*x509.Certificate, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", errCAFile, err)
+	}
+
+	certs := make([]*x509.Certificate, 0)
+	for block, rest := pem.Decode(b); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %s: %w", errCAFile, file, err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, nil, fmt.Errorf("%w: %s has no certificate", errCAFile, file)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	return pool, certs, nil
+}
+
+// newHTTPTransport create the transport of options, the auth is not applied.
+func newHTTPTransport(opts TransportOptions) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.Proxy != "" {
+		proxy, err := url.Parse(opts.Proxy)
+		if err != nil || !proxy.IsAbs() {
+			return nil, fmt.Errorf("%w: %q", errProxyInvalid, opts.Proxy)
+		}
+		t.Proxy = http.ProxyURL(proxy)
+	}
+	if opts.CAFile == "" && !opts.InsecureSkipVerify {
+		return t, nil
+	}
+
+	t.TLSClientConfig = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		//nolint:gosec // It's enabled explicitly for the internal mirrors
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+	if opts.CAFile != "" {
+		pool, _, err := loadCAFile(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+	return t, nil
+}
+
+// authTransport set the Authorization of the requests to origin.
+type authTransport struct {
+	origin        string
+	authorization string
+	base          http.RoundTripper
+}
+
+// RoundTrip implement http.RoundTripper
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if originOf(req.URL) != t.origin || req.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", t.authorization)
+	return t.base.RoundTrip(req)
+}
+
+func originOf(u *url.URL) string {
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
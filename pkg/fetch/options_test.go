@@ -0,0 +1,174 @@
+package fetch
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestPolicy_Proxy(t *testing.T) {
+	g := gomega.NewWithT(t)
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer proxy.Close()
+
+	p := &Policy{}
+	g.Expect(p.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+	g.Expect(p.Register("corp", "http://wiki.corp/", &TransportOptions{Proxy: proxy.URL})).To(gomega.Succeed())
+
+	g.Expect(get(p.Client("corp"), "http://wiki.corp/a")).To(gomega.Succeed())
+	g.Expect(proxied).To(gomega.Equal([]string{"http://wiki.corp/a"}))
+
+	err := p.Register("corp", "http://wiki.corp/", &TransportOptions{Proxy: "proxy.corp"})
+	g.Expect(err).To(gomega.MatchError(errProxyInvalid))
+}
+
+func TestPolicy_Auth(t *testing.T) {
+	g := gomega.NewWithT(t)
+	var authorizations []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	other := httptest.NewServer(handler)
+	defer other.Close()
+
+	p := &Policy{}
+	g.Expect(p.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+	err := p.Register("wiki", server.URL+"/blog/", &TransportOptions{Auth: &AuthOptions{TokenEnv: "WIKI_TOKEN"}})
+	g.Expect(err).To(gomega.MatchError(errAuthEnvEmpty))
+
+	t.Setenv("WIKI_TOKEN", "secret")
+	t.Setenv("WIKI_PASSWORD", "pass")
+	err = p.Register("wiki", server.URL+"/blog/", &TransportOptions{Auth: &AuthOptions{TokenEnv: "WIKI_TOKEN"}})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	err = p.Register("mirror", server.URL, &TransportOptions{Auth: &AuthOptions{
+		Username:    "vela",
+		PasswordEnv: "WIKI_PASSWORD",
+	}})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	// The authorization is only sent to the host of source
+	g.Expect(get(p.Client("wiki"), server.URL+"/a")).To(gomega.Succeed())
+	g.Expect(get(p.Client("wiki"), other.URL+"/a")).To(gomega.Succeed())
+	g.Expect(get(p.Client("mirror"), server.URL+"/a")).To(gomega.Succeed())
+	g.Expect(get(p.Client("unknown"), server.URL+"/a")).To(gomega.Succeed())
+	g.Expect(authorizations).To(gomega.Equal([]string{"Bearer secret", "", "Basic dmVsYTpwYXNz", ""}))
+
+	err = p.Register("wiki", server.URL, &TransportOptions{Auth: &AuthOptions{TokenEnv: "WIKI_TOKEN", Username: "vela"}})
+	g.Expect(err).To(gomega.MatchError(errAuthInvalid))
+	err = p.Register("wiki", server.URL, &TransportOptions{Auth: &AuthOptions{}})
+	g.Expect(err).To(gomega.MatchError(errAuthInvalid))
+}
+
+func TestPolicy_TLS(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: server.Certificate().Raw,
+	}), 0o600)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	p := &Policy{}
+	g.Expect(p.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+	g.Expect(p.Register("ca", server.URL, &TransportOptions{CAFile: caFile})).To(gomega.Succeed())
+	g.Expect(p.Register("insecure", server.URL, &TransportOptions{InsecureSkipVerify: true})).To(gomega.Succeed())
+
+	g.Expect(get(p.Client(""), server.URL)).ToNot(gomega.Succeed())
+	g.Expect(get(p.Client("ca"), server.URL)).To(gomega.Succeed())
+	g.Expect(get(p.Client("insecure"), server.URL)).To(gomega.Succeed())
+	g.Expect(p.resolve("ca").spki).To(gomega.HaveLen(1))
+	g.Expect(p.ChromeOptions("ca")).To(gomega.HaveLen(2))
+
+	// The global options are overridden by source
+	p = &Policy{caFile: caFile}
+	g.Expect(p.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+	g.Expect(p.Register("proxy", server.URL, &TransportOptions{Proxy: "http://proxy.corp:3128"})).To(gomega.Succeed())
+	g.Expect(get(p.Client(""), server.URL)).To(gomega.Succeed())
+	g.Expect(p.resolve("proxy").opts).To(gomega.Equal(TransportOptions{
+		Proxy:  "http://proxy.corp:3128",
+		CAFile: caFile,
+	}))
+
+	err = p.Register("ca", server.URL, &TransportOptions{CAFile: filepath.Join(t.TempDir(), "missing.pem")})
+	g.Expect(err).To(gomega.MatchError(errCAFile))
+	p = &Policy{caFile: caFile + ".missing"}
+	g.Expect(p.AfterPropertiesSet(context.Background())).To(gomega.MatchError(errCAFile))
+}
+
+func TestPolicy_CookieJar(t *testing.T) {
+	g := gomega.NewWithT(t)
+	var cookies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookies = append(cookies, r.Header.Get("Cookie"))
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1", Path: "/"})
+			http.SetCookie(w, &http.Cookie{Name: "expired", Value: "e", Path: "/", MaxAge: -1})
+		}
+	}))
+	defer server.Close()
+
+	jarFile := filepath.Join(t.TempDir(), "cookies", "jar.json")
+	p := &Policy{cookieJar: jarFile}
+	g.Expect(p.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+	g.Expect(get(p.Client(""), server.URL+"/login")).To(gomega.Succeed())
+	g.Expect(get(p.Client(""), server.URL+"/a")).To(gomega.Succeed())
+
+	// The cookies are loaded by the next run
+	p = &Policy{}
+	g.Expect(p.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+	g.Expect(p.Register("wiki", server.URL, &TransportOptions{CookieJar: jarFile})).To(gomega.Succeed())
+	g.Expect(get(p.Client("wiki"), server.URL+"/b")).To(gomega.Succeed())
+	g.Expect(get(p.Client(""), server.URL+"/c")).To(gomega.Succeed())
+	g.Expect(cookies).To(gomega.Equal([]string{"", "session=s1", "session=s1", ""}))
+
+	u, err := url.Parse(server.URL + "/b")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(p.ChromeActions("wiki", u)).To(gomega.HaveLen(1))
+	g.Expect(p.ChromeActions("", u)).To(gomega.BeEmpty())
+
+	// The nil options unregister the source
+	g.Expect(p.Register("wiki", server.URL, nil)).To(gomega.Succeed())
+	g.Expect(p.Jar("wiki")).To(gomega.BeNil())
+
+	g.Expect(os.WriteFile(jarFile, []byte(`[{"url":"/"}]`), 0o600)).To(gomega.Succeed())
+	_, err = newPersistentJar(jarFile)
+	g.Expect(err).To(gomega.MatchError(errCookieJar))
+}
+
+func TestPolicy_AcquireProxy(t *testing.T) {
+	g := gomega.NewWithT(t)
+	// The host is reachable through the proxy only
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		_, _ = w.Write([]byte("User-agent: *\nDisallow: /private/\n"))
+	}))
+	defer proxy.Close()
+
+	p := &Policy{robots: true}
+	g.Expect(p.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+	g.Expect(p.Register("corp", "http://wiki.corp/", &TransportOptions{Proxy: proxy.URL})).To(gomega.Succeed())
+
+	release, err := p.Acquire(context.Background(), "corp", &url.URL{Scheme: "http", Host: "wiki.corp", Path: "/a"})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	release()
+	_, err = p.Acquire(context.Background(), "corp", &url.URL{Scheme: "http", Host: "wiki.corp", Path: "/private/a"})
+	g.Expect(err).To(gomega.MatchError(ErrDisallowedByRobots))
+	g.Expect(proxied).To(gomega.Equal([]string{"http://wiki.corp/robots.txt"}))
+}
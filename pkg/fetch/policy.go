@@ -0,0 +1,302 @@
+// Package fetch implement the polite fetch policy shared by the list pages
+// and articles.
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyvoxel/airmid/anvil"
+	airapp "github.com/anyvoxel/airmid/app"
+	"github.com/anyvoxel/airmid/ioc"
+	"github.com/temoto/robotstxt"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+func init() {
+	anvil.Must(airapp.RegisterBeanDefinition(
+		"vela.fetch.policy",
+		ioc.MustNewBeanDefinition(
+			reflect.TypeFor[*Policy](),
+		),
+	))
+}
+
+// DefaultUserAgent identifies vela and where to find its owner.
+const DefaultUserAgent = "vela/1.0 (+https://github.com/anyvoxel/vela)"
+
+const (
+	// robotsTTL is how long the robots.txt of a host is cached.
+	robotsTTL = 24 * time.Hour
+	// robotsRetryInterval is how long the failure of fetching robots.txt is
+	// cached, the host is disallowed until it's fetched again.
+	robotsRetryInterval = 5 * time.Minute
+)
+
+var (
+	// ErrDisallowedByRobots is returned if the url is disallowed by the
+	// robots.txt of its host.
+	ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+	// ErrRobotsUnavailable is returned if the robots.txt of the url host is
+	// failed to fetch, e.g. the network error or 5xx, it's retried later.
+	ErrRobotsUnavailable = errors.New("robots.txt is unavailable")
+)
+
+// Policy is the fetch policy of crawling, it respects the robots.txt, limits
+// the delay and concurrency of each host and the total requests per minute,
+// and identifies the requests by the User-Agent. The nil Policy allows all
+// requests, e.g. in tests.
+type Policy struct {
+	// userAgent defaults to DefaultUserAgent
+	userAgent       string        `airmid:"value:${vela.fetch.user_agent:=}"`
+	robots          bool          `airmid:"value:${vela.fetch.robots:=true}"`
+	hostDelay       time.Duration `airmid:"value:${vela.fetch.host_delay:=2s}"`
+	hostConcurrency int           `airmid:"value:${vela.fetch.host_concurrency:=1}"`
+	// requestsPerMinute is the cap of all hosts, 0 is unlimited
+	requestsPerMinute int `airmid:"value:${vela.fetch.requests_per_minute:=60}"`
+
+	// The global transport options, see TransportOptions
+	proxy              string `airmid:"value:${vela.fetch.proxy:=}"`
+	caFile             string `airmid:"value:${vela.fetch.ca_file:=}"`
+	insecureSkipVerify bool   `airmid:"value:${vela.fetch.insecure_skip_verify:=false}"`
+	cookieJar          string `airmid:"value:${vela.fetch.cookie_jar:=}"`
+
+	mu      sync.Mutex
+	next    time.Time
+	hosts   map[string]*hostState
+	global  *sourceTransport
+	sources map[string]*sourceTransport
+	jars    map[string]*persistentJar
+}
+
+// hostState is the robots.txt and limits of a host.
+type hostState struct {
+	slots chan struct{}
+
+	mu           sync.Mutex
+	next         time.Time
+	robots       *robotstxt.Group
+	robotsErr    error
+	robotsExpire time.Time
+}
+
+var _ ioc.InitializingBean = (*Policy)(nil)
+
+// AfterPropertiesSet implement InitializingBean
+func (p *Policy) AfterPropertiesSet(_ context.Context) error {
+	p.userAgent = strings.TrimSpace(p.userAgent)
+	if p.userAgent == "" {
+		p.userAgent = DefaultUserAgent
+	}
+	if p.hostConcurrency <= 0 {
+		p.hostConcurrency = 1
+	}
+	p.hosts = make(map[string]*hostState)
+
+	global, err := p.newSourceTransport(p.globalOptions(), "")
+	if err != nil {
+		return fmt.Errorf("invalid vela.fetch options: %w", err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.global = global
+	return nil
+}
+
+// UserAgent return the User-Agent of requests.
+func (p *Policy) UserAgent() string {
+	if p == nil || p.userAgent == "" {
+		return DefaultUserAgent
+	}
+	return p.userAgent
+}
+
+// Acquire wait until the url of source is allowed to be fetched, the release
+// must be called when the fetch is done. The robots.txt is fetched by the
+// transport of source, e.g. its proxy.
+func (p *Policy) Acquire(ctx context.Context, source string, u *url.URL) (release func(), err error) {
+	var transport http.RoundTripper = http.DefaultTransport
+	if st := p.resolve(source); st != nil {
+		transport = st.transport
+	}
+	return p.acquire(ctx, u, transport)
+}
+
+// acquire is Acquire which fetches the robots.txt by transport.
+func (p *Policy) acquire(ctx context.Context, u *url.URL, transport http.RoundTripper) (release func(), err error) {
+	if p == nil {
+		return func() {}, nil
+	}
+
+	host := p.host(u)
+	if p.robots {
+		group, err := p.robotsGroup(ctx, u, host, transport)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrRobotsUnavailable, u, err)
+		}
+		if !group.Test(robotsPath(u)) {
+			return nil, fmt.Errorf("%w: %s", ErrDisallowedByRobots, u)
+		}
+	}
+
+	select {
+	case host.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release = func() { <-host.slots }
+
+	err = sleepUntil(ctx, host.reserve(p.hostDelay))
+	if err == nil {
+		err = sleepUntil(ctx, p.reserve())
+	}
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// reserve return the time when the next request is allowed by the total
+// requests per minute.
+func (p *Policy) reserve() time.Time {
+	if p.requestsPerMinute <= 0 {
+		return time.Time{}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	at := later(time.Now(), p.next)
+	p.next = at.Add(time.Minute / time.Duration(p.requestsPerMinute))
+	return at
+}
+
+// reserve return the time when the next request of host is allowed, the
+// Crawl-delay of robots.txt is respected if it's longer than the delay.
+func (h *hostState) reserve(delay time.Duration) time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.robots != nil {
+		delay = max(delay, h.robots.CrawlDelay)
+	}
+	at := later(time.Now(), h.next)
+	h.next = at.Add(delay)
+	return at
+}
+
+func (p *Policy) host(u *url.URL) *hostState {
+	key := u.Scheme + "://" + u.Host
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.hosts == nil {
+		p.hosts = make(map[string]*hostState)
+	}
+	host, ok := p.hosts[key]
+	if !ok {
+		host = &hostState{slots: make(chan struct{}, max(p.hostConcurrency, 1))}
+		p.hosts[key] = host
+	}
+	return host
+}
+
+// robotsGroup return the robots.txt group of the User-Agent. The failure of
+// fetching robots.txt is cached for robotsRetryInterval instead of robotsTTL,
+// so the host is disallowed for a while instead of being allowed for a day.
+func (p *Policy) robotsGroup(
+	ctx context.Context, u *url.URL, host *hostState, transport http.RoundTripper,
+) (*robotstxt.Group, error) {
+	host.mu.Lock()
+	defer host.mu.Unlock()
+	if time.Now().Before(host.robotsExpire) {
+		return host.robots, host.robotsErr
+	}
+
+	robots, err := p.fetchRobots(ctx, u, transport)
+	if err != nil {
+		slogctx.FromCtx(ctx).WarnContext(ctx, "fetch robots.txt failed",
+			slog.String("Host", u.Host),
+			slog.Any("Error", err),
+		)
+		// The canceled request is not the failure of host
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		host.robots, host.robotsErr = nil, err
+		host.robotsExpire = time.Now().Add(robotsRetryInterval)
+		return nil, err
+	}
+	host.robots, host.robotsErr = robots.FindGroup(robotsAgent(p.userAgent)), nil
+	host.robotsExpire = time.Now().Add(robotsTTL)
+	return host.robots, nil
+}
+
+func (p *Policy) fetchRobots(
+	ctx context.Context, u *url.URL, transport http.RoundTripper,
+) (*robotstxt.RobotsData, error) {
+	robotsURL := &url.URL{Scheme: u.Scheme, User: u.User, Host: u.Host, Path: "/robots.txt"}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", p.userAgent)
+
+	resp, err := (&http.Client{Transport: transport, Timeout: time.Minute}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint
+	// The 4xx means there is no robots.txt and all urls are allowed, but the
+	// 5xx may be temporary
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return robotstxt.FromResponse(resp)
+}
+
+// robotsAgent is the product token of User-Agent which is matched by the
+// robots.txt, e.g. vela of vela/1.0.
+func robotsAgent(userAgent string) string {
+	agent, _, _ := strings.Cut(userAgent, "/")
+	return strings.TrimSpace(agent)
+}
+
+func robotsPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func sleepUntil(ctx context.Context, at time.Time) error {
+	d := time.Until(at)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
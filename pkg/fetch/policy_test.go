@@ -0,0 +1,183 @@
+package fetch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+type testServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	userAgents []string
+	paths      []string
+}
+
+func newTestServer(t *testing.T, robots string) *testServer {
+	s := &testServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.userAgents = append(s.userAgents, r.Header.Get("User-Agent"))
+		s.paths = append(s.paths, r.URL.Path)
+		s.mu.Unlock()
+
+		if r.URL.Path == "/robots.txt" {
+			if robots == "" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(robots))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newTestPolicy(g *gomega.WithT) *Policy {
+	p := &Policy{robots: true, hostConcurrency: 1}
+	g.Expect(p.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+	return p
+}
+
+func get(client *http.Client, u string) error {
+	resp, err := client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint
+	_, err = io.ReadAll(resp.Body)
+	return err
+}
+
+func TestPolicy_Robots(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server := newTestServer(t, "User-agent: *\nDisallow: /private\n\nUser-agent: vela\nDisallow: /drafts\n")
+	p := newTestPolicy(g)
+	client := p.Client("")
+
+	g.Expect(get(client, server.URL+"/private/a.html")).To(gomega.Succeed())
+	g.Expect(get(client, server.URL+"/posts/a.html")).To(gomega.Succeed())
+	err := get(client, server.URL+"/drafts/a.html")
+	g.Expect(err).To(gomega.MatchError(ErrDisallowedByRobots))
+
+	// The robots.txt is fetched once, and all requests are identified
+	g.Expect(server.paths).To(gomega.Equal([]string{"/robots.txt", "/private/a.html", "/posts/a.html"}))
+	g.Expect(server.userAgents).To(gomega.HaveEach(DefaultUserAgent))
+
+	// The robots.txt is ignored if it's disabled
+	p.robots = false
+	g.Expect(get(client, server.URL+"/drafts/a.html")).To(gomega.Succeed())
+}
+
+func TestPolicy_RobotsUnavailable(t *testing.T) {
+	g := gomega.NewWithT(t)
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(int(status.Load()))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	p := newTestPolicy(g)
+	client := p.Client("")
+
+	// The 5xx disallows the host until it's retried
+	err := get(client, server.URL+"/posts/a.html")
+	g.Expect(err).To(gomega.MatchError(ErrRobotsUnavailable))
+	status.Store(http.StatusNotFound)
+	err = get(client, server.URL+"/posts/a.html")
+	g.Expect(err).To(gomega.MatchError(ErrRobotsUnavailable))
+
+	u, err := url.Parse(server.URL)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	host := p.host(u)
+	g.Expect(time.Until(host.robotsExpire)).To(gomega.BeNumerically("<=", robotsRetryInterval))
+	host.robotsExpire = time.Time{}
+	g.Expect(get(client, server.URL+"/posts/a.html")).To(gomega.Succeed())
+	g.Expect(time.Until(host.robotsExpire)).To(gomega.BeNumerically(">", robotsRetryInterval))
+
+	// The network error disallows the host too
+	server.Close()
+	release, err := p.Acquire(context.Background(), "", &url.URL{Scheme: "http", Host: u.Host, Path: "/"})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	release()
+	host.robotsExpire = time.Time{}
+	_, err = p.Acquire(context.Background(), "", &url.URL{Scheme: "http", Host: u.Host, Path: "/"})
+	g.Expect(err).To(gomega.MatchError(ErrRobotsUnavailable))
+}
+
+func TestPolicy_UserAgent(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server := newTestServer(t, "")
+	p := &Policy{userAgent: "vela-test/1.0 (+mailto:ops@example.com)"}
+	g.Expect(p.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+
+	g.Expect(get(p.Client(""), server.URL+"/a")).To(gomega.Succeed())
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/b", nil)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	req.Header.Set("User-Agent", "custom")
+	resp, err := p.Client("").Do(req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	_ = resp.Body.Close()
+
+	g.Expect(server.userAgents).To(gomega.Equal([]string{"vela-test/1.0 (+mailto:ops@example.com)", "custom"}))
+	g.Expect(robotsAgent(p.UserAgent())).To(gomega.Equal("vela-test"))
+
+	var nilPolicy *Policy
+	g.Expect(nilPolicy.UserAgent()).To(gomega.Equal(DefaultUserAgent))
+	release, err := nilPolicy.Acquire(context.Background(), "", &url.URL{Scheme: "https", Host: "example.com"})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	release()
+}
+
+func TestPolicy_Limits(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server := newTestServer(t, "User-agent: *\nCrawl-delay: 0.1\n")
+	other := newTestServer(t, "")
+	p := newTestPolicy(g)
+	p.hostDelay = 50 * time.Millisecond
+
+	// The Crawl-delay is longer than the host delay
+	start := time.Now()
+	for range 3 {
+		g.Expect(get(p.Client(""), server.URL+"/a")).To(gomega.Succeed())
+	}
+	g.Expect(time.Since(start)).To(gomega.BeNumerically(">=", 200*time.Millisecond))
+
+	// The requests per minute is shared by all hosts
+	p.requestsPerMinute = 600
+	p.next = time.Time{}
+	start = time.Now()
+	g.Expect(get(p.Client(""), other.URL+"/a")).To(gomega.Succeed())
+	g.Expect(get(p.Client(""), server.URL+"/a")).To(gomega.Succeed())
+	g.Expect(time.Since(start)).To(gomega.BeNumerically(">=", 100*time.Millisecond))
+
+	// The slot of host is held until the body is closed
+	p.robots = false
+	p.hostDelay = 0
+	p.requestsPerMinute = 0
+	resp, err := p.Client("").Get(other.URL + "/a")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = p.Acquire(ctx, "", &url.URL{Scheme: "http", Host: other.Listener.Addr().String()})
+	g.Expect(err).To(gomega.MatchError(context.DeadlineExceeded))
+	_ = resp.Body.Close()
+	release, err := p.Acquire(context.Background(), "", &url.URL{Scheme: "http", Host: other.Listener.Addr().String()})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	release()
+}
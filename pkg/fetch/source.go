@@ -0,0 +1,166 @@
+package fetch
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// sourceTransport is the transport built from the options of a source.
+type sourceTransport struct {
+	opts TransportOptions
+	// origin is the scheme://host which the authorization is sent to
+	origin        string
+	authorization string
+	// spki is the base64 sha256 of the public keys of CA file, chrome
+	// trusts them by --ignore-certificate-errors-spki-list
+	spki []string
+
+	transport http.RoundTripper
+	// jar is nil if the cookies are not persisted
+	jar *persistentJar
+}
+
+func (p *Policy) globalOptions() TransportOptions {
+	return TransportOptions{
+		Proxy:              p.proxy,
+		CAFile:             p.caFile,
+		InsecureSkipVerify: p.insecureSkipVerify,
+		CookieJar:          p.cookieJar,
+	}
+}
+
+func (p *Policy) newSourceTransport(opts TransportOptions, sourceURL string) (*sourceTransport, error) {
+	transport, err := newHTTPTransport(opts)
+	if err != nil {
+		return nil, err
+	}
+	st := &sourceTransport{opts: opts, transport: transport}
+
+	if opts.CAFile != "" {
+		_, certs, err := loadCAFile(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, cert := range certs {
+			sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			st.spki = append(st.spki, base64.StdEncoding.EncodeToString(sum[:]))
+		}
+	}
+	if opts.Auth != nil {
+		u, err := url.Parse(sourceURL)
+		if err != nil || !u.IsAbs() {
+			return nil, errAuthInvalid
+		}
+		st.authorization, err = opts.Auth.authorization()
+		if err != nil {
+			return nil, err
+		}
+		st.origin = originOf(u)
+		st.transport = &authTransport{origin: st.origin, authorization: st.authorization, base: transport}
+	}
+	if opts.CookieJar != "" {
+		st.jar, err = p.jar(opts.CookieJar)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return st, nil
+}
+
+// jar return the persistent jar of file, the sources with the same file
+// share the jar.
+func (p *Policy) jar(file string) (*persistentJar, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if jar, ok := p.jars[file]; ok {
+		return jar, nil
+	}
+
+	jar, err := newPersistentJar(file)
+	if err != nil {
+		return nil, err
+	}
+	if p.jars == nil {
+		p.jars = make(map[string]*persistentJar)
+	}
+	p.jars[file] = jar
+	return jar, nil
+}
+
+// Register the transport options of source, which override the global ones
+// for the list page and posts of source.
+func (p *Policy) Register(source string, sourceURL string, opts *TransportOptions) error {
+	register, err := p.Prepare(source, sourceURL, opts)
+	if err != nil {
+		return err
+	}
+	register()
+	return nil
+}
+
+// Prepare build the transport of source and return the func to register it,
+// the nil opts unregister the previous one. It's used to validate all sources
+// before any of them is changed.
+func (p *Policy) Prepare(source string, sourceURL string, opts *TransportOptions) (func(), error) {
+	if p == nil {
+		return func() {}, nil
+	}
+
+	var st *sourceTransport
+	if opts != nil {
+		var err error
+		st, err = p.newSourceTransport(opts.merge(p.globalOptions()), sourceURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if st == nil {
+			delete(p.sources, source)
+			return
+		}
+		if p.sources == nil {
+			p.sources = make(map[string]*sourceTransport)
+		}
+		p.sources[source] = st
+	}, nil
+}
+
+// resolve return the transport of source, or the global one.
+func (p *Policy) resolve(source string) *sourceTransport {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if st, ok := p.sources[source]; ok {
+		return st
+	}
+	return p.global
+}
+
+// Jar return the cookie jar of source, it's nil if the cookies are not
+// persisted.
+func (p *Policy) Jar(source string) http.CookieJar {
+	st := p.resolve(source)
+	if st == nil || st.jar == nil {
+		return nil
+	}
+	return st.jar
+}
+
+// Client return the http client which sends the requests of source by the
+// policy.
+func (p *Policy) Client(source string) *http.Client {
+	return &http.Client{
+		Transport: p.Transport(source, nil),
+		Jar:       p.Jar(source),
+		Timeout:   time.Minute,
+	}
+}
@@ -0,0 +1,62 @@
+package fetch
+
+import (
+	"io"
+	"net/http"
+)
+
+// transport is the http.RoundTripper which sends the requests by the policy.
+type transport struct {
+	policy *Policy
+	base   http.RoundTripper
+}
+
+// Transport return the http.RoundTripper which sends the requests of source
+// by the policy with base, the User-Agent is set if the request doesn't have
+// one. The base defaults to the transport of source.
+func (p *Policy) Transport(source string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+		if st := p.resolve(source); st != nil {
+			base = st.transport
+		}
+	}
+	return &transport{policy: p, base: base}
+}
+
+// RoundTrip implement http.RoundTripper
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.policy.acquire(req.Context(), req.URL, t.base)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.policy.UserAgent())
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	// The host slot is held until the body is read
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releaseBody release the host slot once it's closed.
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+	done    bool
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	if !b.done {
+		b.done = true
+		b.release()
+	}
+	return err
+}
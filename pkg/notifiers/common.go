@@ -0,0 +1,138 @@
+package notifiers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+const (
+	// summaryExcerptRunes is the max runes of summary included in a chat message.
+	summaryExcerptRunes = 300
+
+	defaultHTTPTimeout = 30 * time.Second
+)
+
+var errUnexpectedStatus = errors.New("unexpected response status")
+
+// filterResults return the results which belong to one of domains and have
+// one of tags, the empty domains or tags matches all.
+func filterResults(results []*storage.SummaryResult, domains []string, tags []string) []*storage.SummaryResult {
+	domains = compactValues(domains)
+	tags = compactValues(tags)
+	if len(domains) == 0 && len(tags) == 0 {
+		return results
+	}
+
+	filtered := make([]*storage.SummaryResult, 0, len(results))
+	for _, result := range results {
+		if len(domains) > 0 && !slices.Contains(domains, result.Domain) {
+			continue
+		}
+		if len(tags) > 0 && !slices.ContainsFunc(result.Tags, func(tag string) bool {
+			return slices.Contains(tags, tag)
+		}) {
+			continue
+		}
+		filtered = append(filtered, result)
+	}
+	return filtered
+}
+
+// compactValues trims all values and drops the empty ones, the property
+// value with empty default will be injected as [""].
+func compactValues(values []string) []string {
+	ret := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		ret = append(ret, v)
+	}
+	return ret
+}
+
+func messageTitle(results []*storage.SummaryResult) string {
+	return fmt.Sprintf("vela: %d new posts", len(results))
+}
+
+// renderText render all results into one plain text message. The summary of
+// each post is truncated to maxSummaryRunes, or keep all if it's not positive.
+func renderText(results []*storage.SummaryResult, maxSummaryRunes int) string {
+	var b strings.Builder
+	b.WriteString(messageTitle(results))
+	for _, result := range results {
+		fmt.Fprintf(&b, "\n\n[%s] %s\n%s", result.Domain, result.Title, result.Path)
+		if result.Summary != "" {
+			b.WriteString("\n")
+			b.WriteString(truncateRunes(result.Summary, maxSummaryRunes))
+		}
+	}
+	return b.String()
+}
+
+// renderMarkdown render all results into one markdown message.
+func renderMarkdown(results []*storage.SummaryResult, maxSummaryRunes int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s", messageTitle(results))
+	for _, result := range results {
+		fmt.Fprintf(&b, "\n\n**[%s]** [%s](%s)", result.Domain, result.Title, result.Path)
+		if result.Summary != "" {
+			b.WriteString("\n\n")
+			b.WriteString(truncateRunes(result.Summary, maxSummaryRunes))
+		}
+	}
+	return b.String()
+}
+
+func truncateRunes(s string, maxRunes int) string {
+	if maxRunes <= 0 || utf8.RuneCountInString(s) <= maxRunes {
+		return s
+	}
+	return string([]rune(s)[:maxRunes]) + "..."
+}
+
+// postJSON will post the body as json to url, and decode the response into
+// out if it's not nil.
+func postJSON(ctx context.Context, client *http.Client, url string, body any, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: %d: %s", errUnexpectedStatus, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	if out == nil || len(bytes.TrimSpace(respBody)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
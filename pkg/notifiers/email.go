@@ -0,0 +1,104 @@
+package notifiers
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anyvoxel/airmid/anvil"
+	airapp "github.com/anyvoxel/airmid/app"
+	"github.com/anyvoxel/airmid/ioc"
+
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+func init() {
+	anvil.Must(airapp.RegisterBeanDefinition(
+		"vela.notifiers.email",
+		ioc.MustNewBeanDefinition(
+			reflect.TypeFor[*emailNotifier](),
+		),
+	))
+}
+
+// emailNotifier send all new results as one mail by SMTP.
+type emailNotifier struct {
+	host     string   `airmid:"value:${vela.notifiers.email.host:=}"`
+	port     int      `airmid:"value:${vela.notifiers.email.port:=587}"`
+	username string   `airmid:"value:${vela.notifiers.email.username:=}"`
+	password string   `airmid:"value:${vela.notifiers.email.password:=}"`
+	from     string   `airmid:"value:${vela.notifiers.email.from:=}"`
+	to       []string `airmid:"value:${vela.notifiers.email.to:=}"`
+	domains  []string `airmid:"value:${vela.notifiers.email.domains:=}"`
+	tags     []string `airmid:"value:${vela.notifiers.email.tags:=}"`
+
+	// sendMail is used to replace smtp.SendMail in test
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+var (
+	_ ioc.InitializingBean = (*emailNotifier)(nil)
+	_ Notifier             = (*emailNotifier)(nil)
+)
+
+// AfterPropertiesSet implement InitializingBean
+func (n *emailNotifier) AfterPropertiesSet(_ context.Context) error {
+	n.sendMail = smtp.SendMail
+	return nil
+}
+
+// Name implement Notifier.Name
+func (*emailNotifier) Name() string { return "email" }
+
+// Notify implement Notifier.Notify
+func (n *emailNotifier) Notify(_ context.Context, results []*storage.SummaryResult) error {
+	host := strings.TrimSpace(n.host)
+	to := compactValues(n.to)
+	if host == "" || len(to) == 0 {
+		return nil
+	}
+
+	results = filterResults(results, n.domains, n.tags)
+	if len(results) == 0 {
+		return nil
+	}
+
+	from := strings.TrimSpace(n.from)
+	if from == "" {
+		from = n.username
+	}
+
+	var auth smtp.Auth
+	if n.username != "" {
+		auth = smtp.PlainAuth("", n.username, n.password, host)
+	}
+
+	return n.sendMail(
+		net.JoinHostPort(host, strconv.Itoa(n.port)),
+		auth,
+		from,
+		to,
+		buildMail(from, to, messageTitle(results), renderText(results, 0), time.Now()),
+	)
+}
+
+func buildMail(from string, to []string, subject string, body string, now time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,128 @@
+package notifiers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/anyvoxel/airmid/anvil"
+	airapp "github.com/anyvoxel/airmid/app"
+	"github.com/anyvoxel/airmid/ioc"
+
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+func init() {
+	anvil.Must(airapp.RegisterBeanDefinition(
+		"vela.notifiers.incomingWebhook",
+		ioc.MustNewBeanDefinition(
+			reflect.TypeFor[*incomingWebhookNotifier](),
+		),
+	))
+}
+
+const (
+	flavorSlack    = "slack"
+	flavorFeishu   = "feishu"
+	flavorDingTalk = "dingtalk"
+)
+
+var (
+	errUnknownFlavor = errors.New("unknown incoming webhook flavor")
+	errChatResponse  = errors.New("incoming webhook response error")
+)
+
+// chatResponse is the response of feishu & dingtalk, both of them will
+// return http 200 with an error code in body.
+type chatResponse struct {
+	Code    int    `json:"code"`
+	Msg     string `json:"msg"`
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// incomingWebhookNotifier post all new results as one chat message to a
+// Slack/Feishu/DingTalk style incoming webhook.
+type incomingWebhookNotifier struct {
+	url     string   `airmid:"value:${vela.notifiers.incoming_webhook.url:=}"`
+	flavor  string   `airmid:"value:${vela.notifiers.incoming_webhook.flavor:=slack}"`
+	domains []string `airmid:"value:${vela.notifiers.incoming_webhook.domains:=}"`
+	tags    []string `airmid:"value:${vela.notifiers.incoming_webhook.tags:=}"`
+
+	client *http.Client
+}
+
+var (
+	_ ioc.InitializingBean = (*incomingWebhookNotifier)(nil)
+	_ Notifier             = (*incomingWebhookNotifier)(nil)
+)
+
+// AfterPropertiesSet implement InitializingBean
+func (n *incomingWebhookNotifier) AfterPropertiesSet(_ context.Context) error {
+	switch n.flavor {
+	case flavorSlack, flavorFeishu, flavorDingTalk:
+	default:
+		return fmt.Errorf("%w: %q", errUnknownFlavor, n.flavor)
+	}
+
+	n.client = &http.Client{Timeout: defaultHTTPTimeout}
+	return nil
+}
+
+// Name implement Notifier.Name
+func (*incomingWebhookNotifier) Name() string { return "incoming_webhook" }
+
+// Notify implement Notifier.Notify
+func (n *incomingWebhookNotifier) Notify(ctx context.Context, results []*storage.SummaryResult) error {
+	url := strings.TrimSpace(n.url)
+	if url == "" {
+		return nil
+	}
+
+	results = filterResults(results, n.domains, n.tags)
+	if len(results) == 0 {
+		return nil
+	}
+
+	switch n.flavor {
+	case flavorFeishu:
+		return n.post(ctx, url, map[string]any{
+			"msg_type": "text",
+			"content": map[string]string{
+				"text": renderText(results, summaryExcerptRunes),
+			},
+		})
+	case flavorDingTalk:
+		return n.post(ctx, url, map[string]any{
+			"msgtype": "markdown",
+			"markdown": map[string]string{
+				"title": messageTitle(results),
+				"text":  renderMarkdown(results, summaryExcerptRunes),
+			},
+		})
+	default:
+		// Slack will response with plain text 'ok'
+		return postJSON(ctx, n.client, url, map[string]string{
+			"text": renderText(results, summaryExcerptRunes),
+		}, nil)
+	}
+}
+
+func (n *incomingWebhookNotifier) post(ctx context.Context, url string, body any) error {
+	var resp chatResponse
+	err := postJSON(ctx, n.client, url, body, &resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.Code != 0 {
+		return fmt.Errorf("%w: %d: %s", errChatResponse, resp.Code, resp.Msg)
+	}
+	if resp.ErrCode != 0 {
+		return fmt.Errorf("%w: %d: %s", errChatResponse, resp.ErrCode, resp.ErrMsg)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package notifiers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+func testResults() []*storage.SummaryResult {
+	return []*storage.SummaryResult{
+		{Domain: "brooker", Title: "t1", Path: "https://brooker.co.za/blog/1", Summary: "s1"},
+		{Domain: "charap", Title: "t2", Path: "https://charap.co/2", Summary: "s2"},
+	}
+}
+
+// newStubServer return a http stub which record all request bodies and
+// response with resp.
+func newStubServer(t *testing.T, resp string) (*httptest.Server, *[]string, *[]string) {
+	bodies := []string{}
+	paths := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		paths = append(paths, r.URL.Path)
+		_, _ = w.Write([]byte(resp))
+	}))
+	t.Cleanup(server.Close)
+	return server, &bodies, &paths
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server, bodies, _ := newStubServer(t, "")
+
+	n := &webhookNotifier{url: server.URL, domains: []string{"charap"}}
+	g.Expect(n.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+	g.Expect(n.Notify(context.Background(), testResults())).To(gomega.Succeed())
+
+	g.Expect(*bodies).To(gomega.HaveLen(1))
+	var payload webhookPayload
+	g.Expect(json.Unmarshal([]byte((*bodies)[0]), &payload)).To(gomega.Succeed())
+	g.Expect(payload.Count).To(gomega.Equal(1))
+	g.Expect(payload.Results[0].Path).To(gomega.Equal("https://charap.co/2"))
+}
+
+func TestWebhookNotifier_NotifyWithoutMatchedResults(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server, bodies, _ := newStubServer(t, "")
+
+	n := &webhookNotifier{url: server.URL, domains: []string{"unknown"}}
+	g.Expect(n.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+	g.Expect(n.Notify(context.Background(), testResults())).To(gomega.Succeed())
+	g.Expect(*bodies).To(gomega.BeEmpty())
+}
+
+func TestWebhookNotifier_NotifyUnexpectedStatus(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	n := &webhookNotifier{url: server.URL}
+	g.Expect(n.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+	err := n.Notify(context.Background(), testResults())
+	g.Expect(err).To(gomega.MatchError(errUnexpectedStatus))
+}
+
+func TestIncomingWebhookNotifier_Notify(t *testing.T) {
+	type testCase struct {
+		flavor string
+		resp   string
+		expect string
+		err    error
+	}
+	testCases := []testCase{
+		{flavor: flavorSlack, resp: "ok", expect: `"text":"vela: 2 new posts`},
+		{flavor: flavorFeishu, resp: `{"code":0,"msg":"success"}`, expect: `"msg_type":"text"`},
+		{flavor: flavorFeishu, resp: `{"code":19001,"msg":"param invalid"}`, err: errChatResponse},
+		{flavor: flavorDingTalk, resp: `{"errcode":0,"errmsg":"ok"}`, expect: `"msgtype":"markdown"`},
+		{flavor: flavorDingTalk, resp: `{"errcode":310000,"errmsg":"keywords not in content"}`, err: errChatResponse},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.flavor, func(t *testing.T) {
+			g := gomega.NewWithT(t)
+			server, bodies, _ := newStubServer(t, tc.resp)
+
+			n := &incomingWebhookNotifier{url: server.URL, flavor: tc.flavor}
+			g.Expect(n.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+			err := n.Notify(context.Background(), testResults())
+			if tc.err != nil {
+				g.Expect(err).To(gomega.MatchError(tc.err))
+				return
+			}
+
+			g.Expect(err).ToNot(gomega.HaveOccurred())
+			g.Expect(*bodies).To(gomega.HaveLen(1))
+			g.Expect((*bodies)[0]).To(gomega.ContainSubstring(tc.expect))
+		})
+	}
+}
+
+func TestIncomingWebhookNotifier_UnknownFlavor(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	n := &incomingWebhookNotifier{flavor: "teams"}
+	g.Expect(n.AfterPropertiesSet(context.Background())).To(gomega.MatchError(errUnknownFlavor))
+}
+
+func TestTelegramNotifier_Notify(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server, bodies, paths := newStubServer(t, `{"ok":true}`)
+
+	n := &telegramNotifier{apiURL: server.URL, token: "123:abc", chatID: "42"}
+	g.Expect(n.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+	g.Expect(n.Notify(context.Background(), testResults())).To(gomega.Succeed())
+
+	g.Expect(*paths).To(gomega.Equal([]string{"/bot123:abc/sendMessage"}))
+	var body map[string]any
+	g.Expect(json.Unmarshal([]byte((*bodies)[0]), &body)).To(gomega.Succeed())
+	g.Expect(body["chat_id"]).To(gomega.Equal("42"))
+	g.Expect(body["text"]).To(gomega.ContainSubstring("[brooker] t1"))
+}
+
+func TestTelegramNotifier_NotifyFailed(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server, _, _ := newStubServer(t, `{"ok":false,"description":"chat not found"}`)
+
+	n := &telegramNotifier{apiURL: server.URL, token: "123:abc", chatID: "42"}
+	g.Expect(n.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+	g.Expect(n.Notify(context.Background(), testResults())).To(gomega.MatchError(errTelegramResponse))
+}
+
+func TestEmailNotifier_Notify(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	n := &emailNotifier{
+		host: "localhost",
+		port: 2525,
+		from: "vela@example.com",
+		to:   []string{"a@example.com", " ", "b@example.com"},
+	}
+	g.Expect(n.AfterPropertiesSet(context.Background())).To(gomega.Succeed())
+
+	var (
+		gotAddr string
+		gotTo   []string
+		gotMsg  string
+	)
+	n.sendMail = func(addr string, _ smtp.Auth, _ string, to []string, msg []byte) error {
+		gotAddr, gotTo, gotMsg = addr, to, string(msg)
+		return nil
+	}
+	g.Expect(n.Notify(context.Background(), testResults())).To(gomega.Succeed())
+
+	g.Expect(gotAddr).To(gomega.Equal("localhost:2525"))
+	g.Expect(gotTo).To(gomega.Equal([]string{"a@example.com", "b@example.com"}))
+	g.Expect(gotMsg).To(gomega.ContainSubstring("To: a@example.com, b@example.com\r\n"))
+	g.Expect(gotMsg).To(gomega.ContainSubstring("Subject: vela: 2 new posts\r\n"))
+	g.Expect(strings.Count(gotMsg, "https://")).To(gomega.Equal(2))
+}
+
+func TestNotifier_NotConfigured(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	ns := []Notifier{
+		&webhookNotifier{},
+		&incomingWebhookNotifier{flavor: flavorSlack},
+		&telegramNotifier{},
+		&emailNotifier{},
+	}
+	for _, n := range ns {
+		g.Expect(n.Notify(context.Background(), testResults())).To(gomega.Succeed(), n.Name())
+	}
+}
+
+func TestFilterResults(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	results := testResults()
+	results[0].Tags = []string{"consensus", "databases"}
+	results[1].Tags = []string{"llms"}
+
+	g.Expect(filterResults(results, []string{""}, []string{""})).To(gomega.HaveLen(2))
+	g.Expect(filterResults(results, nil, []string{"consensus"})).To(gomega.Equal(results[:1]))
+	g.Expect(filterResults(results, []string{"charap"}, []string{"llms", "security"})).To(gomega.Equal(results[1:]))
+	g.Expect(filterResults(results, []string{"charap"}, []string{"consensus"})).To(gomega.BeEmpty())
+}
@@ -0,0 +1,93 @@
+package notifiers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/anyvoxel/airmid/anvil"
+	airapp "github.com/anyvoxel/airmid/app"
+	"github.com/anyvoxel/airmid/ioc"
+
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+func init() {
+	anvil.Must(airapp.RegisterBeanDefinition(
+		"vela.notifiers.telegram",
+		ioc.MustNewBeanDefinition(
+			reflect.TypeFor[*telegramNotifier](),
+		),
+	))
+}
+
+// telegramMaxMessageRunes is the max length of telegram text message.
+const telegramMaxMessageRunes = 4096
+
+var errTelegramResponse = errors.New("telegram response error")
+
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+// telegramNotifier send all new results as one message by telegram bot.
+type telegramNotifier struct {
+	apiURL  string   `airmid:"value:${vela.notifiers.telegram.api_url:=https://api.telegram.org}"`
+	token   string   `airmid:"value:${vela.notifiers.telegram.token:=}"`
+	chatID  string   `airmid:"value:${vela.notifiers.telegram.chat_id:=}"`
+	domains []string `airmid:"value:${vela.notifiers.telegram.domains:=}"`
+	tags    []string `airmid:"value:${vela.notifiers.telegram.tags:=}"`
+
+	client *http.Client
+}
+
+var (
+	_ ioc.InitializingBean = (*telegramNotifier)(nil)
+	_ Notifier             = (*telegramNotifier)(nil)
+)
+
+// AfterPropertiesSet implement InitializingBean
+func (n *telegramNotifier) AfterPropertiesSet(_ context.Context) error {
+	n.client = &http.Client{Timeout: defaultHTTPTimeout}
+	return nil
+}
+
+// Name implement Notifier.Name
+func (*telegramNotifier) Name() string { return "telegram" }
+
+// Notify implement Notifier.Notify
+func (n *telegramNotifier) Notify(ctx context.Context, results []*storage.SummaryResult) error {
+	if strings.TrimSpace(n.token) == "" || strings.TrimSpace(n.chatID) == "" {
+		return nil
+	}
+
+	results = filterResults(results, n.domains, n.tags)
+	if len(results) == 0 {
+		return nil
+	}
+
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimRight(n.apiURL, "/"), strings.TrimSpace(n.token))
+	var resp telegramResponse
+	err := postJSON(ctx, n.client, endpoint, map[string]any{
+		"chat_id":                  strings.TrimSpace(n.chatID),
+		"text":                     truncateRunes(renderText(results, summaryExcerptRunes), telegramMaxMessageRunes-3),
+		"disable_web_page_preview": true,
+	}, &resp)
+	if err != nil {
+		// The bot token is part of the url, don't leak it into logs.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = strings.ReplaceAll(urlErr.URL, strings.TrimSpace(n.token), "<redacted>")
+		}
+		return err
+	}
+	if !resp.OK {
+		return fmt.Errorf("%w: %s", errTelegramResponse, resp.Description)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+// Package notifiers implement all notification sinks.
+package notifiers
+
+import (
+	"context"
+
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+// Notifier is used to deliver new summaries to an external sink.
+type Notifier interface {
+	// Name return unique notifier name
+	Name() string
+
+	// Notify will deliver all new results of one run as a single message.
+	// It should do nothing when the sink is not configured.
+	Notify(ctx context.Context, results []*storage.SummaryResult) error
+}
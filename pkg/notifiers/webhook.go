@@ -0,0 +1,72 @@
+package notifiers
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/anyvoxel/airmid/anvil"
+	airapp "github.com/anyvoxel/airmid/app"
+	"github.com/anyvoxel/airmid/ioc"
+
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+func init() {
+	anvil.Must(airapp.RegisterBeanDefinition(
+		"vela.notifiers.webhook",
+		ioc.MustNewBeanDefinition(
+			reflect.TypeFor[*webhookNotifier](),
+		),
+	))
+}
+
+// webhookPayload is the json body posted to the generic webhook.
+type webhookPayload struct {
+	Title   string                   `json:"title"`
+	Count   int                      `json:"count"`
+	Results []*storage.SummaryResult `json:"results"`
+}
+
+// webhookNotifier post all new results as a json document to the url.
+type webhookNotifier struct {
+	url     string   `airmid:"value:${vela.notifiers.webhook.url:=}"`
+	domains []string `airmid:"value:${vela.notifiers.webhook.domains:=}"`
+	tags    []string `airmid:"value:${vela.notifiers.webhook.tags:=}"`
+
+	client *http.Client
+}
+
+var (
+	_ ioc.InitializingBean = (*webhookNotifier)(nil)
+	_ Notifier             = (*webhookNotifier)(nil)
+)
+
+// AfterPropertiesSet implement InitializingBean
+func (n *webhookNotifier) AfterPropertiesSet(_ context.Context) error {
+	n.client = &http.Client{Timeout: defaultHTTPTimeout}
+	return nil
+}
+
+// Name implement Notifier.Name
+func (*webhookNotifier) Name() string { return "webhook" }
+
+// Notify implement Notifier.Notify
+func (n *webhookNotifier) Notify(ctx context.Context, results []*storage.SummaryResult) error {
+	url := strings.TrimSpace(n.url)
+	if url == "" {
+		return nil
+	}
+
+	results = filterResults(results, n.domains, n.tags)
+	if len(results) == 0 {
+		return nil
+	}
+
+	return postJSON(ctx, n.client, url, &webhookPayload{
+		Title:   messageTitle(results),
+		Count:   len(results),
+		Results: results,
+	}, nil)
+}
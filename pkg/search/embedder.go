@@ -0,0 +1,120 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+)
+
+const defaultEmbeddingBaseURL = "https://api.openai.com/v1"
+
+var errEmbeddingResponse = errors.New("unexpected embedding response")
+
+// openAIEmbedder call the OpenAI compatible embeddings endpoint.
+type openAIEmbedder struct {
+	apiKey  string
+	model   string
+	baseURL string
+	client  *http.Client
+}
+
+var _ embedding.Embedder = (*openAIEmbedder)(nil)
+
+// newOpenAIEmbedder create the embedder from OPENAI_*_EMBEDDING env, it
+// return nil if the model is not configured.
+func newOpenAIEmbedder() *openAIEmbedder {
+	model := strings.TrimSpace(os.Getenv("OPENAI_MODEL_EMBEDDING"))
+	if model == "" {
+		return nil
+	}
+
+	baseURL := strings.TrimSpace(os.Getenv("OPENAI_BASE_URL_EMBEDDING"))
+	if baseURL == "" {
+		baseURL = defaultEmbeddingBaseURL
+	}
+	return &openAIEmbedder{
+		apiKey:  os.Getenv("OPENAI_API_KEY_EMBEDDING"),
+		model:   model,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: time.Minute},
+	}
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// EmbedStrings implement embedding.Embedder
+func (e *openAIEmbedder) EmbedStrings(
+	ctx context.Context, texts []string, _ ...embedding.Option,
+) ([][]float64, error) {
+	b, err := json.Marshal(&embeddingRequest{Model: e.model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/embeddings", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var out embeddingResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("%w: status %d: %w", errEmbeddingResponse, resp.StatusCode, err)
+	}
+	if out.Error != nil {
+		return nil, fmt.Errorf("%w: %s", errEmbeddingResponse, out.Error.Message)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%w: status %d", errEmbeddingResponse, resp.StatusCode)
+	}
+
+	vectors := make([][]float64, len(texts))
+	for _, item := range out.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("%w: index %d out of range", errEmbeddingResponse, item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	for i, vector := range vectors {
+		if len(vector) == 0 {
+			return nil, fmt.Errorf("%w: missing embedding of input %d", errEmbeddingResponse, i)
+		}
+	}
+	return vectors, nil
+}
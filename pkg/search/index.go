@@ -0,0 +1,166 @@
+package search
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// indexEntry is a line of the index file.
+type indexEntry struct {
+	Path   string    `json:"path"`
+	Model  string    `json:"model"`
+	Vector []float64 `json:"vector"`
+}
+
+// vectorIndex is a flat file index, the vectors are appended to a jsonl
+// file and searched by brute force which is fast enough for thousands of posts.
+type vectorIndex struct {
+	filePath string
+	model    string
+	vectors  map[string][]float64
+
+	// size and modTime are the stat of the loaded file, the file appended by
+	// another process makes the index stale
+	size    int64
+	modTime time.Time
+}
+
+// loadVectorIndex read the index file, the entries embedded by another
+// model are ignored so they will be indexed again.
+func loadVectorIndex(filePath string, model string) (*vectorIndex, error) {
+	idx := &vectorIndex{
+		filePath: filePath,
+		model:    model,
+		vectors:  map[string][]float64{},
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return idx, nil
+		}
+		return nil, err
+	}
+	defer f.Close() //nolint
+
+	err = idx.stat(f)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(f)
+	for {
+		var entry indexEntry
+		err := decoder.Decode(&entry)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return idx, nil
+			}
+			return nil, err
+		}
+
+		if entry.Model != model {
+			continue
+		}
+		idx.vectors[entry.Path] = entry.Vector
+	}
+}
+
+func (idx *vectorIndex) stat(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	idx.size, idx.modTime = info.Size(), info.ModTime()
+	return nil
+}
+
+// Stale return true if the index file is changed since it's loaded or
+// appended by this index.
+func (idx *vectorIndex) Stale() bool {
+	info, err := os.Stat(idx.filePath)
+	if err != nil {
+		return idx.size != 0
+	}
+	return info.Size() != idx.size || !info.ModTime().Equal(idx.modTime)
+}
+
+// Contains return true if the path is indexed.
+func (idx *vectorIndex) Contains(path string) bool {
+	_, ok := idx.vectors[path]
+	return ok
+}
+
+// Len return the number of indexed paths.
+func (idx *vectorIndex) Len() int {
+	return len(idx.vectors)
+}
+
+// Append persist the entries to the index file.
+func (idx *vectorIndex) Append(paths []string, vectors [][]float64) error {
+	err := os.MkdirAll(filepath.Dir(idx.filePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(idx.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint
+
+	encoder := json.NewEncoder(f)
+	for i, path := range paths {
+		err = encoder.Encode(&indexEntry{Path: path, Model: idx.model, Vector: vectors[i]})
+		if err != nil {
+			return err
+		}
+		idx.vectors[path] = vectors[i]
+	}
+	return idx.stat(f)
+}
+
+type scoredPath struct {
+	path  string
+	score float64
+}
+
+// Nearest return the paths ordered by cosine similarity to the vector.
+func (idx *vectorIndex) Nearest(vector []float64) []scoredPath {
+	scored := make([]scoredPath, 0, len(idx.vectors))
+	for path, v := range idx.vectors {
+		scored = append(scored, scoredPath{path: path, score: cosine(vector, v)})
+	}
+
+	slices.SortFunc(scored, func(a, b scoredPath) int {
+		if c := cmp.Compare(b.score, a.score); c != 0 {
+			return c
+		}
+		return strings.Compare(a.path, b.path)
+	})
+	return scored
+}
+
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
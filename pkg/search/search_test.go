@@ -0,0 +1,175 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+
+	"github.com/anyvoxel/vela/pkg/storage"
+	mock_storage "github.com/anyvoxel/vela/pkg/storage/mocks"
+)
+
+// keywordEmbedder embed the text by counting the keywords.
+type keywordEmbedder struct {
+	keywords []string
+	calls    int
+	texts    int
+}
+
+func (e *keywordEmbedder) EmbedStrings(_ context.Context, texts []string, _ ...embedding.Option) ([][]float64, error) {
+	e.calls++
+	e.texts += len(texts)
+
+	vectors := make([][]float64, 0, len(texts))
+	for _, text := range texts {
+		vector := make([]float64, len(e.keywords))
+		for i, keyword := range e.keywords {
+			vector[i] = float64(strings.Count(strings.ToLower(text), keyword))
+		}
+		vectors = append(vectors, vector)
+	}
+	return vectors, nil
+}
+
+func testResults() []*storage.SummaryResult {
+	return []*storage.SummaryResult{
+		{Domain: "brooker", Path: "https://brooker.co.za/blog/1", Title: "Raft snapshotting", Summary: "raft raft"},
+		{Domain: "charap", Path: "https://charap.co/2", Title: "Paxos made live", Summary: "paxos"},
+		{Domain: "simonwillison", Path: "https://simonwillison.net/3", Title: "LLM agents", Summary: "llm"},
+		{Domain: "uberblog", Path: "https://www.uber.com/4", Title: "Skipped", Status: storage.StatusSkippedLowRelevance},
+	}
+}
+
+func TestSearcher_IndexAndSearch(t *testing.T) {
+	g := gomega.NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	s := mock_storage.NewMockStorage(mockCtrl)
+	s.EXPECT().Query(gomock.Any(), &storage.Query{}).Return(testResults(), nil).AnyTimes()
+
+	indexFile := filepath.Join(t.TempDir(), "index", "embeddings.jsonl")
+	embedder := &keywordEmbedder{keywords: []string{"raft", "paxos", "llm"}}
+	searcher, err := NewSearcher(s, embedder, "keyword", indexFile)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	indexed, err := searcher.Index(context.Background())
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(indexed).To(gomega.Equal(3))
+
+	// The indexed results are skipped
+	indexed, err = searcher.Index(context.Background())
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(indexed).To(gomega.Equal(0))
+	g.Expect(embedder.texts).To(gomega.Equal(3))
+
+	hits, err := searcher.Search(context.Background(), "what about raft?", 2)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(hits).To(gomega.HaveLen(2))
+	g.Expect(hits[0].Result.Path).To(gomega.Equal("https://brooker.co.za/blog/1"))
+	g.Expect(hits[0].Score).To(gomega.BeNumerically("~", 1, 1e-9))
+
+	// The index is loaded from file
+	searcher, err = NewSearcher(s, embedder, "keyword", indexFile)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	indexed, err = searcher.Index(context.Background())
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(indexed).To(gomega.Equal(0))
+
+	// The results embedded by another model are indexed again
+	searcher, err = NewSearcher(s, embedder, "another", indexFile)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	indexed, err = searcher.Index(context.Background())
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(indexed).To(gomega.Equal(3))
+}
+
+func TestSearcher_ReloadStaleIndex(t *testing.T) {
+	g := gomega.NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	s := mock_storage.NewMockStorage(mockCtrl)
+	s.EXPECT().Query(gomock.Any(), &storage.Query{}).Return(testResults(), nil).AnyTimes()
+
+	// The reader is loaded before the writer indexes, e.g. the serve command
+	// runs along with the daemon
+	indexFile := filepath.Join(t.TempDir(), "index", "embeddings.jsonl")
+	embedder := &keywordEmbedder{keywords: []string{"raft", "paxos", "llm"}}
+	reader, err := NewSearcher(s, embedder, "keyword", indexFile)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	hits, err := reader.Search(context.Background(), "raft", 1)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(hits).To(gomega.BeEmpty())
+
+	writer, err := NewSearcher(s, embedder, "keyword", indexFile)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(writer.Index(context.Background())).To(gomega.Equal(3))
+
+	hits, err = reader.Search(context.Background(), "raft", 1)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(hits).To(gomega.HaveLen(1))
+	g.Expect(hits[0].Result.Path).To(gomega.Equal("https://brooker.co.za/blog/1"))
+}
+
+func TestHit_JSON(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	b, err := json.Marshal(&Hit{Score: 0.5, Result: &storage.SummaryResult{Path: "https://charap.co/2"}})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	var hit Hit
+	g.Expect(json.Unmarshal(b, &hit)).To(gomega.Succeed())
+	g.Expect(hit.Score).To(gomega.Equal(0.5))
+	g.Expect(hit.Result.Path).To(gomega.Equal("https://charap.co/2"))
+}
+
+func TestSearcher_NotConfigured(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	searcher := &searcherImpl{}
+	indexed, err := searcher.Index(context.Background())
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(indexed).To(gomega.Equal(0))
+
+	_, err = searcher.Search(context.Background(), "raft", 10)
+	g.Expect(err).To(gomega.MatchError(ErrNotConfigured))
+}
+
+func TestOpenAIEmbedder_EmbedStrings(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	var (
+		gotPath string
+		gotAuth string
+		gotReq  embeddingRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &gotReq)
+		_, _ = w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("OPENAI_MODEL_EMBEDDING", "text-embedding-3-small")
+	t.Setenv("OPENAI_BASE_URL_EMBEDDING", server.URL+"/v1/")
+	t.Setenv("OPENAI_API_KEY_EMBEDDING", "sk-test")
+	embedder := newOpenAIEmbedder()
+	g.Expect(embedder).ToNot(gomega.BeNil())
+
+	vectors, err := embedder.EmbedStrings(context.Background(), []string{"a", "b"})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(vectors).To(gomega.Equal([][]float64{{1, 0}, {0, 1}}))
+	g.Expect(gotPath).To(gomega.Equal("/v1/embeddings"))
+	g.Expect(gotAuth).To(gomega.Equal("Bearer sk-test"))
+	g.Expect(gotReq).To(gomega.Equal(embeddingRequest{Model: "text-embedding-3-small", Input: []string{"a", "b"}}))
+}
@@ -0,0 +1,215 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/anyvoxel/airmid/anvil"
+	airapp "github.com/anyvoxel/airmid/app"
+	"github.com/anyvoxel/airmid/ioc"
+	"github.com/cloudwego/eino/components/embedding"
+	slogctx "github.com/veqryn/slog-context"
+
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+func init() {
+	anvil.Must(airapp.RegisterBeanDefinition(
+		"vela.search.searcher",
+		ioc.MustNewBeanDefinition(
+			reflect.TypeFor[*searcherImpl](),
+		),
+	))
+}
+
+const (
+	// embedBatchSize is the max number of texts embedded in one request.
+	embedBatchSize = 32
+)
+
+var (
+	// ErrNotConfigured is returned by Search if the embedding model is not set.
+	ErrNotConfigured = errors.New("search is not configured, set OPENAI_MODEL_EMBEDDING")
+	// ErrEmptyQuery is returned by Search if the query is blank.
+	ErrEmptyQuery = errors.New("search query is empty")
+)
+
+// searcherImpl embed the summaries into a flat file index.
+type searcherImpl struct {
+	store     storage.Storage `airmid:"autowire:vela.storage.storage"`
+	indexFile string          `airmid:"value:${vela.search.index_file:=./index/embeddings.jsonl}"`
+
+	embedder embedding.Embedder
+	model    string
+
+	mu    sync.Mutex
+	index *vectorIndex
+}
+
+// NewSearcher creates a new Searcher with the given embedder.
+// This is intended for testing purposes.
+func NewSearcher(store storage.Storage, embedder embedding.Embedder, model string, indexFile string) (Searcher, error) {
+	s := &searcherImpl{
+		store:     store,
+		indexFile: indexFile,
+		embedder:  embedder,
+		model:     model,
+	}
+	return s, s.loadIndex()
+}
+
+var (
+	_ ioc.InitializingBean = (*searcherImpl)(nil)
+	_ Searcher             = (*searcherImpl)(nil)
+)
+
+// AfterPropertiesSet implement InitializingBean
+func (s *searcherImpl) AfterPropertiesSet(_ context.Context) error {
+	embedder := newOpenAIEmbedder()
+	if embedder == nil {
+		return nil
+	}
+
+	s.embedder = embedder
+	s.model = embedder.model
+	return s.loadIndex()
+}
+
+func (s *searcherImpl) loadIndex() error {
+	index, err := loadVectorIndex(s.indexFile, s.model)
+	if err != nil {
+		return fmt.Errorf("load vela.search.index_file %q failed: %w", s.indexFile, err)
+	}
+	s.index = index
+	return nil
+}
+
+// reloadIfStale reload the index which is appended by another process, e.g.
+// the serve command reads the vectors indexed by the daemon.
+func (s *searcherImpl) reloadIfStale() error {
+	if !s.index.Stale() {
+		return nil
+	}
+	return s.loadIndex()
+}
+
+// Index implement Searcher.Index
+func (s *searcherImpl) Index(ctx context.Context) (int, error) {
+	if s.embedder == nil {
+		return 0, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.reloadIfStale()
+	if err != nil {
+		return 0, err
+	}
+	results, err := s.store.Query(ctx, &storage.Query{})
+	if err != nil {
+		return 0, err
+	}
+
+	pending := make([]*storage.SummaryResult, 0)
+	for _, result := range results {
+		if result.Status != "" || s.index.Contains(result.Path) {
+			continue
+		}
+		pending = append(pending, result)
+	}
+
+	indexed := 0
+	for start := 0; start < len(pending); start += embedBatchSize {
+		batch := pending[start:min(start+embedBatchSize, len(pending))]
+		paths := make([]string, 0, len(batch))
+		texts := make([]string, 0, len(batch))
+		for _, result := range batch {
+			paths = append(paths, result.Path)
+			texts = append(texts, embeddingText(result))
+		}
+
+		vectors, err := s.embedder.EmbedStrings(ctx, texts)
+		if err != nil {
+			return indexed, err
+		}
+		err = s.index.Append(paths, vectors)
+		if err != nil {
+			return indexed, err
+		}
+		indexed += len(batch)
+	}
+
+	slogctx.FromCtx(ctx).InfoContext(ctx, "index results",
+		slog.Int("Indexed", indexed),
+		slog.Int("Total", s.index.Len()))
+	return indexed, nil
+}
+
+// Search implement Searcher.Search
+func (s *searcherImpl) Search(ctx context.Context, query string, k int) ([]*Hit, error) {
+	if s.embedder == nil {
+		return nil, ErrNotConfigured
+	}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrEmptyQuery
+	}
+
+	vectors, err := s.embedder.EmbedStrings(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := s.store.Query(ctx, &storage.Query{})
+	if err != nil {
+		return nil, err
+	}
+	byPath := make(map[string]*storage.SummaryResult, len(results))
+	for _, result := range results {
+		byPath[result.Path] = result
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = s.reloadIfStale()
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]*Hit, 0, k)
+	for _, scored := range s.index.Nearest(vectors[0]) {
+		if len(hits) >= k {
+			break
+		}
+
+		result, ok := byPath[scored.path]
+		if !ok {
+			continue
+		}
+		hits = append(hits, &Hit{Score: scored.score, Result: result})
+	}
+	return hits, nil
+}
+
+// embeddingText is the text embedded for the result.
+func embeddingText(result *storage.SummaryResult) string {
+	parts := []string{result.Title}
+	for _, part := range []string{result.Thesis, result.Summary} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(result.Tags) > 0 {
+		parts = append(parts, "Tags: "+strings.Join(result.Tags, ", "))
+	}
+	if len(result.Keywords) > 0 {
+		parts = append(parts, "Keywords: "+strings.Join(result.Keywords, ", "))
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,27 @@
+// Package search implement semantic search over the persisted summaries.
+package search
+
+import (
+	"context"
+
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+// Hit is a result of Search.
+type Hit struct {
+	// Score is the cosine similarity between the query and the result
+	Score float64 `json:"score"`
+	// Result is a named field, the promoted UnmarshalJSON of an embedded
+	// SummaryResult would drop the score
+	Result *storage.SummaryResult `json:"result"`
+}
+
+// Searcher is the interface for semantic search.
+type Searcher interface {
+	// Index will embed all persisted results which are not indexed yet, it
+	// return the number of new indexed results.
+	Index(ctx context.Context) (int, error)
+
+	// Search return the top k results which are most similar to the query.
+	Search(ctx context.Context, query string, k int) ([]*Hit, error)
+}
@@ -0,0 +1,340 @@
+// Package server implement the http read api over storage.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	slogctx "github.com/veqryn/slog-context"
+
+	"github.com/anyvoxel/vela/pkg/collectors/manual"
+	"github.com/anyvoxel/vela/pkg/search"
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+	defaultTopK  = 10
+
+	// maxRequestBytes is the max size of request body.
+	maxRequestBytes = 64 * 1024
+)
+
+var errInvalidParameter = errors.New("invalid parameter")
+
+// Post is the json representation of a persisted result.
+type Post struct {
+	ID string `json:"id"`
+
+	*storage.SummaryResult
+}
+
+// UnmarshalJSON implement json.Unmarshaler, the promoted method of
+// SummaryResult would drop the id.
+func (p *Post) UnmarshalJSON(data []byte) error {
+	var id struct {
+		ID string `json:"id"`
+	}
+	err := json.Unmarshal(data, &id)
+	if err != nil {
+		return err
+	}
+
+	p.ID = id.ID
+	p.SummaryResult = &storage.SummaryResult{}
+	return json.Unmarshal(data, p.SummaryResult)
+}
+
+// PostList is the response of GET /posts.
+type PostList struct {
+	Total  int     `json:"total"`
+	Offset int     `json:"offset"`
+	Limit  int     `json:"limit"`
+	Items  []*Post `json:"items"`
+}
+
+// DomainList is the response of GET /domains.
+type DomainList struct {
+	Items []*storage.DomainStats `json:"items"`
+}
+
+// RunList is the response of GET /runs.
+type RunList struct {
+	Items []*storage.Run `json:"items"`
+}
+
+// SearchHit is a result of GET /search.
+type SearchHit struct {
+	// Score is the cosine similarity between the query and the post
+	Score float64 `json:"score"`
+	Post  *Post   `json:"post"`
+}
+
+// SearchResult is the response of GET /search.
+type SearchResult struct {
+	Items []*SearchHit `json:"items"`
+}
+
+// Submission is the request and response of POST /submissions.
+type Submission struct {
+	URL string `json:"url"`
+}
+
+// SubmissionList is the response of GET /submissions.
+type SubmissionList struct {
+	Items []string `json:"items"`
+}
+
+// Error is the response of failed request.
+type Error struct {
+	Error string `json:"error"`
+}
+
+// handler serve the read api of store.
+type handler struct {
+	store    storage.Storage
+	searcher search.Searcher
+}
+
+// NewHandler return the http handler of the read api:
+//   - GET /posts?domain=&tag=&keyword=&q=&since=&until=&limit=&offset=
+//   - GET /posts/{id}
+//   - GET /domains
+//   - GET /runs?limit=
+//   - GET /search?q=&top_k=
+//   - GET /submissions
+//   - POST /submissions {"url":""}
+//
+// and the web ui under /ui.
+func NewHandler(store storage.Storage, searcher search.Searcher) http.Handler {
+	h := &handler{store: store, searcher: searcher}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /posts", h.listPosts)
+	mux.HandleFunc("GET /posts/{id}", h.getPost)
+	mux.HandleFunc("GET /domains", h.listDomains)
+	mux.HandleFunc("GET /runs", h.listRuns)
+	mux.HandleFunc("GET /search", h.search)
+	mux.HandleFunc("GET /submissions", h.listSubmissions)
+	mux.HandleFunc("POST /submissions", h.submit)
+	h.registerUI(mux)
+	return mux
+}
+
+func (h *handler) listPosts(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	q, err := parseQuery(params)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	limit, offset, err := parsePagination(params)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	results, err := h.store.Query(r.Context(), q)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	list := &PostList{
+		Total:  len(results),
+		Offset: offset,
+		Limit:  limit,
+		Items:  make([]*Post, 0, limit),
+	}
+	if offset < len(results) {
+		for _, result := range results[offset:min(offset+limit, len(results))] {
+			list.Items = append(list.Items, newPost(result))
+		}
+	}
+	writeJSON(w, r, http.StatusOK, list)
+}
+
+func (h *handler) getPost(w http.ResponseWriter, r *http.Request) {
+	result, err := h.store.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, newPost(result))
+}
+
+func (h *handler) listDomains(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.store.Domains(r.Context())
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, &DomainList{Items: stats})
+}
+
+func (h *handler) listRuns(w http.ResponseWriter, r *http.Request) {
+	limit, _, err := parsePagination(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	runs, err := h.store.Runs(r.Context(), limit)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, &RunList{Items: runs})
+}
+
+func (h *handler) search(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := strings.TrimSpace(params.Get("q"))
+	if query == "" {
+		writeError(w, r, http.StatusBadRequest, fmt.Errorf("%w: q is empty", errInvalidParameter))
+		return
+	}
+	topK, err := parseInt(params, "top_k", defaultTopK)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if topK <= 0 {
+		writeError(w, r, http.StatusBadRequest, fmt.Errorf("%w: top_k %d", errInvalidParameter, topK))
+		return
+	}
+
+	hits, err := h.searcher.Search(r.Context(), query, min(topK, maxLimit))
+	if err != nil {
+		if errors.Is(err, search.ErrNotConfigured) {
+			writeError(w, r, http.StatusServiceUnavailable, err)
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	result := &SearchResult{Items: make([]*SearchHit, 0, len(hits))}
+	for _, hit := range hits {
+		result.Items = append(result.Items, &SearchHit{Score: hit.Score, Post: newPost(hit.Result)})
+	}
+	writeJSON(w, r, http.StatusOK, result)
+}
+
+func (h *handler) listSubmissions(w http.ResponseWriter, r *http.Request) {
+	paths, err := h.store.Submissions(r.Context())
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, &SubmissionList{Items: append([]string{}, paths...)})
+}
+
+func (h *handler) submit(w http.ResponseWriter, r *http.Request) {
+	var submission Submission
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&submission)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, fmt.Errorf("%w: %w", errInvalidParameter, err))
+		return
+	}
+
+	path, err := manual.NormalizeURL(submission.URL)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	err = h.store.Submit(r.Context(), path)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, r, http.StatusAccepted, &Submission{URL: path})
+}
+
+func newPost(result *storage.SummaryResult) *Post {
+	return &Post{ID: storage.PostID(result.Path), SummaryResult: result}
+}
+
+func parseQuery(params url.Values) (*storage.Query, error) {
+	since, err := storage.ParseQueryTime(params.Get("since"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: since %q", errInvalidParameter, params.Get("since"))
+	}
+	until, err := storage.ParseQueryTime(params.Get("until"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: until %q", errInvalidParameter, params.Get("until"))
+	}
+
+	return &storage.Query{
+		Domain:  strings.TrimSpace(params.Get("domain")),
+		Tag:     strings.TrimSpace(params.Get("tag")),
+		Keyword: strings.TrimSpace(params.Get("keyword")),
+		Text:    strings.TrimSpace(params.Get("q")),
+		Since:   since,
+		Until:   until,
+	}, nil
+}
+
+func parsePagination(params url.Values) (int, int, error) {
+	limit, err := parseInt(params, "limit", defaultLimit)
+	if err != nil {
+		return 0, 0, err
+	}
+	offset, err := parseInt(params, "offset", 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	if limit <= 0 || offset < 0 {
+		return 0, 0, fmt.Errorf("%w: limit %d offset %d", errInvalidParameter, limit, offset)
+	}
+	return min(limit, maxLimit), offset, nil
+}
+
+func parseInt(params url.Values, key string, defaultValue int) (int, error) {
+	value := strings.TrimSpace(params.Get(key))
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s %q", errInvalidParameter, key, value)
+	}
+	return i, nil
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if status >= http.StatusInternalServerError {
+		logError(r, err)
+	}
+	writeJSON(w, r, status, &Error{Error: err.Error()})
+}
+
+func logError(r *http.Request, err error) {
+	slogctx.FromCtx(r.Context()).ErrorContext(r.Context(), "serve request failed",
+		slog.String("Path", r.URL.Path),
+		slog.Any("Error", err))
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		slogctx.FromCtx(r.Context()).ErrorContext(r.Context(), "write response failed",
+			slog.String("Path", r.URL.Path),
+			slog.Any("Error", err))
+	}
+}
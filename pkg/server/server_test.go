@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anyvoxel/airmid/ioc"
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+	"github.com/anyvoxel/vela/pkg/search"
+	mock_search "github.com/anyvoxel/vela/pkg/search/mocks"
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+func newTestStorage(t *testing.T) storage.Storage {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+
+	s := storage.NewStorage(t.TempDir())
+	g.Expect(s.(ioc.InitializingBean).AfterPropertiesSet(ctx)).To(gomega.Succeed())
+	g.Expect(s.Put(ctx, []*storage.SummaryResult{
+		{
+			Domain:        "brooker",
+			Path:          "https://brooker.co.za/blog/1",
+			Title:         "Raft snapshotting",
+			Summary:       "How to snapshot the log",
+			SummaryDetail: apitypes.SummaryDetail{Tags: []string{"consensus"}},
+			PublishedAt:   time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Domain:      "brooker",
+			Path:        "https://brooker.co.za/blog/2",
+			Title:       "Cell based architecture",
+			Status:      storage.StatusSkippedLowRelevance,
+			PublishedAt: time.Date(2026, 9, 3, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Domain:      "charap",
+			Path:        "https://charap.co/3",
+			Title:       "Paxos",
+			PublishedAt: time.Date(2026, 10, 9, 0, 0, 0, 0, time.UTC),
+		},
+	})).To(gomega.Succeed())
+	g.Expect(s.PutRun(ctx, &storage.Run{
+		ID:        "20261001T000000Z",
+		StartedAt: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		Collected: 3,
+	})).To(gomega.Succeed())
+	g.Expect(s.PutRun(ctx, &storage.Run{
+		ID:        "20261002T000000Z",
+		StartedAt: time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC),
+	})).To(gomega.Succeed())
+
+	return s
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(NewHandler(newTestStorage(t), mock_search.NewMockSearcher(gomock.NewController(t))))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func getJSON(t *testing.T, url string, out any) int {
+	g := gomega.NewWithT(t)
+
+	resp, err := http.Get(url) //nolint:noctx
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	defer resp.Body.Close() //nolint
+	g.Expect(resp.Header.Get("Content-Type")).To(gomega.HavePrefix("application/json"))
+	g.Expect(json.NewDecoder(resp.Body).Decode(out)).To(gomega.Succeed())
+	return resp.StatusCode
+}
+
+func TestHandler_ListPosts(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server := newTestServer(t)
+
+	var list PostList
+	g.Expect(getJSON(t, server.URL+"/posts", &list)).To(gomega.Equal(http.StatusOK))
+	g.Expect(list.Total).To(gomega.Equal(3))
+	g.Expect(list.Limit).To(gomega.Equal(defaultLimit))
+	g.Expect(list.Items[0].Path).To(gomega.Equal("https://charap.co/3"))
+	g.Expect(list.Items[0].ID).To(gomega.Equal(storage.PostID("https://charap.co/3")))
+
+	list = PostList{}
+	g.Expect(getJSON(t, server.URL+"/posts?domain=brooker&limit=1&offset=1", &list)).To(gomega.Equal(http.StatusOK))
+	g.Expect(list.Total).To(gomega.Equal(2))
+	g.Expect(list.Items).To(gomega.HaveLen(1))
+	g.Expect(list.Items[0].Path).To(gomega.Equal("https://brooker.co.za/blog/2"))
+
+	list = PostList{}
+	g.Expect(getJSON(t, server.URL+"/posts?q=SNAPSHOT&tag=consensus&since=2026-10-01", &list)).
+		To(gomega.Equal(http.StatusOK))
+	g.Expect(list.Total).To(gomega.Equal(1))
+	g.Expect(list.Items[0].Title).To(gomega.Equal("Raft snapshotting"))
+
+	list = PostList{}
+	g.Expect(getJSON(t, server.URL+"/posts?offset=10", &list)).To(gomega.Equal(http.StatusOK))
+	g.Expect(list.Items).To(gomega.BeEmpty())
+
+	var e Error
+	g.Expect(getJSON(t, server.URL+"/posts?since=yesterday", &e)).To(gomega.Equal(http.StatusBadRequest))
+	g.Expect(e.Error).To(gomega.ContainSubstring("since"))
+	g.Expect(getJSON(t, server.URL+"/posts?limit=-1", &e)).To(gomega.Equal(http.StatusBadRequest))
+}
+
+func TestHandler_GetPost(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server := newTestServer(t)
+
+	var post Post
+	g.Expect(getJSON(t, server.URL+"/posts/"+storage.PostID("https://charap.co/3"), &post)).
+		To(gomega.Equal(http.StatusOK))
+	g.Expect(post.Title).To(gomega.Equal("Paxos"))
+
+	var e Error
+	g.Expect(getJSON(t, server.URL+"/posts/unknown", &e)).To(gomega.Equal(http.StatusNotFound))
+}
+
+func TestHandler_ListDomains(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server := newTestServer(t)
+
+	var list DomainList
+	g.Expect(getJSON(t, server.URL+"/domains", &list)).To(gomega.Equal(http.StatusOK))
+	g.Expect(list.Items).To(gomega.HaveLen(2))
+	g.Expect(*list.Items[0]).To(gomega.Equal(storage.DomainStats{
+		Domain:            "brooker",
+		Posts:             2,
+		Skipped:           1,
+		LatestPublishedAt: time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC),
+	}))
+}
+
+func TestHandler_ListRuns(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server := newTestServer(t)
+
+	var list RunList
+	g.Expect(getJSON(t, server.URL+"/runs", &list)).To(gomega.Equal(http.StatusOK))
+	g.Expect(list.Items).To(gomega.HaveLen(2))
+	g.Expect(list.Items[0].ID).To(gomega.Equal("20261002T000000Z"))
+
+	list = RunList{}
+	g.Expect(getJSON(t, server.URL+"/runs?limit=1", &list)).To(gomega.Equal(http.StatusOK))
+	g.Expect(list.Items).To(gomega.HaveLen(1))
+}
+
+func TestHandler_Search(t *testing.T) {
+	g := gomega.NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	searcher := mock_search.NewMockSearcher(mockCtrl)
+	searcher.EXPECT().Search(gomock.Any(), "raft snapshotting", 2).Return([]*search.Hit{
+		{Score: 0.87, Result: &storage.SummaryResult{Title: "Raft", Path: "https://brooker.co.za/blog/1"}},
+	}, nil)
+	searcher.EXPECT().Search(gomock.Any(), "raft", maxLimit).Return(nil, search.ErrNotConfigured)
+	server := httptest.NewServer(NewHandler(newTestStorage(t), searcher))
+	defer server.Close()
+
+	var result SearchResult
+	g.Expect(getJSON(t, server.URL+"/search?q=+raft+snapshotting+&top_k=2", &result)).To(gomega.Equal(http.StatusOK))
+	g.Expect(result.Items).To(gomega.HaveLen(1))
+	g.Expect(result.Items[0].Score).To(gomega.Equal(0.87))
+	g.Expect(result.Items[0].Post.ID).To(gomega.Equal(storage.PostID("https://brooker.co.za/blog/1")))
+	g.Expect(result.Items[0].Post.Title).To(gomega.Equal("Raft"))
+
+	var e Error
+	g.Expect(getJSON(t, server.URL+"/search?q=raft&top_k=1000", &e)).To(gomega.Equal(http.StatusServiceUnavailable))
+	g.Expect(getJSON(t, server.URL+"/search?q=+", &e)).To(gomega.Equal(http.StatusBadRequest))
+	g.Expect(getJSON(t, server.URL+"/search?q=raft&top_k=0", &e)).To(gomega.Equal(http.StatusBadRequest))
+	g.Expect(e.Error).To(gomega.ContainSubstring("top_k"))
+}
+
+func TestHandler_Submit(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server := newTestServer(t)
+
+	resp, err := http.Post(server.URL+"/submissions", "application/json", //nolint:noctx
+		strings.NewReader(`{"url":"https://example.com/a#top"}`))
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	var submission Submission
+	g.Expect(json.NewDecoder(resp.Body).Decode(&submission)).To(gomega.Succeed())
+	resp.Body.Close() //nolint
+	g.Expect(resp.StatusCode).To(gomega.Equal(http.StatusAccepted))
+	g.Expect(submission.URL).To(gomega.Equal("https://example.com/a"))
+
+	var list SubmissionList
+	g.Expect(getJSON(t, server.URL+"/submissions", &list)).To(gomega.Equal(http.StatusOK))
+	g.Expect(list.Items).To(gomega.Equal([]string{"https://example.com/a"}))
+
+	resp, err = http.Post(server.URL+"/submissions", "application/json", //nolint:noctx
+		strings.NewReader(`{"url":"example.com"}`))
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	resp.Body.Close() //nolint
+	g.Expect(resp.StatusCode).To(gomega.Equal(http.StatusBadRequest))
+}
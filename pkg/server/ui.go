@@ -0,0 +1,372 @@
+package server
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+//go:embed web
+var webFS embed.FS
+
+var uiTemplate = template.Must(template.New("index.html").Funcs(template.FuncMap{
+	"date": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	},
+}).ParseFS(webFS, "web/index.html"))
+
+const (
+	// userCookie is the cookie which remembers the user of ui.
+	userCookie = "vela_user"
+	// defaultUser is the user when the reader doesn't provide one.
+	defaultUser = "default"
+	// uiPageSize is the number of posts in a page.
+	uiPageSize = 50
+
+	viewNew     = "new"
+	viewStarred = "starred"
+	viewAll     = "all"
+)
+
+var errUnknownAction = errors.New("unknown action")
+
+// uiItem is a post rendered in ui.
+type uiItem struct {
+	ID string
+	*storage.SummaryResult
+	State *storage.PostState
+	New   bool
+	HTML  template.HTML
+}
+
+// uiPage is the data of ui template.
+type uiPage struct {
+	User       string
+	LastSeenAt time.Time
+	Domains    []*storage.DomainStats
+	Tags       []string
+
+	Domain string
+	Tag    string
+	Text   string
+	View   string
+	Lang   string
+
+	Items      []*uiItem
+	Total      int
+	NextOffset int
+	// Self is the url of current page, the forms redirect back to it
+	Self string
+}
+
+func (h *handler) registerUI(mux *http.ServeMux) {
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ui", http.StatusFound)
+	})
+	mux.HandleFunc("GET /ui", h.uiIndex)
+	mux.HandleFunc("POST /ui/seen", h.uiSeen)
+	mux.HandleFunc("POST /ui/posts/{id}/state", h.uiPostState)
+	mux.HandleFunc("POST /ui/posts/{id}/resummarize", h.uiResummarize)
+}
+
+func (h *handler) uiIndex(w http.ResponseWriter, r *http.Request) {
+	user, err := uiUser(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	params := r.URL.Query()
+	q, err := parseQuery(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, offset, err := parsePagination(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.uiPage(r, user, q, offset)
+	if err != nil {
+		writeUIError(w, r, err)
+		return
+	}
+
+	var b bytes.Buffer
+	err = uiTemplate.Execute(&b, page)
+	if err != nil {
+		writeUIError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(b.Bytes())
+}
+
+func (h *handler) uiPage(r *http.Request, user string, q *storage.Query, offset int) (*uiPage, error) {
+	ctx := r.Context()
+	params := r.URL.Query()
+
+	state, err := h.store.UserState(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	domains, err := h.store.Domains(ctx)
+	if err != nil {
+		return nil, err
+	}
+	all, err := h.store.Query(ctx, &storage.Query{})
+	if err != nil {
+		return nil, err
+	}
+	results, err := h.store.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &uiPage{
+		User:       user,
+		LastSeenAt: state.LastSeenAt,
+		Domains:    domains,
+		Tags:       collectTags(all),
+		Domain:     q.Domain,
+		Tag:        q.Tag,
+		Text:       q.Text,
+		View:       params.Get("view"),
+		Lang:       params.Get("lang"),
+		Self:       r.URL.RequestURI(),
+	}
+
+	items := make([]*uiItem, 0)
+	for _, result := range results {
+		item := &uiItem{
+			ID:            storage.PostID(result.Path),
+			SummaryResult: result,
+		}
+		item.State = state.Post(item.ID)
+		item.New = !result.CollectedAt.IsZero() && result.CollectedAt.After(state.LastSeenAt)
+		if !page.visible(item) {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	page.Total = len(items)
+	if offset < len(items) {
+		page.Items = items[offset:min(offset+uiPageSize, len(items))]
+	}
+	if offset+uiPageSize < len(items) {
+		page.NextOffset = offset + uiPageSize
+	}
+	for _, item := range page.Items {
+		item.HTML, err = renderMarkdown(item.summaryIn(page.Lang))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// visible return true if the item should be listed in the view, the
+// dismissed posts are only listed in all view.
+func (p *uiPage) visible(item *uiItem) bool {
+	switch p.View {
+	case viewAll:
+		return true
+	case viewNew:
+		return item.New && !item.State.Dismissed
+	case viewStarred:
+		return item.State.Starred
+	default:
+		return !item.State.Dismissed
+	}
+}
+
+// NextURL return the url of next page.
+func (p *uiPage) NextURL() string {
+	u, err := url.Parse(p.Self)
+	if err != nil {
+		return "/ui"
+	}
+	q := u.Query()
+	q.Set("offset", fmt.Sprint(p.NextOffset))
+	u.RawQuery = q.Encode()
+	return u.RequestURI()
+}
+
+func (item *uiItem) summaryIn(lang string) string {
+	if lang == "" {
+		return item.Summary
+	}
+	return item.SummaryIn(lang)
+}
+
+func (h *handler) uiSeen(w http.ResponseWriter, r *http.Request) {
+	user, err := uiUser(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.store.UpdateUserState(r.Context(), user, func(state *storage.UserState) {
+		state.LastSeenAt = time.Now().UTC()
+	})
+	if err != nil {
+		writeUIError(w, r, err)
+		return
+	}
+	redirectBack(w, r)
+}
+
+func (h *handler) uiPostState(w http.ResponseWriter, r *http.Request) {
+	user, err := uiUser(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id := r.PathValue("id")
+	_, err = h.store.Get(r.Context(), id)
+	if err != nil {
+		writeUIError(w, r, err)
+		return
+	}
+
+	apply, err := postAction(r.FormValue("action"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.store.UpdateUserState(r.Context(), user, func(state *storage.UserState) {
+		postState := state.Post(id)
+		apply(postState)
+		postState.UpdatedAt = time.Now().UTC()
+		state.Posts[id] = postState
+	})
+	if err != nil {
+		writeUIError(w, r, err)
+		return
+	}
+	redirectBack(w, r)
+}
+
+// postAction return the update of post state by the action, it's validated
+// before the user state is locked.
+func postAction(action string) (func(state *storage.PostState), error) {
+	switch action {
+	case "read":
+		return func(state *storage.PostState) { state.Read = true }, nil
+	case "unread":
+		return func(state *storage.PostState) { state.Read = false }, nil
+	case "star":
+		return func(state *storage.PostState) { state.Starred = true }, nil
+	case "unstar":
+		return func(state *storage.PostState) { state.Starred = false }, nil
+	case "dismiss":
+		return func(state *storage.PostState) { state.Dismissed = true }, nil
+	case "undismiss":
+		return func(state *storage.PostState) { state.Dismissed = false }, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnknownAction, action)
+	}
+}
+
+func (h *handler) uiResummarize(w http.ResponseWriter, r *http.Request) {
+	result, err := h.store.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeUIError(w, r, err)
+		return
+	}
+
+	err = h.store.EnqueueResummarize(r.Context(), result.Path)
+	if err != nil {
+		writeUIError(w, r, err)
+		return
+	}
+	redirectBack(w, r)
+}
+
+// uiUser return the user from the user query or the cookie, the user query
+// will be remembered in the cookie.
+func uiUser(w http.ResponseWriter, r *http.Request) (string, error) {
+	user := strings.TrimSpace(r.URL.Query().Get("user"))
+	if user != "" {
+		err := storage.ValidateUser(user)
+		if err != nil {
+			return "", err
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     userCookie,
+			Value:    user,
+			Path:     "/",
+			MaxAge:   365 * 24 * 3600,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		return user, nil
+	}
+
+	cookie, err := r.Cookie(userCookie)
+	if err != nil || storage.ValidateUser(cookie.Value) != nil {
+		return defaultUser, nil
+	}
+	return cookie.Value, nil
+}
+
+// redirectBack redirect to the page which submits the form, only the ui
+// pages are allowed.
+func redirectBack(w http.ResponseWriter, r *http.Request) {
+	back := r.FormValue("back")
+	if !strings.HasPrefix(back, "/ui") {
+		back = "/ui"
+	}
+	http.Redirect(w, r, back, http.StatusSeeOther)
+}
+
+func writeUIError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	logError(r, err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+func renderMarkdown(text string) (template.HTML, error) {
+	var b bytes.Buffer
+	// The raw html in summary is escaped by default
+	err := goldmark.Convert([]byte(text), &b)
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(b.String()), nil //nolint:gosec
+}
+
+func collectTags(results []*storage.SummaryResult) []string {
+	tags := make([]string, 0)
+	for _, result := range results {
+		for _, tag := range result.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	slices.Sort(tags)
+	return tags
+}
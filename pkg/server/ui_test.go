@@ -0,0 +1,94 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/anyvoxel/vela/pkg/storage"
+)
+
+func newTestClient(t *testing.T) *http.Client {
+	jar, err := cookiejar.New(nil)
+	gomega.NewWithT(t).Expect(err).ToNot(gomega.HaveOccurred())
+	return &http.Client{Jar: jar}
+}
+
+func getPage(t *testing.T, client *http.Client, u string) string {
+	g := gomega.NewWithT(t)
+
+	resp, err := client.Get(u) //nolint:noctx
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	defer resp.Body.Close() //nolint
+	g.Expect(resp.StatusCode).To(gomega.Equal(http.StatusOK))
+	b, err := io.ReadAll(resp.Body)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	return string(b)
+}
+
+func TestUI_Index(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server := newTestServer(t)
+	client := newTestClient(t)
+
+	page := getPage(t, client, server.URL+"/")
+	g.Expect(page).To(gomega.ContainSubstring("Raft snapshotting"))
+	g.Expect(page).To(gomega.ContainSubstring("<p>How to snapshot the log</p>"))
+	g.Expect(page).To(gomega.ContainSubstring(`<option value="consensus">consensus</option>`))
+
+	page = getPage(t, client, server.URL+"/ui?domain=charap")
+	g.Expect(page).To(gomega.ContainSubstring("Paxos"))
+	g.Expect(page).ToNot(gomega.ContainSubstring("Raft snapshotting"))
+}
+
+func TestUI_PostState(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server := newTestServer(t)
+	client := newTestClient(t)
+
+	// The user is remembered by cookie
+	getPage(t, client, server.URL+"/ui?user=alice")
+
+	id := storage.PostID("https://charap.co/3")
+	resp, err := client.PostForm(server.URL+"/ui/posts/"+id+"/state", url.Values{
+		"action": {"dismiss"},
+		"back":   {"/ui?view=all"},
+	})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	resp.Body.Close() //nolint
+	g.Expect(resp.StatusCode).To(gomega.Equal(http.StatusOK))
+	g.Expect(resp.Request.URL.RequestURI()).To(gomega.Equal("/ui?view=all"))
+
+	g.Expect(getPage(t, client, server.URL+"/ui")).ToNot(gomega.ContainSubstring("Paxos"))
+	g.Expect(getPage(t, client, server.URL+"/ui?view=all")).To(gomega.ContainSubstring("Restore"))
+	g.Expect(getPage(t, client, server.URL+"/ui?user=bob")).To(gomega.ContainSubstring("Paxos"))
+
+	resp, err = client.PostForm(server.URL+"/ui/posts/"+id+"/state", url.Values{"action": {"like"}})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	resp.Body.Close() //nolint
+	g.Expect(resp.StatusCode).To(gomega.Equal(http.StatusBadRequest))
+}
+
+func TestUI_Resummarize(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server := newTestServer(t)
+	client := newTestClient(t)
+
+	id := storage.PostID("https://charap.co/3")
+	resp, err := client.PostForm(server.URL+"/ui/posts/"+id+"/resummarize", url.Values{"back": {"https://example.com"}})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	resp.Body.Close() //nolint
+	g.Expect(resp.StatusCode).To(gomega.Equal(http.StatusOK))
+	g.Expect(resp.Request.URL.Path).To(gomega.Equal("/ui"))
+
+	resp, err = client.PostForm(server.URL+"/ui/posts/unknown/resummarize", nil)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	resp.Body.Close() //nolint
+	g.Expect(resp.StatusCode).To(gomega.Equal(http.StatusNotFound))
+	g.Expect(strings.Contains(resp.Request.URL.Path, "unknown")).To(gomega.BeTrue())
+}
@@ -41,6 +41,78 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
 
+// DequeueResummarize mocks base method.
+func (m *MockStorage) DequeueResummarize(ctx context.Context, path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DequeueResummarize", ctx, path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DequeueResummarize indicates an expected call of DequeueResummarize.
+func (mr *MockStorageMockRecorder) DequeueResummarize(ctx, path any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DequeueResummarize", reflect.TypeOf((*MockStorage)(nil).DequeueResummarize), ctx, path)
+}
+
+// DequeueSubmission mocks base method.
+func (m *MockStorage) DequeueSubmission(ctx context.Context, path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DequeueSubmission", ctx, path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DequeueSubmission indicates an expected call of DequeueSubmission.
+func (mr *MockStorageMockRecorder) DequeueSubmission(ctx, path any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DequeueSubmission", reflect.TypeOf((*MockStorage)(nil).DequeueSubmission), ctx, path)
+}
+
+// Domains mocks base method.
+func (m *MockStorage) Domains(ctx context.Context) ([]*storage.DomainStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Domains", ctx)
+	ret0, _ := ret[0].([]*storage.DomainStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Domains indicates an expected call of Domains.
+func (mr *MockStorageMockRecorder) Domains(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Domains", reflect.TypeOf((*MockStorage)(nil).Domains), ctx)
+}
+
+// EnqueueResummarize mocks base method.
+func (m *MockStorage) EnqueueResummarize(ctx context.Context, path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueueResummarize", ctx, path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnqueueResummarize indicates an expected call of EnqueueResummarize.
+func (mr *MockStorageMockRecorder) EnqueueResummarize(ctx, path any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueResummarize", reflect.TypeOf((*MockStorage)(nil).EnqueueResummarize), ctx, path)
+}
+
+// Get mocks base method.
+func (m *MockStorage) Get(ctx context.Context, id string) (*storage.SummaryResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*storage.SummaryResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockStorageMockRecorder) Get(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockStorage)(nil).Get), ctx, id)
+}
+
 // Put mocks base method.
 func (m *MockStorage) Put(ctx context.Context, results []*storage.SummaryResult) error {
 	m.ctrl.T.Helper()
@@ -55,6 +127,94 @@ func (mr *MockStorageMockRecorder) Put(ctx, results any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockStorage)(nil).Put), ctx, results)
 }
 
+// PutRun mocks base method.
+func (m *MockStorage) PutRun(ctx context.Context, run *storage.Run) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutRun", ctx, run)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutRun indicates an expected call of PutRun.
+func (mr *MockStorageMockRecorder) PutRun(ctx, run any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutRun", reflect.TypeOf((*MockStorage)(nil).PutRun), ctx, run)
+}
+
+// Query mocks base method.
+func (m *MockStorage) Query(ctx context.Context, q *storage.Query) ([]*storage.SummaryResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Query", ctx, q)
+	ret0, _ := ret[0].([]*storage.SummaryResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Query indicates an expected call of Query.
+func (mr *MockStorageMockRecorder) Query(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockStorage)(nil).Query), ctx, q)
+}
+
+// ResummarizeQueue mocks base method.
+func (m *MockStorage) ResummarizeQueue(ctx context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResummarizeQueue", ctx)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResummarizeQueue indicates an expected call of ResummarizeQueue.
+func (mr *MockStorageMockRecorder) ResummarizeQueue(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResummarizeQueue", reflect.TypeOf((*MockStorage)(nil).ResummarizeQueue), ctx)
+}
+
+// Runs mocks base method.
+func (m *MockStorage) Runs(ctx context.Context, limit int) ([]*storage.Run, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Runs", ctx, limit)
+	ret0, _ := ret[0].([]*storage.Run)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Runs indicates an expected call of Runs.
+func (mr *MockStorageMockRecorder) Runs(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Runs", reflect.TypeOf((*MockStorage)(nil).Runs), ctx, limit)
+}
+
+// Submissions mocks base method.
+func (m *MockStorage) Submissions(ctx context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Submissions", ctx)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Submissions indicates an expected call of Submissions.
+func (mr *MockStorageMockRecorder) Submissions(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Submissions", reflect.TypeOf((*MockStorage)(nil).Submissions), ctx)
+}
+
+// Submit mocks base method.
+func (m *MockStorage) Submit(ctx context.Context, path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Submit", ctx, path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Submit indicates an expected call of Submit.
+func (mr *MockStorageMockRecorder) Submit(ctx, path any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Submit", reflect.TypeOf((*MockStorage)(nil).Submit), ctx, path)
+}
+
 // SummaryExists mocks base method.
 func (m *MockStorage) SummaryExists(ctx context.Context, path string) bool {
 	m.ctrl.T.Helper()
@@ -68,3 +228,46 @@ func (mr *MockStorageMockRecorder) SummaryExists(ctx, path any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SummaryExists", reflect.TypeOf((*MockStorage)(nil).SummaryExists), ctx, path)
 }
+
+// Update mocks base method.
+func (m *MockStorage) Update(ctx context.Context, results []*storage.SummaryResult) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, results)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockStorageMockRecorder) Update(ctx, results any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockStorage)(nil).Update), ctx, results)
+}
+
+// UpdateUserState mocks base method.
+func (m *MockStorage) UpdateUserState(ctx context.Context, user string, update func(*storage.UserState)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserState", ctx, user, update)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserState indicates an expected call of UpdateUserState.
+func (mr *MockStorageMockRecorder) UpdateUserState(ctx, user, update any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserState", reflect.TypeOf((*MockStorage)(nil).UpdateUserState), ctx, user, update)
+}
+
+// UserState mocks base method.
+func (m *MockStorage) UserState(ctx context.Context, user string) (*storage.UserState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserState", ctx, user)
+	ret0, _ := ret[0].(*storage.UserState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserState indicates an expected call of UserState.
+func (mr *MockStorageMockRecorder) UserState(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserState", reflect.TypeOf((*MockStorage)(nil).UserState), ctx, user)
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"slices"
+)
+
+const (
+	// resummarizeFile is the json file of re-summarize queue under the data dir.
+	resummarizeFile = "resummarize.json"
+	// submissionsFile is the json file of submitted urls under the data dir.
+	submissionsFile = "submissions.json"
+)
+
+// EnqueueResummarize implement Storage.EnqueueResummarize
+func (s *localStorage) EnqueueResummarize(_ context.Context, path string) error {
+	return s.enqueue(resummarizeFile, path)
+}
+
+// ResummarizeQueue implement Storage.ResummarizeQueue
+func (s *localStorage) ResummarizeQueue(_ context.Context) ([]string, error) {
+	return s.queue(resummarizeFile)
+}
+
+// DequeueResummarize implement Storage.DequeueResummarize
+func (s *localStorage) DequeueResummarize(_ context.Context, path string) error {
+	return s.dequeue(resummarizeFile, path)
+}
+
+// Submit implement Storage.Submit
+func (s *localStorage) Submit(_ context.Context, path string) error {
+	return s.enqueue(submissionsFile, path)
+}
+
+// Submissions implement Storage.Submissions
+func (s *localStorage) Submissions(_ context.Context) ([]string, error) {
+	return s.queue(submissionsFile)
+}
+
+// DequeueSubmission implement Storage.DequeueSubmission
+func (s *localStorage) DequeueSubmission(_ context.Context, path string) error {
+	return s.dequeue(submissionsFile, path)
+}
+
+func (s *localStorage) enqueue(file string, item string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items, err := s.readQueue(file)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(items, item) {
+		return nil
+	}
+	return s.writeQueue(file, append(items, item))
+}
+
+func (s *localStorage) queue(file string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.readQueue(file)
+}
+
+func (s *localStorage) dequeue(file string, item string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items, err := s.readQueue(file)
+	if err != nil {
+		return err
+	}
+	i := slices.Index(items, item)
+	if i < 0 {
+		return nil
+	}
+	return s.writeQueue(file, slices.Delete(items, i, i+1))
+}
+
+// readQueue always read the file, because the queue is written by the
+// server and consumed by the collect run.
+func (s *localStorage) readQueue(file string) ([]string, error) {
+	b, err := os.ReadFile(path.Join(s.dataPath, file))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var items []string
+	err = json.Unmarshal(b, &items)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *localStorage) writeQueue(file string, items []string) error {
+	b, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path.Join(s.dataPath, file), b)
+}
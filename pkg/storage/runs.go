@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"time"
+)
+
+// runsFile is the jsonl file of run history under the data dir, it's not
+// loaded as summary because only the sub dirs contain summaries.
+const runsFile = "runs.jsonl"
+
+// Run is the history of a collect run.
+type Run struct {
+	ID         string    `json:"id"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+
+	// Collected is the number of new posts
+	Collected int `json:"collected"`
+	// Summarized is the number of new posts which are summarized
+	Summarized int `json:"summarized"`
+	// Skipped is the number of new posts which are recorded without summary
+	Skipped int `json:"skipped"`
+	// Failed is the number of new posts which will be retried in next run
+	Failed int `json:"failed"`
+	// Resummarized is the number of queued posts which are summarized again
+	Resummarized int `json:"resummarized"`
+
+	// Error is not empty if the run is failed
+	Error string `json:"error,omitempty"`
+}
+
+func (s *localStorage) readRuns() error {
+	f, err := os.Open(path.Join(s.dataPath, runsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close() //nolint
+
+	decoder := json.NewDecoder(f)
+	for {
+		var run Run
+		err := decoder.Decode(&run)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		s.runs = append(s.runs, &run)
+	}
+}
+
+// PutRun implement Storage.PutRun
+func (s *localStorage) PutRun(_ context.Context, run *Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(path.Join(s.dataPath, runsFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint
+
+	err = json.NewEncoder(f).Encode(run)
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, run)
+	return nil
+}
+
+// Runs implement Storage.Runs
+func (s *localStorage) Runs(ctx context.Context, limit int) ([]*Run, error) {
+	err := s.reloadIfChanged(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	runs := slices.Clone(s.runs)
+	slices.SortStableFunc(runs, func(a, b *Run) int {
+		return b.StartedAt.Compare(a.StartedAt)
+	})
+	if limit > 0 && len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
@@ -3,20 +3,28 @@ package storage
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"path"
 	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/anyvoxel/airmid/anvil"
 	airapp "github.com/anyvoxel/airmid/app"
 	"github.com/anyvoxel/airmid/ioc"
 	slogctx "github.com/veqryn/slog-context"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
 )
 
 func init() {
@@ -28,28 +36,227 @@ func init() {
 	))
 }
 
+// DefaultLanguage is the language of records which were persisted before
+// the summary supports multiple languages.
+const DefaultLanguage = "zh-CN"
+
+// StatusSkippedLowRelevance is the status of result which is recorded
+// without summary because its relevance is below the threshold.
+const StatusSkippedLowRelevance = "skipped_low_relevance"
+
+// StatusUnsupportedMedia is the status of result which is recorded without
+// summary because it's a video or audio without transcript.
+const StatusUnsupportedMedia = "unsupported_media"
+
 // SummaryResult is the result of a summary.
 type SummaryResult struct {
-	Domain      string    `json:"domain"`
-	Path        string    `json:"path"`
-	Title       string    `json:"title"`
-	Summary     string    `json:"summary"`
+	Domain string `json:"domain"`
+	Path   string `json:"path"`
+	Title  string `json:"title"`
+
+	// Summary is the summary text in the first configured language.
+	Summary string `json:"summary"`
+	// Summaries is the summary text keyed by language, e.g. zh-CN, en.
+	Summaries map[string]string `json:"summaries,omitempty"`
+
+	apitypes.SummaryDetail
+
+	// Relevance is the score against the interest profiles, it's nil if
+	// there isn't any profile.
+	Relevance *apitypes.Relevance `json:"relevance,omitempty"`
+	// Status is empty if the post is summarized.
+	Status string `json:"status,omitempty"`
+	// Source is empty for the collected post, or apitypes.SourceManual
+	Source string `json:"source,omitempty"`
+	// Model is the provider/model which produces the summary, it's empty
+	// for old records.
+	Model string `json:"model,omitempty"`
+	// CollectedAt is the time the post is summarized, it's zero for old records.
+	CollectedAt time.Time `json:"collected_at,omitzero"`
+	// Updated is true if the record replaces the previous one of the same
+	// path, the other duplicate paths are reported when loaded.
+	Updated bool `json:"updated,omitempty"`
+
 	PublishedAt time.Time `json:"published_at"`
 }
 
+// UnmarshalJSON implement json.Unmarshaler, the record which only contains
+// the single summary will be loaded as DefaultLanguage.
+func (r *SummaryResult) UnmarshalJSON(data []byte) error {
+	type plain SummaryResult
+	err := json.Unmarshal(data, (*plain)(r))
+	if err != nil {
+		return err
+	}
+
+	if len(r.Summaries) == 0 && r.Summary != "" {
+		r.Summaries = map[string]string{DefaultLanguage: r.Summary}
+	}
+	return nil
+}
+
+// SummaryIn return the summary text in lang, it will fallback to Summary
+// if the lang is not available.
+func (r *SummaryResult) SummaryIn(lang string) string {
+	if summary, ok := r.Summaries[lang]; ok {
+		return summary
+	}
+	return r.Summary
+}
+
+// Query is the filter of persisted results, the zero value matches all.
+type Query struct {
+	// Domain matches the result's Domain
+	Domain string
+	// Tag matches one of the result's Tags, case insensitive
+	Tag string
+	// Keyword matches one of the result's Keywords, case insensitive
+	Keyword string
+	// Since matches the result published at or after it
+	Since time.Time
+	// Until matches the result published before it
+	Until time.Time
+	// Text matches the result's Title, Thesis or one of the summaries,
+	// case insensitive
+	Text string
+}
+
+// Match return true if the result satisfies the query.
+func (q *Query) Match(result *SummaryResult) bool {
+	if q.Domain != "" && q.Domain != result.Domain {
+		return false
+	}
+	if q.Tag != "" && !containsFold(result.Tags, q.Tag) {
+		return false
+	}
+	if q.Keyword != "" && !containsFold(result.Keywords, q.Keyword) {
+		return false
+	}
+	if !q.Since.IsZero() && result.PublishedAt.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !result.PublishedAt.Before(q.Until) {
+		return false
+	}
+	if q.Text != "" && !result.containsText(q.Text) {
+		return false
+	}
+	return true
+}
+
+func (r *SummaryResult) containsText(text string) bool {
+	text = strings.ToLower(text)
+	for _, s := range []string{r.Title, r.Thesis, r.Summary} {
+		if strings.Contains(strings.ToLower(s), text) {
+			return true
+		}
+	}
+	for _, s := range r.Summaries {
+		if strings.Contains(strings.ToLower(s), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// PostID return the stable id of the post, which is derived from its path.
+func PostID(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(sum[:8])
+}
+
+// DomainStats is the statistics of persisted results of a domain.
+type DomainStats struct {
+	Domain string `json:"domain"`
+	// Posts is the number of all results
+	Posts int `json:"posts"`
+	// Skipped is the number of results which are not summarized
+	Skipped int `json:"skipped"`
+	// LatestPublishedAt is the publish time of the newest result
+	LatestPublishedAt time.Time `json:"latest_published_at"`
+}
+
+// ParseQueryTime parse the RFC3339 or YYYY-MM-DD time used by Query, the
+// empty string will be parsed as zero time.
+func ParseQueryTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func containsFold(items []string, s string) bool {
+	return slices.ContainsFunc(items, func(item string) bool {
+		return strings.EqualFold(item, s)
+	})
+}
+
 // Storage is the interface for storage.
 type Storage interface {
 	// SummaryExists return true if this summary already persist
 	SummaryExists(ctx context.Context, path string) bool
 	// Put will persist all result to jsonl file.
 	Put(ctx context.Context, results []*SummaryResult) error
+	// Query return all persisted results which match the query, ordered by
+	// PublishedAt descending.
+	Query(ctx context.Context, q *Query) ([]*SummaryResult, error)
+	// Get return the result of PostID, or ErrNotFound.
+	Get(ctx context.Context, id string) (*SummaryResult, error)
+	// Domains return the statistics of all domains, ordered by domain.
+	Domains(ctx context.Context) ([]*DomainStats, error)
+	// PutRun will persist the history of a run.
+	PutRun(ctx context.Context, run *Run) error
+	// Runs return the latest limit runs, ordered by StartedAt descending.
+	Runs(ctx context.Context, limit int) ([]*Run, error)
+	// Update will persist the results which replace the previous ones of the same path.
+	Update(ctx context.Context, results []*SummaryResult) error
+
+	// UserState return the state of user, it's empty if the user never marked any post.
+	UserState(ctx context.Context, user string) (*UserState, error)
+	// UpdateUserState will apply the update to the state of user and persist it,
+	// the concurrent updates of the same user are applied in order.
+	UpdateUserState(ctx context.Context, user string, update func(state *UserState)) error
+
+	// EnqueueResummarize will queue the post to be summarized again in next run.
+	EnqueueResummarize(ctx context.Context, path string) error
+	// ResummarizeQueue return the paths of queued posts.
+	ResummarizeQueue(ctx context.Context) ([]string, error)
+	// DequeueResummarize will remove the post from the queue.
+	DequeueResummarize(ctx context.Context, path string) error
+
+	// Submit will queue the url submitted by user to be collected in next run.
+	Submit(ctx context.Context, path string) error
+	// Submissions return the queued urls submitted by user.
+	Submissions(ctx context.Context) ([]string, error)
+	// DequeueSubmission will remove the url from the submission queue.
+	DequeueSubmission(ctx context.Context, path string) error
 }
 
+// ErrNotFound is returned when the record doesn't exist.
+var ErrNotFound = errors.New("not found")
+
 // localStorage will access and persist to all previous posts.
 type localStorage struct {
+	mu         sync.RWMutex
 	existPosts map[string]bool
+	records    []*SummaryResult
+	runs       []*Run
 	dataPath   string
 	dir        string `airmid:"value:${vela.storage.dir:=./}"`
+
+	// stamps is the stat of the loaded files, the files written by another
+	// process are reloaded before the query
+	stamps map[string]fileStamp
+}
+
+// fileStamp is the stat of a loaded jsonl file.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
 }
 
 // NewStorage creates a new Storage with the given directory.
@@ -78,17 +285,87 @@ func (s *localStorage) AfterPropertiesSet(ctx context.Context) error {
 		}
 	}
 
-	s.existPosts = map[string]bool{}
 	s.dataPath = dataPath
+	stamps, err := s.fileStamps()
+	if err != nil {
+		return err
+	}
+	return s.load(ctx, stamps)
+}
 
-	err = s.readPreviousSummary(ctx)
+// load read all summaries and runs, the loaded ones are kept if it's failed.
+func (s *localStorage) load(ctx context.Context, stamps map[string]fileStamp) error {
+	next := &localStorage{dataPath: s.dataPath, existPosts: map[string]bool{}}
+	err := next.readPreviousSummary(ctx)
+	if err != nil {
+		return err
+	}
+	err = next.readRuns()
 	if err != nil {
 		return err
 	}
 
+	s.existPosts, s.records, s.runs, s.stamps = next.existPosts, next.records, next.runs, stamps
 	return nil
 }
 
+// fileStamps return the stat of the summary files and the runs file.
+func (s *localStorage) fileStamps() (map[string]fileStamp, error) {
+	stamps := map[string]fileStamp{}
+	add := func(filename string) error {
+		info, err := os.Stat(filename)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		stamps[filename] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	}
+
+	dirEntries, err := os.ReadDir(s.dataPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+		subEntries, err := os.ReadDir(path.Join(s.dataPath, dirEntry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, subEntry := range subEntries {
+			if !strings.HasSuffix(subEntry.Name(), ".jsonl") {
+				continue
+			}
+			err = add(path.Join(s.dataPath, dirEntry.Name(), subEntry.Name()))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return stamps, add(path.Join(s.dataPath, runsFile))
+}
+
+// reloadIfChanged reload the summaries and runs if any file is changed since
+// it's loaded, e.g. the serve command reads the results written by the daemon.
+// The writes of this storage are reloaded too, which is cheap for a run.
+func (s *localStorage) reloadIfChanged(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stamps, err := s.fileStamps()
+	if err != nil {
+		return err
+	}
+	if maps.Equal(stamps, s.stamps) {
+		return nil
+	}
+	return s.load(ctx, stamps)
+}
+
 func (s *localStorage) readPreviousSummary(ctx context.Context) error {
 	dirEntries, err := os.ReadDir(s.dataPath)
 	if err != nil {
@@ -147,14 +424,15 @@ func (s *localStorage) readPreviousSummaryFile(ctx context.Context, dir string,
 			return err
 		}
 
-		_, ok := s.existPosts[result.Path]
-		if ok {
+		// The later record is an update of the previous one, the files are
+		// read in time order.
+		if s.existPosts[result.Path] && !result.Updated {
 			slogctx.FromCtx(ctx).ErrorContext(ctx,
 				"duplicate path in storage",
 				slog.String("Path", result.Path),
 			)
 		}
-		s.existPosts[result.Path] = true
+		s.upsertRecord(&result)
 
 		if len(strings.Split(result.Title, "\n")) > 1 {
 			slogctx.FromCtx(ctx).ErrorContext(ctx,
@@ -167,6 +445,9 @@ func (s *localStorage) readPreviousSummaryFile(ctx context.Context, dir string,
 
 // SummaryExists return true if this summary already persist
 func (s *localStorage) SummaryExists(_ context.Context, path string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	return s.existPosts[path]
 }
 
@@ -176,6 +457,9 @@ func (s *localStorage) Put(ctx context.Context, results []*SummaryResult) error
 		return nil
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	f, err := s.openFile(ctx)
 	if err != nil {
 		return err
@@ -183,7 +467,7 @@ func (s *localStorage) Put(ctx context.Context, results []*SummaryResult) error
 	defer f.Close() //nolint
 
 	for _, result := range results {
-		if s.SummaryExists(ctx, result.Path) {
+		if s.existPosts[result.Path] {
 			continue
 		}
 
@@ -191,6 +475,8 @@ func (s *localStorage) Put(ctx context.Context, results []*SummaryResult) error
 		if err != nil {
 			return err
 		}
+		s.existPosts[result.Path] = true
+		s.records = append(s.records, result)
 	}
 	slogctx.FromCtx(ctx).InfoContext(ctx, "save results",
 		slog.String("Filename", f.Name()),
@@ -198,6 +484,125 @@ func (s *localStorage) Put(ctx context.Context, results []*SummaryResult) error
 	return nil
 }
 
+// Update will persist the results which replace the previous ones of the
+// same path, they will override the previous ones when loaded.
+func (s *localStorage) Update(ctx context.Context, results []*SummaryResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.openFile(ctx)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint
+
+	for _, result := range results {
+		result.Updated = true
+		err = json.NewEncoder(f).Encode(result)
+		if err != nil {
+			return err
+		}
+		s.upsertRecord(result)
+	}
+	slogctx.FromCtx(ctx).InfoContext(ctx, "update results",
+		slog.String("Filename", f.Name()),
+		slog.Int("Rows", len(results)))
+	return nil
+}
+
+func (s *localStorage) upsertRecord(result *SummaryResult) {
+	if s.existPosts[result.Path] {
+		i := slices.IndexFunc(s.records, func(r *SummaryResult) bool { return r.Path == result.Path })
+		s.records[i] = result
+		return
+	}
+
+	s.existPosts[result.Path] = true
+	s.records = append(s.records, result)
+}
+
+// Query implement Storage.Query
+func (s *localStorage) Query(ctx context.Context, q *Query) ([]*SummaryResult, error) {
+	err := s.reloadIfChanged(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make([]*SummaryResult, 0)
+	for _, result := range s.records {
+		if q.Match(result) {
+			results = append(results, result)
+		}
+	}
+
+	slices.SortStableFunc(results, func(a, b *SummaryResult) int {
+		return b.PublishedAt.Compare(a.PublishedAt)
+	})
+	return results, nil
+}
+
+// Get implement Storage.Get
+func (s *localStorage) Get(ctx context.Context, id string) (*SummaryResult, error) {
+	err := s.reloadIfChanged(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, result := range s.records {
+		if PostID(result.Path) == id {
+			return result, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: post %s", ErrNotFound, id)
+}
+
+// Domains implement Storage.Domains
+func (s *localStorage) Domains(ctx context.Context) ([]*DomainStats, error) {
+	err := s.reloadIfChanged(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := map[string]*DomainStats{}
+	for _, result := range s.records {
+		stat, ok := stats[result.Domain]
+		if !ok {
+			stat = &DomainStats{Domain: result.Domain}
+			stats[result.Domain] = stat
+		}
+
+		stat.Posts++
+		if result.Status != "" {
+			stat.Skipped++
+		}
+		if result.PublishedAt.After(stat.LatestPublishedAt) {
+			stat.LatestPublishedAt = result.PublishedAt
+		}
+	}
+
+	ret := make([]*DomainStats, 0, len(stats))
+	for _, stat := range stats {
+		ret = append(ret, stat)
+	}
+	slices.SortFunc(ret, func(a, b *DomainStats) int {
+		return strings.Compare(a.Domain, b.Domain)
+	})
+	return ret, nil
+}
+
 func (s *localStorage) openFile(_ context.Context) (*os.File, error) {
 	monthStr := time.Now().UTC().Format("200601")
 	dataDir := path.Join(s.dir, "data", monthStr)
@@ -0,0 +1,257 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anyvoxel/airmid/ioc"
+	"github.com/onsi/gomega"
+	slogctx "github.com/veqryn/slog-context"
+
+	"github.com/anyvoxel/vela/pkg/apitypes"
+)
+
+func TestSummaryResult_UnmarshalLegacyRecord(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	var result SummaryResult
+	err := json.Unmarshal([]byte(`{"domain":"brooker","path":"https://brooker.co.za/blog/1","summary":"摘要"}`), &result)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(result.Summaries).To(gomega.Equal(map[string]string{DefaultLanguage: "摘要"}))
+	g.Expect(result.SummaryIn(DefaultLanguage)).To(gomega.Equal("摘要"))
+	g.Expect(result.SummaryIn("en")).To(gomega.Equal("摘要"))
+}
+
+func TestSummaryResult_UnmarshalMultiLanguageRecord(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	var result SummaryResult
+	err := json.Unmarshal([]byte(`{"summary":"summary","summaries":{"en":"summary","zh-CN":"摘要"}}`), &result)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(result.SummaryIn("zh-CN")).To(gomega.Equal("摘要"))
+	g.Expect(result.SummaryIn("en")).To(gomega.Equal("summary"))
+}
+
+func TestSummaryResult_MarshalSummaryDetail(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	result := &SummaryResult{
+		Path:    "https://brooker.co.za/blog/1",
+		Summary: "summary",
+		SummaryDetail: apitypes.SummaryDetail{
+			Thesis:      "thesis",
+			Keywords:    []string{"raft"},
+			ContentType: apitypes.ContentTypePaper,
+		},
+	}
+	data, err := json.Marshal(result)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(string(data)).To(gomega.ContainSubstring(`"thesis":"thesis","keywords":["raft"],"content_type":"paper"`))
+
+	var got SummaryResult
+	g.Expect(json.Unmarshal(data, &got)).To(gomega.Succeed())
+	g.Expect(got.SummaryDetail).To(gomega.Equal(result.SummaryDetail))
+}
+
+func TestLocalStorage_Query(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	s := NewStorage(dir)
+	g.Expect(s.(ioc.InitializingBean).AfterPropertiesSet(ctx)).To(gomega.Succeed())
+	g.Expect(s.Put(ctx, []*SummaryResult{
+		{
+			Domain:        "brooker",
+			Path:          "https://brooker.co.za/blog/1",
+			SummaryDetail: apitypes.SummaryDetail{Tags: []string{"consensus"}, Keywords: []string{"Raft"}},
+			PublishedAt:   time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Domain:        "brooker",
+			Path:          "https://brooker.co.za/blog/2",
+			SummaryDetail: apitypes.SummaryDetail{Tags: []string{"databases"}},
+			PublishedAt:   time.Date(2026, 9, 3, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Domain:        "charap",
+			Path:          "https://charap.co/3",
+			SummaryDetail: apitypes.SummaryDetail{Tags: []string{"consensus"}},
+			PublishedAt:   time.Date(2026, 10, 9, 0, 0, 0, 0, time.UTC),
+		},
+	})).To(gomega.Succeed())
+	g.Expect(s.SummaryExists(ctx, "https://charap.co/3")).To(gomega.BeTrue())
+
+	paths := func(q *Query) []string {
+		results, err := s.Query(ctx, q)
+		g.Expect(err).ToNot(gomega.HaveOccurred())
+		ret := []string{}
+		for _, result := range results {
+			ret = append(ret, result.Path)
+		}
+		return ret
+	}
+
+	since, err := ParseQueryTime("2026-10-01")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(paths(&Query{Tag: "Consensus", Since: since})).To(gomega.Equal([]string{
+		"https://charap.co/3",
+		"https://brooker.co.za/blog/1",
+	}))
+	g.Expect(paths(&Query{Domain: "brooker"})).To(gomega.HaveLen(2))
+	g.Expect(paths(&Query{Keyword: "raft"})).To(gomega.Equal([]string{"https://brooker.co.za/blog/1"}))
+	g.Expect(paths(&Query{Until: since})).To(gomega.Equal([]string{"https://brooker.co.za/blog/2"}))
+
+	// All results should be loaded by a new storage
+	s = NewStorage(dir)
+	g.Expect(s.(ioc.InitializingBean).AfterPropertiesSet(ctx)).To(gomega.Succeed())
+	g.Expect(paths(&Query{})).To(gomega.HaveLen(3))
+}
+
+func TestLocalStorage_Runs(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	s := NewStorage(dir)
+	g.Expect(s.(ioc.InitializingBean).AfterPropertiesSet(ctx)).To(gomega.Succeed())
+	g.Expect(s.PutRun(ctx, &Run{ID: "1", StartedAt: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)})).To(gomega.Succeed())
+	g.Expect(s.PutRun(ctx, &Run{ID: "2", StartedAt: time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)})).To(gomega.Succeed())
+
+	// The runs file should not be loaded as summary
+	s = NewStorage(dir)
+	g.Expect(s.(ioc.InitializingBean).AfterPropertiesSet(ctx)).To(gomega.Succeed())
+	runs, err := s.Runs(ctx, 0)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(runs).To(gomega.HaveLen(2))
+	g.Expect(runs[0].ID).To(gomega.Equal("2"))
+	results, err := s.Query(ctx, &Query{})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(results).To(gomega.BeEmpty())
+
+	_, err = s.Get(ctx, PostID("https://brooker.co.za/blog/1"))
+	g.Expect(err).To(gomega.MatchError(ErrNotFound))
+}
+
+func TestLocalStorage_Update(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	s := NewStorage(dir)
+	g.Expect(s.(ioc.InitializingBean).AfterPropertiesSet(ctx)).To(gomega.Succeed())
+	g.Expect(s.Put(ctx, []*SummaryResult{{Path: "https://charap.co/3", Summary: "old"}})).To(gomega.Succeed())
+	g.Expect(s.Update(ctx, []*SummaryResult{{Path: "https://charap.co/3", Summary: "new"}})).To(gomega.Succeed())
+
+	// The later record overrides the previous one when loaded
+	var logs bytes.Buffer
+	lctx := slogctx.NewCtx(ctx, slog.New(slog.NewTextHandler(&logs, nil)))
+	s = NewStorage(dir)
+	g.Expect(s.(ioc.InitializingBean).AfterPropertiesSet(lctx)).To(gomega.Succeed())
+	results, err := s.Query(lctx, &Query{})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(results).To(gomega.HaveLen(1))
+	g.Expect(results[0].Summary).To(gomega.Equal("new"))
+	g.Expect(logs.String()).ToNot(gomega.ContainSubstring("duplicate path in storage"))
+
+	// The duplicate path which is not updated is reported, e.g. appended by
+	// another process
+	files, err := filepath.Glob(filepath.Join(dir, "data", "*", "*.jsonl"))
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(files).To(gomega.HaveLen(1))
+	f, err := os.OpenFile(files[0], os.O_APPEND|os.O_WRONLY, 0)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(json.NewEncoder(f).Encode(&SummaryResult{Path: "https://charap.co/3", Summary: "dup"})).To(gomega.Succeed())
+	g.Expect(f.Close()).To(gomega.Succeed())
+	s = NewStorage(dir)
+	g.Expect(s.(ioc.InitializingBean).AfterPropertiesSet(lctx)).To(gomega.Succeed())
+	g.Expect(logs.String()).To(gomega.ContainSubstring("duplicate path in storage"))
+}
+
+func TestLocalStorage_ReloadChangedFiles(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// The reader is loaded before the writer persists, e.g. the serve command
+	// runs along with the daemon
+	reader := NewStorage(dir)
+	g.Expect(reader.(ioc.InitializingBean).AfterPropertiesSet(ctx)).To(gomega.Succeed())
+	writer := NewStorage(dir)
+	g.Expect(writer.(ioc.InitializingBean).AfterPropertiesSet(ctx)).To(gomega.Succeed())
+
+	g.Expect(writer.Put(ctx, []*SummaryResult{{Domain: "charap", Path: "https://charap.co/3", Summary: "old"}})).
+		To(gomega.Succeed())
+	g.Expect(writer.PutRun(ctx, &Run{ID: "1"})).To(gomega.Succeed())
+	results, err := reader.Query(ctx, &Query{})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(results).To(gomega.HaveLen(1))
+	runs, err := reader.Runs(ctx, 0)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(runs).To(gomega.HaveLen(1))
+
+	g.Expect(writer.Update(ctx, []*SummaryResult{{Domain: "charap", Path: "https://charap.co/3", Summary: "new"}})).
+		To(gomega.Succeed())
+	result, err := reader.Get(ctx, PostID("https://charap.co/3"))
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(result.Summary).To(gomega.Equal("new"))
+	domains, err := reader.Domains(ctx)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(domains).To(gomega.HaveLen(1))
+	g.Expect(domains[0].Posts).To(gomega.Equal(1))
+}
+
+func TestLocalStorage_UserState(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+
+	s := NewStorage(t.TempDir())
+	g.Expect(s.(ioc.InitializingBean).AfterPropertiesSet(ctx)).To(gomega.Succeed())
+
+	state, err := s.UserState(ctx, "alice")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(state.Post("1")).To(gomega.Equal(&PostState{}))
+
+	// The concurrent updates are not lost
+	var wg sync.WaitGroup
+	for _, id := range []string{"1", "2", "3", "4"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.Expect(s.UpdateUserState(ctx, "alice", func(state *UserState) {
+				state.Posts[id] = &PostState{Starred: true}
+			})).To(gomega.Succeed())
+		}()
+	}
+	wg.Wait()
+	state, err = s.UserState(ctx, "alice")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(state.Posts).To(gomega.HaveLen(4))
+	g.Expect(state.Post("1").Starred).To(gomega.BeTrue())
+
+	_, err = s.UserState(ctx, "../alice")
+	g.Expect(err).To(gomega.MatchError(errInvalidUser))
+	g.Expect(s.UpdateUserState(ctx, "../alice", func(*UserState) {})).To(gomega.MatchError(errInvalidUser))
+}
+
+func TestLocalStorage_ResummarizeQueue(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctx := context.Background()
+
+	s := NewStorage(t.TempDir())
+	g.Expect(s.(ioc.InitializingBean).AfterPropertiesSet(ctx)).To(gomega.Succeed())
+	g.Expect(s.EnqueueResummarize(ctx, "a")).To(gomega.Succeed())
+	g.Expect(s.EnqueueResummarize(ctx, "b")).To(gomega.Succeed())
+	g.Expect(s.EnqueueResummarize(ctx, "a")).To(gomega.Succeed())
+	g.Expect(s.ResummarizeQueue(ctx)).To(gomega.Equal([]string{"a", "b"}))
+
+	g.Expect(s.DequeueResummarize(ctx, "a")).To(gomega.Succeed())
+	g.Expect(s.ResummarizeQueue(ctx)).To(gomega.Equal([]string{"b"}))
+}
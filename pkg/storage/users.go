@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"time"
+)
+
+// usersDir is the dir of user states under the data dir, the state files
+// are not loaded as summary because they are not jsonl.
+const usersDir = "users"
+
+var (
+	errInvalidUser = errors.New("invalid user name")
+
+	userPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+)
+
+// PostState is the state of a post marked by user.
+type PostState struct {
+	Read      bool      `json:"read,omitempty"`
+	Starred   bool      `json:"starred,omitempty"`
+	Dismissed bool      `json:"dismissed,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UserState is the reading state of user.
+type UserState struct {
+	User string `json:"user"`
+	// LastSeenAt is the time the user marked all posts as seen, the posts
+	// collected after it are new.
+	LastSeenAt time.Time `json:"last_seen_at,omitzero"`
+	// Posts is the state of posts keyed by PostID
+	Posts map[string]*PostState `json:"posts"`
+}
+
+// Post return the state of post, it's never nil.
+func (s *UserState) Post(id string) *PostState {
+	if state, ok := s.Posts[id]; ok {
+		return state
+	}
+	return &PostState{}
+}
+
+// ValidateUser return error if the user name can't be used as file name.
+func ValidateUser(user string) error {
+	if !userPattern.MatchString(user) {
+		return fmt.Errorf("%w: %q", errInvalidUser, user)
+	}
+	return nil
+}
+
+// UserState implement Storage.UserState
+func (s *localStorage) UserState(_ context.Context, user string) (*UserState, error) {
+	err := ValidateUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.readUserState(user)
+}
+
+func (s *localStorage) readUserState(user string) (*UserState, error) {
+	state := &UserState{User: user}
+	b, err := os.ReadFile(s.userStateFile(user))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	} else {
+		err = json.Unmarshal(b, state)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if state.Posts == nil {
+		state.Posts = map[string]*PostState{}
+	}
+	return state, nil
+}
+
+// UpdateUserState implement Storage.UpdateUserState
+func (s *localStorage) UpdateUserState(_ context.Context, user string, update func(state *UserState)) error {
+	err := ValidateUser(user)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.readUserState(user)
+	if err != nil {
+		return err
+	}
+	update(state)
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(path.Join(s.dataPath, usersDir), 0755)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.userStateFile(user), b)
+}
+
+func (s *localStorage) userStateFile(user string) string {
+	return path.Join(s.dataPath, usersDir, user+".json")
+}
+
+// writeFileAtomic write to a temp file and rename it, so the reader of
+// another process never see a partial file.
+func writeFileAtomic(filename string, b []byte) error {
+	tmp := filename + ".tmp"
+	err := os.WriteFile(tmp, b, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
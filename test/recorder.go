@@ -0,0 +1,285 @@
+package test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"unicode/utf8"
+)
+
+// RecordEnv is the env to record the missing interactions from network,
+// e.g. VELA_RECORD=true go test ./pkg/agents/...
+const RecordEnv = "VELA_RECORD"
+
+var (
+	errNoInteraction = errors.New("no recorded interaction")
+	errBodyChanged   = errors.New("request body changed since it's recorded")
+)
+
+// Cassette is the recorded http interactions of a test.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded http request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the matching key of interaction, the headers are not
+// recorded because they may contain credentials.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	// BodySHA256 is the hash of request body, it's empty if the body is empty
+	BodySHA256 string `json:"body_sha256,omitempty"`
+}
+
+// RecordedResponse is the replayed response.
+type RecordedResponse struct {
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	// Body is the utf-8 body, the others are stored in BodyBase64
+	Body       string `json:"body,omitempty"`
+	BodyBase64 string `json:"body_base64,omitempty"`
+}
+
+// recordedHeaders is the response headers kept in cassette.
+var recordedHeaders = []string{"Content-Type"}
+
+// Recorder is a http.RoundTripper which replays the interactions in
+// testdata/<name>.json. The requests are matched by method, url and body, the
+// repeated ones are replayed in the recorded order. A request whose body is
+// changed since it's recorded fails the test. With VELA_RECORD=true the
+// missing interactions are sent by Transport and saved when the test is done.
+type Recorder struct {
+	t    testing.TB
+	path string
+
+	// Record is true if the missing interactions are recorded
+	Record bool
+	// Transport sends the requests to be recorded, it defaults to
+	// http.DefaultTransport
+	Transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	replayed map[*Interaction]bool
+	dirty    bool
+}
+
+var _ http.RoundTripper = (*Recorder)(nil)
+
+// NewRecorder load the cassette testdata/<name>.json of current package, it
+// fails the test if the cassette is missing and not in record mode.
+func NewRecorder(t testing.TB, name string) *Recorder {
+	t.Helper()
+
+	r := &Recorder{
+		t:        t,
+		path:     filepath.Join("testdata", name+".json"),
+		Record:   os.Getenv(RecordEnv) == "true",
+		replayed: make(map[*Interaction]bool),
+	}
+	b, err := os.ReadFile(r.path)
+	switch {
+	case err == nil:
+		err = json.Unmarshal(b, &r.cassette)
+		if err != nil {
+			t.Fatalf("decode cassette %s failed: %v", r.path, err)
+		}
+	case os.IsNotExist(err) && r.Record:
+	default:
+		t.Fatalf("read cassette %s failed: %v, record it with %s=true", r.path, err, RecordEnv)
+	}
+
+	t.Cleanup(func() {
+		err := r.save()
+		if err != nil {
+			t.Errorf("save cassette %s failed: %v", r.path, err)
+		}
+	})
+	return r
+}
+
+// Client return a http client which sends requests by the recorder.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implement http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+	}
+	recorded := RecordedRequest{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		BodySHA256: bodyHash(body),
+	}
+
+	r.mu.Lock()
+	interaction := r.find(recorded)
+	changed := interaction == nil && r.recorded(recorded)
+	r.mu.Unlock()
+	if interaction != nil {
+		return interaction.Response.toResponse(req), nil
+	}
+	if !r.Record {
+		if changed {
+			err := fmt.Errorf("%w: %s %s, re-record it with %s=true", errBodyChanged, req.Method, recorded.URL, RecordEnv)
+			r.t.Error(err)
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %s %s, record it with %s=true", errNoInteraction, req.Method, recorded.URL, RecordEnv)
+	}
+
+	interaction, err := r.record(req, recorded, body)
+	if err != nil {
+		return nil, err
+	}
+	return interaction.Response.toResponse(req), nil
+}
+
+// find return the first interaction of request which is not replayed, or the
+// last one if all of them are replayed.
+func (r *Recorder) find(req RecordedRequest) *Interaction {
+	var last *Interaction
+	for _, interaction := range r.cassette.Interactions {
+		if interaction.Request != req {
+			continue
+		}
+		last = interaction
+		if r.replayed[interaction] {
+			continue
+		}
+		r.replayed[interaction] = true
+		return interaction
+	}
+	return last
+}
+
+// recorded return true if there is an interaction with the same method and
+// url of request, whatever the body is.
+func (r *Recorder) recorded(req RecordedRequest) bool {
+	for _, interaction := range r.cassette.Interactions {
+		if interaction.Request.Method == req.Method && interaction.Request.URL == req.URL {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Recorder) record(req *http.Request, recorded RecordedRequest, body []byte) (*Interaction, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	outReq := req.Clone(req.Context())
+	outReq.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := transport.RoundTrip(outReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	response := RecordedResponse{Status: resp.StatusCode}
+	for _, key := range recordedHeaders {
+		if v := resp.Header.Get(key); v != "" {
+			if response.Header == nil {
+				response.Header = make(map[string]string)
+			}
+			response.Header[key] = v
+		}
+	}
+	if utf8.Valid(respBody) {
+		response.Body = string(respBody)
+	} else {
+		response.BodyBase64 = base64.StdEncoding.EncodeToString(respBody)
+	}
+
+	interaction := &Interaction{Request: recorded, Response: response}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.replayed[interaction] = true
+	r.dirty = true
+	return interaction, nil
+}
+
+func (r *Recorder) save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.dirty {
+		return nil
+	}
+
+	// The html is not escaped to keep the cassette readable
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(&r.cassette)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(r.path), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, buf.Bytes(), 0600)
+}
+
+func (resp *RecordedResponse) toResponse(req *http.Request) *http.Response {
+	body := []byte(resp.Body)
+	if resp.BodyBase64 != "" {
+		// The cassette is checked when it's recorded
+		body, _ = base64.StdEncoding.DecodeString(resp.BodyBase64)
+	}
+
+	header := make(http.Header, len(resp.Header))
+	for k, v := range resp.Header {
+		header.Set(k, v)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.Status, http.StatusText(resp.Status)),
+		StatusCode:    resp.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func bodyHash(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
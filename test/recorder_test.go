@@ -0,0 +1,83 @@
+package test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestRecorder(t *testing.T) {
+	g := gomega.NewWithT(t)
+	t.Chdir(t.TempDir())
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Set-Cookie", "secret")
+		_, _ = w.Write([]byte(r.URL.Path + ":" + string(body)))
+	}))
+	defer server.Close()
+
+	get := func(g *gomega.WithT, client *http.Client, method string, body string) (string, error) {
+		req, err := http.NewRequest(method, server.URL+"/a", strings.NewReader(body))
+		g.Expect(err).ToNot(gomega.HaveOccurred())
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close() //nolint
+		g.Expect(resp.Header.Get("Set-Cookie")).To(gomega.BeEmpty())
+		b, err := io.ReadAll(resp.Body)
+		return string(b), err
+	}
+
+	t.Run("record", func(t *testing.T) {
+		g := gomega.NewWithT(t)
+		t.Setenv(RecordEnv, "true")
+		r := NewRecorder(t, "cassette")
+		g.Expect(get(g, r.Client(), http.MethodPost, "1")).To(gomega.Equal("/a:1"))
+		g.Expect(get(g, r.Client(), http.MethodPost, "2")).To(gomega.Equal("/a:2"))
+	})
+	g.Expect(requests).To(gomega.Equal(2))
+	g.Expect(os.ReadFile("testdata/cassette.json")).ToNot(gomega.ContainSubstring("secret"))
+
+	t.Run("replay", func(t *testing.T) {
+		g := gomega.NewWithT(t)
+		r := NewRecorder(t, "cassette")
+		g.Expect(get(g, r.Client(), http.MethodPost, "1")).To(gomega.Equal("/a:1"))
+		g.Expect(get(g, r.Client(), http.MethodPost, "2")).To(gomega.Equal("/a:2"))
+		// The last one is replayed if all of them are replayed
+		g.Expect(get(g, r.Client(), http.MethodPost, "2")).To(gomega.Equal("/a:2"))
+
+		_, err := get(g, r.Client(), http.MethodGet, "")
+		g.Expect(err).To(gomega.MatchError(errNoInteraction))
+	})
+
+	t.Run("changed body", func(t *testing.T) {
+		g := gomega.NewWithT(t)
+		tb := &errorTB{TB: t}
+		r := NewRecorder(tb, "cassette")
+		_, err := get(g, r.Client(), http.MethodPost, "3")
+		g.Expect(err).To(gomega.MatchError(errBodyChanged))
+		g.Expect(tb.errors).To(gomega.HaveLen(1))
+	})
+	g.Expect(requests).To(gomega.Equal(2))
+}
+
+// errorTB keeps the errors instead of failing the test.
+type errorTB struct {
+	testing.TB
+	errors []string
+}
+
+func (tb *errorTB) Error(args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprint(args...))
+}